@@ -2,9 +2,24 @@ package assets
 
 import (
 	"embed"
+	"fmt"
+	"io/fs"
 )
 
 // FS contains embedded templates and static assets.
 //
 //go:embed templates/*.html web/static/*.css static/*
 var FS embed.FS
+
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationsFS embed.FS
+
+// Migrations returns the embedded SQL migrations for a database driver
+// ("sqlite" or "postgres") with file names at the root, as expected by
+// persistence.DB.Migrate.
+func Migrations(driver string) (fs.FS, error) {
+	if _, err := fs.Stat(migrationsFS, "migrations/"+driver); err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+	return fs.Sub(migrationsFS, "migrations/"+driver)
+}
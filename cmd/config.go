@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"peso/internal/config"
+)
+
+const configUsage = `Usage: peso [flags] config print
+
+Prints the effective configuration, after the config file, environment
+and flags are applied, as YAML that --config accepts. Secrets are
+redacted. Invalid settings are reported instead.
+`
+
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return errors.New("expected config print")
+	}
+	if cfg.File != "" {
+		fmt.Printf("# Loaded from %s\n", cfg.File)
+	}
+	return cfg.Print(os.Stdout)
+}
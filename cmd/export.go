@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"peso/internal/application"
+	"peso/internal/config"
+	"peso/internal/domain/idempotency"
+	"peso/internal/domain/weight"
+)
+
+const importUsage = `Usage: peso import -user USER [-unit kg|lb] FILE
+
+Records the weights in the CSV file FILE, or standard input for -, for
+USER, an email address or user ID. The first row names the columns:
+
+  measured_at  RFC 3339, or a local date and time such as 2026-01-31 07:30
+  weight       the value
+  unit         kg or lb, optional (default -unit)
+  notes        optional
+
+Every row is checked before anything is recorded. Importing the same rows
+again skips them, so an interrupted import can simply be rerun.
+
+Flags:
+  -user USER   user to import for
+  -unit U      unit of rows without one (default kg)
+`
+
+const exportUsage = `Usage: peso export -user USER [-o FILE]
+
+Writes every weight of USER, an email address or user ID, as CSV in the
+format peso import reads, oldest first.
+
+Flags:
+  -user USER   user to export
+  -o FILE      write to FILE instead of standard output
+`
+
+// csvColumns are the columns written by export and read by import.
+var csvColumns = []string{"measured_at", "weight", "unit", "notes"}
+
+func runImport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, importUsage) }
+	userRef := flags.String("user", "", "email address or ID of the user")
+	unit := flags.String("unit", string(weight.WeightUnitKg), "unit of rows without one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *userRef == "" || flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, importUsage)
+		return errors.New("expected -user and exactly one file")
+	}
+
+	in := io.Reader(os.Stdin)
+	if name := flags.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	entries, lines, err := readWeightsCSV(in, *unit)
+	if err != nil {
+		return err
+	}
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+	ctx := context.Background()
+
+	u, err := findUser(ctx, services, *userRef)
+	if err != nil {
+		return err
+	}
+
+	results, err := services.WeightTracker.RecordWeights(ctx, u.ID(), entries)
+	counts := map[application.EntryStatus]int{}
+	for i, result := range results {
+		counts[result.Status]++
+		if result.Status == application.EntryRejected {
+			fmt.Fprintf(os.Stderr, "line %d: %v\n", lines[i], result.Err)
+		}
+	}
+	fmt.Printf("Imported %d weights for %s, %d already present, %d rejected\n",
+		counts[application.EntryCreated], userLabel(u), counts[application.EntryDuplicate], counts[application.EntryRejected])
+	if err != nil {
+		return fmt.Errorf("import interrupted, rerun it to continue: %w", err)
+	}
+	if n := counts[application.EntryRejected]; n > 0 {
+		return fmt.Errorf("%d rows rejected", n)
+	}
+	return nil
+}
+
+// readWeightsCSV parses every row of r, reporting all malformed rows at
+// once, and returns the entries with the line each came from. Rows are
+// keyed by their content so importing them again records nothing new.
+func readWeightsCSV(r io.Reader, defaultUnit string) ([]application.WeightEntry, []int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("empty file, expected a header row")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	column := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return nil, nil, fmt.Errorf("line 1: unknown column %q, expected %s", name, strings.Join(csvColumns, ", "))
+		}
+		column[name] = i
+	}
+	for _, name := range csvColumns[:2] {
+		if _, ok := column[name]; !ok {
+			return nil, nil, fmt.Errorf("line 1: missing column %q", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := column[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []application.WeightEntry
+	var lines []int
+	var errs []error
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		measuredAt, err := parseTime(field(record, "measured_at"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		value, err := strconv.ParseFloat(field(record, "weight"), 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %q is not a number", line, field(record, "weight")))
+			continue
+		}
+		unit := field(record, "unit")
+		if unit == "" {
+			unit = defaultUnit
+		}
+		notes := field(record, "notes")
+
+		key := "import-" + idempotency.Fingerprint(measuredAt.UTC().Format(time.RFC3339Nano), strconv.FormatFloat(value, 'f', -1, 64), unit, notes)
+		entries = append(entries, application.WeightEntry{Key: key, Value: value, Unit: unit, MeasuredAt: measuredAt, Notes: notes})
+		lines = append(lines, line)
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return entries, lines, nil
+}
+
+func runExport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, exportUsage) }
+	userRef := flags.String("user", "", "email address or ID of the user")
+	output := flags.String("o", "", "file to write")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *userRef == "" || flags.NArg() > 0 {
+		fmt.Fprint(os.Stderr, exportUsage)
+		return errors.New("expected -user and no arguments")
+	}
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+	ctx := context.Background()
+
+	u, err := findUser(ctx, services, *userRef)
+	if err != nil {
+		return err
+	}
+	weights, err := services.WeightTracker.GetWeightHistory(ctx, u.ID(), application.TimePeriodAll)
+	if err != nil {
+		return err
+	}
+	slices.SortStableFunc(weights, func(a, b *weight.Weight) int {
+		return a.MeasuredAt().Compare(b.MeasuredAt())
+	})
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+	}
+	writer := csv.NewWriter(out)
+	writer.Write(csvColumns)
+	for _, w := range weights {
+		writer.Write([]string{
+			w.MeasuredAt().Format(time.RFC3339),
+			strconv.FormatFloat(w.Value().Float64(), 'f', -1, 64),
+			w.Unit().String(),
+			w.Notes(),
+		})
+	}
+	writer.Flush()
+	err = writer.Error()
+	if *output != "" {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			fmt.Printf("Exported %d weights of %s to %s\n", len(weights), userLabel(u), *output)
+		}
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"peso/internal/config"
+)
+
+const healthcheckUsage = `Usage: peso [flags] healthcheck [-path P] [-timeout D]
+
+Asks the server running with the same configuration whether it is alive,
+for container health checks. The port and scheme come from PORT and the
+TLS settings, so the probe always matches the listener. Exits 0 when the
+server answers 200.
+
+/live is the default because runtimes restart unhealthy containers, and
+/ready also fails while draining on shutdown and while the database is
+briefly unavailable. Leave /ready to load balancers.
+
+Flags:
+  -path P      endpoint to probe, /live or /ready (default /live)
+  -timeout D   give up after D (default 3s)
+`
+
+func runHealthcheck(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, healthcheckUsage) }
+	path := flags.String("path", "/live", "endpoint to probe")
+	timeout := flags.Duration("timeout", 3*time.Second, "request timeout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	scheme := "http"
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if cfg.TLSEnabled() {
+		scheme = "https"
+		// The certificate names the public host, not the loopback address
+		// probed here.
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	client := &http.Client{Transport: transport, Timeout: *timeout}
+
+	url := scheme + "://127.0.0.1:" + cfg.Port + "/" + strings.TrimPrefix(*path, "/")
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The body ends up in the container's health log.
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	fmt.Println(strings.TrimSpace(string(body)))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return nil
+}
@@ -1,50 +1,82 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
-	"peso/internal/app"
 	"peso/internal/config"
 )
 
-func main() {
-	cfg := config.Load()
+const usage = "Usage: peso [flags] [command]\n\n" + commandsUsage
+
+// commandsUsage follows the configuration flags in the output of peso -h.
+const commandsUsage = `Commands:
+  serve          run the web server (default)
+  user           create, list, deactivate users and reset passwords
+  weight         record and list weights
+  import         record weights from a CSV file
+  export         write weights as CSV
+  stats          summarise users, weights and goals
+  config         print the effective configuration
+  healthcheck    probe the running server
+  migrate        apply, revert and create database migrations
+  backup         snapshot the SQLite database
+  restore        replace the SQLite database with a snapshot
+
+Run peso <command> -h for the flags of a command, and peso -h for the
+configuration flags, which go before the command.
+`
 
-	application, err := app.New(cfg)
+// commands are run against the loaded configuration with the arguments
+// after their name.
+var commands = map[string]func(cfg *config.Config, args []string) error{
+	"serve":       runServe,
+	"user":        runUser,
+	"weight":      runWeight,
+	"import":      runImport,
+	"export":      runExport,
+	"stats":       runStats,
+	"config":      runConfig,
+	"healthcheck": runHealthcheck,
+	"migrate":     runMigrate,
+	"backup":      runBackup,
+	"restore":     runRestore,
+}
+
+func main() {
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(os.Stderr, "\n"+commandsUsage)
+		return
+	}
 	if err != nil {
-		log.Printf("failed to initialize application: %v", err)
-		os.Exit(1)
+		log.Printf("invalid configuration:\n%v", err)
+		os.Exit(2)
 	}
-	defer application.Close()
-
-	errChan := make(chan error, 1)
-	go func() {
-		errChan <- application.Run()
-	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	select {
-	case err := <-errChan:
-		if err != nil {
-			log.Printf("server error: %v", err)
-			os.Exit(1)
-		}
-	case sig := <-quit:
-		log.Printf("received signal: %v", sig)
+
+	// Without a command the server starts, as it always has.
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		fmt.Print(usage)
+		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		log.Printf("unknown command %q", name)
+		os.Exit(2)
+	}
 
-	if err := application.Shutdown(ctx); err != nil {
-		log.Printf("shutdown error: %v", err)
+	// -h prints the usage of the command, which is not a failure.
+	if err := run(cfg, args); err != nil && !errors.Is(err, flag.ErrHelp) {
+		log.Printf("%s: %v", name, err)
 		os.Exit(1)
 	}
 }
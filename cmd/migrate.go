@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	assets "peso"
+	"peso/internal/config"
+	"peso/internal/infrastructure/persistence"
+)
+
+const migrateUsage = `Usage: peso migrate <command> [flags]
+
+Commands:
+  up                 apply all pending migrations
+  down [-steps N]    revert the last N applied migrations (default 1)
+  status             list migrations and whether they are applied
+  create [-dir D] NAME
+                     write an empty NNN_name.up.sql/.down.sql pair in D
+                     (default ./migrations/$DB_DRIVER); rebuild to embed it
+`
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return errors.New("missing migrate command")
+	}
+	command, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+	dir := flags.String("dir", "./migrations/"+cfg.DBDriver, "directory for new migrations")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if command == "create" {
+		name := strings.Join(flags.Args(), "_")
+		up, down, err := persistence.CreateMigration(*dir, name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s\nCreated %s\n", up, down)
+		return nil
+	}
+
+	migrations, err := assets.Migrations(cfg.DBDriver)
+	if err != nil {
+		return err
+	}
+
+	db, err := persistence.Open(cfg.DBDriver, cfg.DBSource())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch command {
+	case "up":
+		return db.Migrate(migrations)
+	case "down":
+		if *steps < 1 {
+			return errors.New("-steps must be at least 1")
+		}
+		return db.MigrateDown(migrations, *steps)
+	case "status":
+		return printMigrationStatus(db, migrations)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
+
+func printMigrationStatus(db *persistence.DB, migrations fs.FS) error {
+	statuses, err := db.MigrationStatus(migrations)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Modified {
+			state = "modified"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
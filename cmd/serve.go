@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"peso/internal/app"
+	"peso/internal/config"
+)
+
+const serveUsage = `Usage: peso [flags] serve
+
+Runs the web server until SIGINT or SIGTERM, then drains in-flight
+requests for up to SHUTDOWN_TIMEOUT. A second signal stops at once.
+Pending migrations are applied on start.
+`
+
+func runServe(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, serveUsage) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprint(os.Stderr, serveUsage)
+		return errors.New("unexpected arguments")
+	}
+
+	application, err := app.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize application: %w", err)
+	}
+	defer application.Close()
+
+	application.Start()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- application.Run()
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			return fmt.Errorf("server error: %w", err)
+		}
+	case sig := <-quit:
+		log.Printf("received signal: %v", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	// A second signal gives up on draining.
+	go func() {
+		<-quit
+		cancel()
+	}()
+
+	if err := application.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shutdown error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"peso/internal/application"
+	"peso/internal/config"
+	"peso/internal/domain/user"
+)
+
+const statsUsage = `Usage: peso stats [-user USER]
+
+Summarises every active user, or only USER, an email address or user ID:
+weights recorded, the latest one, the change over the last month and the
+progress towards the active goal.
+
+Flags:
+  -user USER   only summarise this user
+`
+
+func runStats(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, statsUsage) }
+	userRef := flags.String("user", "", "email address or ID of the user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprint(os.Stderr, statsUsage)
+		return errors.New("unexpected arguments")
+	}
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+	ctx := context.Background()
+
+	var users []*user.User
+	if *userRef != "" {
+		u, err := findUser(ctx, services, *userRef)
+		if err != nil {
+			return err
+		}
+		users = []*user.User{u}
+	} else {
+		if users, err = services.AuthService.ListUsers(ctx); err != nil {
+			return err
+		}
+		sessions, err := services.AuthService.CountActiveSessions(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Active users: %d\nActive sessions: %d\n\n", len(users), sessions)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tWEIGHTS\tLATEST\tMEASURED\tLAST MONTH\tGOAL")
+	for _, u := range users {
+		row, err := userStats(ctx, services.WeightTracker, services.GoalTracker, u)
+		if err != nil {
+			return fmt.Errorf("%s: %w", userLabel(u), err)
+		}
+		fmt.Fprintln(w, row)
+	}
+	return w.Flush()
+}
+
+// userStats formats one row of the stats table, with - for what the user
+// has not recorded yet.
+func userStats(ctx context.Context, weights *application.WeightTracker, goals *application.GoalTracker, u *user.User) (string, error) {
+	history, err := weights.GetWeightHistory(ctx, u.ID(), application.TimePeriodAll)
+	if err != nil {
+		return "", err
+	}
+	if len(history) == 0 {
+		return fmt.Sprintf("%s\t0\t-\t-\t-\t-", userLabel(u)), nil
+	}
+
+	latest, err := weights.GetLatestWeight(ctx, u.ID())
+	if err != nil {
+		return "", err
+	}
+
+	change := "-"
+	trend, err := weights.CalculateWeightTrend(ctx, u.ID(), application.TimePeriodLastMonth)
+	if err != nil {
+		return "", err
+	}
+	switch trend.Direction {
+	case application.TrendIncreasing:
+		change = fmt.Sprintf("+%s %s", trend.TotalChange, latest.Unit())
+	case application.TrendDecreasing:
+		change = fmt.Sprintf("-%s %s", trend.TotalChange, latest.Unit())
+	case application.TrendStable:
+		change = "stable"
+	}
+
+	goal := "-"
+	progress, err := goals.CalculateProgress(ctx, u.ID())
+	switch {
+	case errors.Is(err, application.ErrNoActiveGoal):
+	case err != nil:
+		return "", err
+	default:
+		track := "behind"
+		if progress.IsOnTrack {
+			track = "on track"
+		}
+		goal = fmt.Sprintf("%s %s by %s, %s %s to go, %s",
+			progress.Goal.TargetWeight(), progress.Goal.Unit(), progress.Goal.TargetDate(),
+			progress.WeightToLose, progress.Goal.Unit(), track)
+	}
+
+	return fmt.Sprintf("%s\t%d\t%s %s\t%s\t%s\t%s",
+		userLabel(u), len(history), latest.Value(), latest.Unit(),
+		latest.MeasuredAt().Format("2006-01-02 15:04"), change, goal), nil
+}
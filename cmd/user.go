@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"peso/internal/app"
+	"peso/internal/config"
+	"peso/internal/domain/user"
+)
+
+const userUsage = `Usage: peso user <command> [flags]
+
+Commands:
+  create -name NAME [-password-stdin] EMAIL
+                     add an account; without a password the user
+                     chooses one on their first sign-in
+  list               list the active accounts
+  deactivate USER    disable an account and sign it out everywhere;
+                     its data is kept
+  reset-password -password-stdin USER
+                     replace the password and sign out everywhere
+
+USER is an email address or user ID. Passwords are read from the first
+line of standard input so they stay out of the shell history and ps.
+`
+
+func runUser(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, userUsage)
+		return errors.New("missing user command")
+	}
+	command, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("user "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, userUsage) }
+	name := flags.String("name", "", "display name of the new user")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from standard input")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	wantArgs := 1
+	if command == "list" {
+		wantArgs = 0
+	}
+	if flags.NArg() != wantArgs {
+		fmt.Fprint(os.Stderr, userUsage)
+		return fmt.Errorf("user %s expects %d argument(s), got %d", command, wantArgs, flags.NArg())
+	}
+
+	var password string
+	if *passwordStdin {
+		var err error
+		if password, err = readPassword(os.Stdin); err != nil {
+			return err
+		}
+	}
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+	ctx := context.Background()
+	auth := services.AuthService
+
+	switch command {
+	case "create":
+		if *name == "" {
+			fmt.Fprint(os.Stderr, userUsage)
+			return errors.New("-name is required")
+		}
+		u, err := auth.CreateUser(ctx, *name, flags.Arg(0), password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created user %s (%s)\n", userLabel(u), u.ID())
+		return nil
+	case "list":
+		users, err := auth.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		return printUsers(users)
+	case "deactivate":
+		u, err := findUser(ctx, services, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := auth.DeactivateUser(ctx, u.ID()); err != nil {
+			return err
+		}
+		fmt.Printf("Deactivated user %s (%s)\n", userLabel(u), u.ID())
+		return nil
+	case "reset-password":
+		if !*passwordStdin {
+			fmt.Fprint(os.Stderr, userUsage)
+			return errors.New("-password-stdin is required")
+		}
+		u, err := findUser(ctx, services, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := auth.ResetPassword(ctx, u.ID(), password); err != nil {
+			return err
+		}
+		fmt.Printf("Reset password of %s (%s)\n", userLabel(u), u.ID())
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, userUsage)
+		return fmt.Errorf("unknown user command %q", command)
+	}
+}
+
+func printUsers(users []*user.User) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME\tPASSWORD\tCREATED")
+	for _, u := range users {
+		password := "no"
+		if u.HasPassword() {
+			password = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", u.ID(), u.Email(), u.Name(), password, u.CreatedAt().Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// readPassword reads the first line of r, without its line ending.
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("empty password on standard input")
+	}
+	return password, nil
+}
+
+// openServices connects the application services to the configured
+// database. Slow queries and failed event handlers are logged to stderr so
+// they never mix with the output of a command.
+func openServices(cfg *config.Config) (*app.Services, error) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	return app.Open(cfg, logger)
+}
+
+// findUser resolves the USER argument of a command.
+func findUser(ctx context.Context, services *app.Services, emailOrID string) (*user.User, error) {
+	u, err := services.AuthService.FindUser(ctx, emailOrID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", emailOrID, err)
+	}
+	return u, nil
+}
+
+// userLabel names a user by email, or by ID for the accounts created before
+// sign-in existed, which have none.
+func userLabel(u *user.User) string {
+	if u.Email() == "" {
+		return u.ID().String()
+	}
+	return u.Email()
+}
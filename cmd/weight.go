@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"peso/internal/config"
+	"peso/internal/domain/weight"
+)
+
+const weightUsage = `Usage: peso weight <command> -user USER [flags]
+
+Commands:
+  add [-unit kg|lb] [-at TIME] [-notes TEXT] VALUE
+                     record a weight, measured now unless -at is given
+  list [-limit N]    list the N most recent weights (default 20)
+
+USER is an email address or user ID. TIME is RFC 3339, or a local date
+and time such as 2026-01-31 or "2026-01-31 07:30".
+`
+
+// timeLayouts are accepted for measurement times, the local ones in the
+// server's time zone.
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+func runWeight(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, weightUsage)
+		return errors.New("missing weight command")
+	}
+	command, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("weight "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, weightUsage) }
+	userRef := flags.String("user", "", "email address or ID of the user")
+	unit := flags.String("unit", string(weight.WeightUnitKg), "unit of the weight")
+	at := flags.String("at", "", "when the weight was measured")
+	notes := flags.String("notes", "", "notes on the measurement")
+	limit := flags.Int("limit", 20, "number of weights to list")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *userRef == "" {
+		fmt.Fprint(os.Stderr, weightUsage)
+		return errors.New("-user is required")
+	}
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+	ctx := context.Background()
+
+	u, err := findUser(ctx, services, *userRef)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "add":
+		if flags.NArg() != 1 {
+			fmt.Fprint(os.Stderr, weightUsage)
+			return errors.New("expected exactly one weight value")
+		}
+		number, err := strconv.ParseFloat(flags.Arg(0), 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", flags.Arg(0))
+		}
+		value, err := weight.NewWeightValue(number)
+		if err != nil {
+			return err
+		}
+		weightUnit, err := weight.NewWeightUnit(*unit)
+		if err != nil {
+			return err
+		}
+		measuredAt := time.Now()
+		if *at != "" {
+			if measuredAt, err = parseTime(*at); err != nil {
+				return err
+			}
+		}
+
+		w, err := services.WeightTracker.RecordWeight(ctx, u.ID(), value, weightUnit, measuredAt, *notes)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %s %s for %s at %s (%s)\n", w.Value(), w.Unit(), userLabel(u), w.MeasuredAt().Format(time.RFC3339), w.ID())
+		return nil
+	case "list":
+		if *limit < 1 {
+			return errors.New("-limit must be at least 1")
+		}
+		weights, err := services.WeightTracker.GetRecentWeights(ctx, u.ID(), *limit)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tMEASURED\tWEIGHT\tNOTES")
+		for _, entry := range weights {
+			fmt.Fprintf(w, "%s\t%s\t%s %s\t%s\n", entry.ID(), entry.MeasuredAt().Format("2006-01-02 15:04"), entry.Value(), entry.Unit(), entry.Notes())
+		}
+		return w.Flush()
+	default:
+		fmt.Fprint(os.Stderr, weightUsage)
+		return fmt.Errorf("unknown weight command %q", command)
+	}
+}
+
+// parseTime reads a measurement time in one of timeLayouts.
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time such as 2026-01-31T07:30:00Z or 2026-01-31 07:30", s)
+}
@@ -2,83 +2,488 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
-	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
+	assets "peso"
 	"peso/internal/application"
 	"peso/internal/config"
+	"peso/internal/domain/event"
+	"peso/internal/infrastructure/backup"
+	"peso/internal/infrastructure/certs"
+	"peso/internal/infrastructure/eventbus"
+	"peso/internal/infrastructure/health"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/live"
 	"peso/internal/infrastructure/logging"
+	"peso/internal/infrastructure/metrics"
 	"peso/internal/infrastructure/persistence"
+	"peso/internal/infrastructure/tracing"
 	"peso/internal/infrastructure/web"
+	"peso/internal/infrastructure/webhooks"
 )
 
+// certReloadInterval is how often the TLS certificate files are checked
+// for changes.
+const certReloadInterval = time.Minute
+
+// readinessTimeout bounds how long the readiness checks may take together.
+const readinessTimeout = 2 * time.Second
+
+// purgeInterval is how often deleted records past their retention are
+// removed.
+const purgeInterval = time.Hour
+
 type App struct {
-	config *config.Config
-	logger *slog.Logger
-	db     *persistence.DB
-	server *http.Server
+	config      *config.Config
+	logger      *slog.Logger
+	db          *persistence.DB
+	server      *http.Server
+	health      *health.Checker
+	authService *application.AuthService
+	backups     *backup.Manager
+	events      *eventbus.Bus
+	webhooks    *webhooks.Dispatcher
+
+	weightTracker *application.WeightTracker
+	goalTracker   *application.GoalTracker
+	idempotency   *application.Idempotency
+
+	certs          *certs.Reloader
+	redirectServer *http.Server
+
+	// Background jobs run under jobsCtx from Start until Shutdown or
+	// Close cancels it.
+	jobsCtx  context.Context
+	stopJobs context.CancelFunc
+	jobs     sync.WaitGroup
+
+	shutdownTracing func(context.Context) error
 }
 
 func New(cfg *config.Config) (*App, error) {
 	logger := logging.NewLogger(cfg.LogLevel)
 
-	db, err := persistence.NewDB(cfg.DBPath)
+	defaultLocale, ok := i18n.Parse(cfg.DefaultLocale)
+	if !ok {
+		return nil, fmt.Errorf("unsupported DEFAULT_LOCALE %q", cfg.DefaultLocale)
+	}
+
+	migrations, err := assets.Migrations(cfg.DBDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.TracingEnabled {
+		shutdownTracing, err = tracing.Setup(context.Background())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := persistence.Open(cfg.DBDriver, cfg.DBSource())
 	if err != nil {
+		shutdownTracing(context.Background())
 		return nil, err
 	}
 
-	migrationsDir := os.DirFS("./migrations")
-	if err := db.Migrate(migrationsDir); err != nil {
+	db.LogSlowQueries(logger, cfg.SlowQueryThreshold)
+	m := metrics.New()
+	db.ObserveQueries(m.ObserveQuery)
+
+	if err := db.Migrate(migrations); err != nil {
 		db.Close()
+		shutdownTracing(context.Background())
 		return nil, err
 	}
 
-	userRepo := persistence.NewUserRepository(db)
-	weightRepo := persistence.NewWeightRepository(db)
-	goalRepo := persistence.NewGoalRepository(db)
-	sessionRepo := persistence.NewSessionRepository(db)
+	events := eventbus.New(logger)
+	services := newServices(cfg, db, events)
+	authService := services.AuthService
+
+	events.Subscribe(event.NameWeightRecorded, m.OnEvent)
+	m.Registry().NewGaugeFunc("peso_active_sessions", "Sessions that have not expired.", func(ctx context.Context) (float64, error) {
+		n, err := authService.CountActiveSessions(ctx)
+		return float64(n), err
+	})
+	broker := live.NewBroker()
+	events.Subscribe(eventbus.All, broker.OnEvent)
+	events.SubscribeAsync(eventbus.All, func(ctx context.Context, e event.Event) error {
+		logger.DebugContext(ctx, "domain_event",
+			slog.String("event", e.Name()),
+			slog.String("subject_id", e.Subject().String()),
+		)
+		return nil
+	})
 
-	weightTracker := application.NewWeightTracker(userRepo, weightRepo)
-	goalTracker := application.NewGoalTracker(userRepo, weightRepo, goalRepo)
-	authService := application.NewAuthService(userRepo, sessionRepo)
+	var backups *backup.Manager
+	if db.Dialect() == persistence.DialectSQLite {
+		backups = backup.NewManager(db, cfg.BackupDir, backup.Retention{
+			Last:   cfg.BackupKeepLast,
+			Daily:  cfg.BackupKeepDaily,
+			Weekly: cfg.BackupKeepWeekly,
+		}, logger)
+	}
 
-	if err := authService.CleanupExpiredSessions(); err != nil {
-		logger.Warn("failed_to_cleanup_sessions", slog.Any("error", err))
+	checker := health.NewChecker(readinessTimeout)
+	checker.Register("database", db.PingContext)
+	checker.Register("migrations", func(ctx context.Context) error {
+		pending, err := db.PendingMigrations(ctx, migrations)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations, first %s", len(pending), pending[0])
+		}
+		return nil
+	})
+	if db.Dialect() == persistence.DialectSQLite {
+		checker.Register("data_dir", health.DirWritable(filepath.Dir(cfg.DBPath)))
 	}
 
-	router := web.NewRouter(weightTracker, goalTracker, authService, userRepo, logger)
+	router := web.NewRouter(cfg, defaultLocale, services.WeightTracker, services.GoalTracker, authService, services.AuditLog, services.WebhookService, services.Idempotency, services.users, backups, broker, m, checker, logger)
 
 	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+		Addr:              ":" + cfg.Port,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	server.RegisterOnShutdown(broker.Close)
+
+	app := &App{
+		config:      cfg,
+		logger:      logger,
+		db:          db,
+		server:      server,
+		health:      checker,
+		authService: authService,
+		backups:     backups,
+		events:      events,
+		webhooks:    webhooks.NewDispatcher(services.webhooks, services.deliveries, db, cfg.WebhookAllowPrivateNetworks, logger),
+
+		weightTracker: services.WeightTracker,
+		goalTracker:   services.GoalTracker,
+		idempotency:   services.Idempotency,
+
+		shutdownTracing: shutdownTracing,
+	}
+	app.jobsCtx, app.stopJobs = context.WithCancel(context.Background())
+
+	if err := app.configureTLS(); err != nil {
+		events.Close(context.Background())
+		db.Close()
+		shutdownTracing(context.Background())
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// configureTLS enables HTTPS on the main server when a certificate is
+// configured, and optionally a plain HTTP listener that redirects to it.
+func (a *App) configureTLS() error {
+	cfg := a.config
+	if !cfg.TLSEnabled() {
+		return nil
+	}
+
+	minVersion, err := certs.ParseMinVersion(cfg.TLSMinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid TLS_MIN_VERSION: %w", err)
+	}
+
+	reloader, err := certs.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile, a.logger)
+	if err != nil {
+		return err
+	}
+
+	a.certs = reloader
+	a.server.TLSConfig = &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if cfg.HTTPRedirectPort != "" {
+		a.redirectServer = &http.Server{
+			Addr:              ":" + cfg.HTTPRedirectPort,
+			Handler:           web.NewHTTPSRedirectHandler(cfg.Port),
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		}
+	}
+	return nil
+}
+
+// Start launches the background jobs: session cleanup, purges, webhook
+// deliveries, scheduled backups and certificate reloads. Call it once,
+// before Run, from the goroutine that later calls Shutdown or Close.
+func (a *App) Start() {
+	ctx := a.jobsCtx
+	a.jobs.Add(1)
+	go func() {
+		defer a.jobs.Done()
+		a.runSessionCleanup(ctx, a.config.SessionCleanupInterval)
+	}()
+
+	if a.config.DeletedRetention > 0 {
+		a.jobs.Add(1)
+		go func() {
+			defer a.jobs.Done()
+			a.runDeletedPurge(ctx, a.config.DeletedRetention)
+		}()
+	}
+
+	if a.config.IdempotencyRetention > 0 {
+		a.jobs.Add(1)
+		go func() {
+			defer a.jobs.Done()
+			a.runIdempotencyPurge(ctx, a.config.IdempotencyRetention)
+		}()
 	}
 
-	return &App{
-		config: cfg,
-		logger: logger,
-		db:     db,
-		server: server,
-	}, nil
+	if a.config.WebhookPollInterval > 0 {
+		a.jobs.Add(1)
+		go func() {
+			defer a.jobs.Done()
+			a.webhooks.Run(ctx, a.config.WebhookPollInterval)
+		}()
+	}
+
+	if a.backups != nil && a.config.BackupInterval > 0 {
+		a.jobs.Add(1)
+		go func() {
+			defer a.jobs.Done()
+			a.backups.Run(ctx, a.config.BackupInterval)
+		}()
+	}
+
+	if a.certs != nil {
+		a.jobs.Add(1)
+		go func() {
+			defer a.jobs.Done()
+			a.certs.Watch(ctx, certReloadInterval)
+		}()
+	}
 }
 
+// Run serves requests until Shutdown is called or a listener fails. On
+// failure the redirect listener and the background jobs are stopped before
+// it returns, so Close can safely follow.
 func (a *App) Run() error {
 	a.logger.Info("server_start",
 		slog.String("port", a.config.Port),
-		slog.String("db_path", a.config.DBPath),
+		slog.String("db_driver", a.config.DBDriver),
+		slog.Bool("tls", a.certs != nil),
+		slog.Bool("tracing", a.config.TracingEnabled),
 	)
 
-	if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	err := a.serve()
+	if err != nil {
+		if a.redirectServer != nil {
+			a.redirectServer.Close()
+		}
+		a.stopJobs()
+		a.jobs.Wait()
+	}
+	return err
+}
+
+func (a *App) serve() error {
+	if a.certs == nil {
+		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	}
+
+	if a.redirectServer != nil {
+		ln, err := net.Listen("tcp", a.redirectServer.Addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for HTTP redirects: %w", err)
+		}
+		a.logger.Info("http_redirect_start", slog.String("port", a.config.HTTPRedirectPort))
+		go func() {
+			if err := a.redirectServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+				a.logger.Error("http_redirect_failed", slog.Any("error", err))
+			}
+		}()
+	}
+
+	// Certificates come from TLSConfig.GetCertificate.
+	if err := a.server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
 }
 
+// Shutdown stops accepting requests, lets in-flight requests and background
+// jobs finish, then flushes queued events and spans, giving up once ctx is
+// done.
 func (a *App) Shutdown(ctx context.Context) error {
-	a.logger.Info("server_shutdown")
-	return a.server.Shutdown(ctx)
+	start := time.Now()
+	a.logger.Info("server_shutdown", slog.Duration("drain_delay", a.config.DrainDelay))
+
+	// Readiness fails from here on. Keep serving for DrainDelay so load
+	// balancers notice and stop routing new requests before the listener
+	// closes; in-flight requests are then given the rest of ctx to finish.
+	a.health.Drain()
+	if a.config.DrainDelay > 0 {
+		select {
+		case <-time.After(a.config.DrainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	err := a.server.Shutdown(ctx)
+	if err != nil {
+		a.logger.Warn("requests_not_drained", slog.Any("error", err))
+	}
+	if a.redirectServer != nil {
+		if rerr := a.redirectServer.Shutdown(ctx); err == nil {
+			err = rerr
+		}
+	}
+
+	// Cancelled jobs abandon their current run the way a failed one would:
+	// purges roll back and a half-written backup is removed.
+	a.stopJobs()
+	jobsDone := make(chan struct{})
+	go func() {
+		a.jobs.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+	case <-ctx.Done():
+		a.logger.Warn("jobs_not_drained", slog.Any("error", ctx.Err()))
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+
+	if berr := a.events.Close(ctx); err == nil {
+		err = berr
+	}
+
+	// Flush the spans of the requests and jobs that just finished.
+	if terr := a.shutdownTracing(ctx); err == nil {
+		err = terr
+	}
+
+	a.logger.Info("server_stopped", slog.Duration("duration", time.Since(start)))
+	return err
+}
+
+// runSessionCleanup purges expired sessions once at startup and then on
+// every tick until ctx is cancelled.
+func (a *App) runSessionCleanup(ctx context.Context, interval time.Duration) {
+	cleanup := func() {
+		deleted, err := a.authService.CleanupExpiredSessions(ctx)
+		if err != nil {
+			a.logger.Warn("failed_to_cleanup_sessions", slog.Any("error", err))
+		} else if deleted > 0 {
+			a.logger.Info("expired_sessions_cleaned", slog.Int64("deleted", deleted))
+		}
+	}
+
+	cleanup()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cleanup()
+		}
+	}
+}
+
+// runDeletedPurge permanently removes weights and goals deleted longer than
+// retention ago, once at startup and then every purgeInterval.
+func (a *App) runDeletedPurge(ctx context.Context, retention time.Duration) {
+	purge := func() {
+		before := time.Now().Add(-retention)
+		weights, err := a.weightTracker.PurgeDeleted(ctx, before)
+		if err != nil {
+			a.logger.Warn("failed_to_purge_deleted_weights", slog.Any("error", err))
+		}
+		goals, err := a.goalTracker.PurgeDeleted(ctx, before)
+		if err != nil {
+			a.logger.Warn("failed_to_purge_deleted_goals", slog.Any("error", err))
+		}
+		if weights > 0 || goals > 0 {
+			a.logger.Info("deleted_records_purged",
+				slog.Int64("weights", weights),
+				slog.Int64("goals", goals),
+			)
+		}
+	}
+
+	purge()
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purge()
+		}
+	}
+}
+
+// runIdempotencyPurge forgets idempotency keys used longer than retention
+// ago, once at startup and then every purgeInterval.
+func (a *App) runIdempotencyPurge(ctx context.Context, retention time.Duration) {
+	purge := func() {
+		deleted, err := a.idempotency.Purge(ctx, time.Now().Add(-retention))
+		if err != nil {
+			a.logger.Warn("failed_to_purge_idempotency_keys", slog.Any("error", err))
+		} else if deleted > 0 {
+			a.logger.Info("idempotency_keys_purged", slog.Int64("deleted", deleted))
+		}
+	}
+
+	purge()
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purge()
+		}
+	}
 }
 
+// Close stops the background jobs, waiting for them to finish, and closes
+// the database.
 func (a *App) Close() error {
+	a.stopJobs()
+	a.jobs.Wait()
 	return a.db.Close()
 }
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"peso/internal/config"
+)
+
+func newTestApp(t *testing.T, port string) *App {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.Port = port
+	cfg.DBPath = filepath.Join(dir, "peso.db")
+	cfg.BackupDir = filepath.Join(dir, "backups")
+	cfg.LogLevel = "error"
+	cfg.WebhookPollInterval = 10 * time.Millisecond
+	cfg.BackupInterval = 10 * time.Millisecond
+
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return a
+}
+
+func TestApp_ShutdownRightAfterStart(t *testing.T) {
+	a := newTestApp(t, "0")
+	a.Start()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- a.Run()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if a.jobsCtx.Err() == nil {
+		t.Error("jobs still running after Shutdown()")
+	}
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Errorf("Run() error = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after Shutdown()")
+	}
+
+	if err := a.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestApp_RunFailureStopsJobs(t *testing.T) {
+	taken, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer taken.Close()
+	port := strconv.Itoa(taken.Addr().(*net.TCPAddr).Port)
+
+	a := newTestApp(t, port)
+	a.Start()
+
+	if err := a.Run(); err == nil {
+		t.Fatal("Run() error = nil, want address in use")
+	}
+	if a.jobsCtx.Err() == nil {
+		t.Error("jobs still running after Run() failed")
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- a.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not return")
+	}
+}
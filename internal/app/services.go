@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	assets "peso"
+	"peso/internal/application"
+	"peso/internal/config"
+	"peso/internal/domain/event"
+	"peso/internal/domain/session"
+	"peso/internal/domain/webhook"
+	"peso/internal/infrastructure/eventbus"
+	"peso/internal/infrastructure/persistence"
+	"peso/internal/interfaces"
+)
+
+// Services are the application services over one database, wired the same
+// way for the server and for the administrative commands.
+type Services struct {
+	WeightTracker  *application.WeightTracker
+	GoalTracker    *application.GoalTracker
+	AuthService    *application.AuthService
+	AuditLog       *application.AuditLog
+	WebhookService *application.WebhookService
+	Idempotency    *application.Idempotency
+
+	users      interfaces.UserRepository
+	webhooks   interfaces.WebhookRepository
+	deliveries interfaces.WebhookDeliveryRepository
+
+	db     *persistence.DB
+	events *eventbus.Bus
+}
+
+// newServices builds the services on db, configured from cfg. Goal
+// achievements and webhook deliveries follow recorded events the same way
+// whether a change comes from the web UI or the command line.
+func newServices(cfg *config.Config, db *persistence.DB, events *eventbus.Bus) *Services {
+	userRepo := persistence.NewUserRepository(db)
+	weightRepo := persistence.NewWeightRepository(db)
+	goalRepo := persistence.NewGoalRepository(db)
+	sessionRepo := persistence.NewSessionRepository(db)
+	auditRepo := persistence.NewAuditRepository(db)
+	webhookRepo := persistence.NewWebhookRepository(db)
+	deliveryRepo := persistence.NewWebhookDeliveryRepository(db)
+	idempotencyRepo := persistence.NewIdempotencyRepository(db)
+
+	weightTracker := application.NewWeightTracker(userRepo, weightRepo, db, events)
+	weightTracker.SetMaxDailyRecordings(cfg.MaxDailyWeightRecordings)
+	goalTracker := application.NewGoalTracker(userRepo, weightRepo, goalRepo, db, events)
+	authService := application.NewAuthService(userRepo, sessionRepo, db, events)
+	authService.SetSessionLifetime(session.Lifetime{Idle: cfg.SessionIdleTimeout, Max: cfg.SessionMaxLifetime})
+	webhookService := application.NewWebhookService(webhookRepo, deliveryRepo, db)
+
+	events.Subscribe(event.NameWeightRecorded, eventbus.Typed(goalTracker.OnWeightRecorded))
+	for _, name := range webhook.SupportedEvents {
+		events.Subscribe(name, webhookService.OnEvent)
+	}
+
+	return &Services{
+		WeightTracker:  weightTracker,
+		GoalTracker:    goalTracker,
+		AuthService:    authService,
+		AuditLog:       application.NewAuditLog(auditRepo),
+		WebhookService: webhookService,
+		Idempotency:    application.NewIdempotency(idempotencyRepo),
+
+		users:      userRepo,
+		webhooks:   webhookRepo,
+		deliveries: deliveryRepo,
+
+		db:     db,
+		events: events,
+	}
+}
+
+// Open connects to the configured database for a command run next to the
+// server. It does not migrate: a schema that is behind is reported instead,
+// so an older server is never surprised by a newer schema.
+func Open(cfg *config.Config, logger *slog.Logger) (*Services, error) {
+	migrations, err := assets.Migrations(cfg.DBDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := persistence.Open(cfg.DBDriver, cfg.DBSource())
+	if err != nil {
+		return nil, err
+	}
+	db.LogSlowQueries(logger, cfg.SlowQueryThreshold)
+
+	// MigrationStatus rather than PendingMigrations, which fails on a
+	// database that was never migrated.
+	statuses, err := db.MigrationStatus(migrations)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	pending := 0
+	for _, status := range statuses {
+		if status.Modified {
+			db.Close()
+			return nil, fmt.Errorf("%w: %s", persistence.ErrMigrationModified, status.Name)
+		}
+		if !status.Applied {
+			pending++
+		}
+	}
+	if pending > 0 {
+		db.Close()
+		return nil, fmt.Errorf("database has %d pending migrations, run peso migrate up first", pending)
+	}
+
+	return newServices(cfg, db, eventbus.New(logger)), nil
+}
+
+// Close waits for queued event handlers, giving up once ctx is done, and
+// closes the database.
+func (s *Services) Close(ctx context.Context) error {
+	return errors.Join(s.events.Close(ctx), s.db.Close())
+}
@@ -0,0 +1,115 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"peso/internal/domain/audit"
+	"peso/internal/domain/goal"
+	"peso/internal/domain/session"
+	"peso/internal/domain/user"
+	"peso/internal/domain/webhook"
+	"peso/internal/domain/weight"
+	"peso/internal/interfaces"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// AuditLog reads the audit trail written by the other services
+type AuditLog struct {
+	auditRepo interfaces.AuditRepository
+}
+
+// NewAuditLog creates a new audit log reader
+func NewAuditLog(auditRepo interfaces.AuditRepository) *AuditLog {
+	return &AuditLog{auditRepo: auditRepo}
+}
+
+// UserActivity returns the most recent changes to a user's data
+func (a *AuditLog) UserActivity(ctx context.Context, userID user.UserID, limit int) ([]*audit.Event, error) {
+	ctx, span := tracer.Start(ctx, "AuditLog.UserActivity")
+	defer span.End()
+
+	return a.Query(ctx, interfaces.AuditQuery{UserID: userID, Limit: limit})
+}
+
+// Query returns the events matching q, newest first. The limit defaults to
+// 50 and is capped at 500.
+func (a *AuditLog) Query(ctx context.Context, q interfaces.AuditQuery) ([]*audit.Event, error) {
+	ctx, span := tracer.Start(ctx, "AuditLog.Query")
+	defer span.End()
+
+	if q.Limit <= 0 {
+		q.Limit = defaultAuditLimit
+	}
+	if q.Limit > maxAuditLimit {
+		q.Limit = maxAuditLimit
+	}
+
+	events, err := a.auditRepo.Find(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	return events, nil
+}
+
+// recordAudit appends an event for a change to userID's data, attributed to
+// the actor and request in ctx. When the context has no actor, the change
+// is attributed to fallbackActor, which may be empty.
+func recordAudit(ctx context.Context, repo interfaces.AuditRepository, userID, fallbackActor user.UserID, action audit.Action, targetID string, before, after any) error {
+	meta := audit.MetadataFromContext(ctx)
+	actor := meta.ActorID
+	if actor == "" {
+		actor = fallbackActor
+	}
+
+	event, err := audit.NewEvent(userID, actor, action, targetID, before, after, meta.RequestID)
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+	if err := repo.Append(ctx, event); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// weightSnapshot is the audit payload for a weight record
+func weightSnapshot(w *weight.Weight) map[string]any {
+	return map[string]any{
+		"value":       w.Value().Float64(),
+		"unit":        w.Unit().String(),
+		"measured_at": w.MeasuredAt().UTC().Format(time.RFC3339),
+		"notes":       w.Notes(),
+	}
+}
+
+// goalSnapshot is the audit payload for a goal
+func goalSnapshot(g *goal.Goal) map[string]any {
+	return map[string]any{
+		"target_weight": g.TargetWeight().Float64(),
+		"unit":          g.Unit().String(),
+		"target_date":   g.TargetDate().String(),
+		"description":   g.Description(),
+		"active":        g.IsActive(),
+	}
+}
+
+// sessionSnapshot is the audit payload for a login
+func sessionSnapshot(s *session.Session) map[string]any {
+	return map[string]any{
+		"device":     s.DeviceName(),
+		"ip_address": s.IPAddress(),
+	}
+}
+
+// webhookSnapshot is the audit payload for a webhook, without its secret
+func webhookSnapshot(w *webhook.Webhook) map[string]any {
+	return map[string]any{
+		"url":    w.URL(),
+		"events": w.Events(),
+	}
+}
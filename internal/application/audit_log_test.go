@@ -0,0 +1,36 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"peso/internal/interfaces"
+)
+
+func TestAuditLog_QueryLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		expected int
+	}{
+		{name: "default limit", limit: 0, expected: defaultAuditLimit},
+		{name: "explicit limit", limit: 20, expected: 20},
+		{name: "limit is capped", limit: 10000, expected: maxAuditLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuditRepo := NewMockAuditRepository()
+			auditLog := NewAuditLog(mockAuditRepo)
+
+			if _, err := auditLog.Query(context.Background(), interfaces.AuditQuery{Limit: tt.limit}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			q := mockAuditRepo.data["FindQuery"].(interfaces.AuditQuery)
+			if q.Limit != tt.expected {
+				t.Errorf("expected limit %d, got %d", tt.expected, q.Limit)
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package application
 
 import (
+	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"peso/internal/domain/audit"
+	"peso/internal/domain/event"
 	"peso/internal/domain/session"
 	"peso/internal/domain/user"
 	"peso/internal/interfaces"
@@ -17,34 +21,46 @@ var (
 	ErrSessionExpired     = errors.New("session expired")
 	ErrNoPassword         = errors.New("user has no password set")
 	ErrInvalidEmail       = errors.New("invalid email format")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrPasswordAlreadySet = errors.New("password already set")
 )
 
 type AuthService struct {
 	userRepo    interfaces.UserRepository
 	sessionRepo interfaces.SessionRepository
+	uow         interfaces.UnitOfWork
+	publisher   interfaces.EventPublisher
+	lifetime    session.Lifetime
+
+	// claimKey signs password claims. It lives only as long as the
+	// process, which is far longer than a claim does.
+	claimKey []byte
 }
 
-func NewAuthService(userRepo interfaces.UserRepository, sessionRepo interfaces.SessionRepository) *AuthService {
+func NewAuthService(userRepo interfaces.UserRepository, sessionRepo interfaces.SessionRepository, uow interfaces.UnitOfWork, publisher interfaces.EventPublisher) *AuthService {
 	return &AuthService{
 		userRepo:    userRepo,
 		sessionRepo: sessionRepo,
+		uow:         uow,
+		publisher:   publisher,
+		lifetime:    session.DefaultLifetime,
+		claimKey:    session.NewClaimKey(),
 	}
 }
 
-func (s *AuthService) Register(name, email, password string) (*user.User, *session.Session, error) {
-	email = strings.TrimSpace(strings.ToLower(email))
+// SetSessionLifetime changes how long new and refreshed sessions last.
+func (s *AuthService) SetSessionLifetime(lifetime session.Lifetime) {
+	s.lifetime = lifetime
+}
 
-	if !isValidEmail(email) {
-		return nil, nil, ErrInvalidEmail
-	}
+func (s *AuthService) Register(ctx context.Context, name, email, password string, client session.ClientInfo) (*user.User, *session.Session, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Register")
+	defer span.End()
 
-	exists, err := s.userRepo.EmailExists(email)
+	email, err := s.availableEmail(ctx, email)
 	if err != nil {
 		return nil, nil, err
 	}
-	if exists {
-		return nil, nil, ErrEmailAlreadyExists
-	}
 
 	userID := uuid.New().String()
 	u, err := user.NewUserWithPassword(userID, name, email, password)
@@ -52,27 +68,171 @@ func (s *AuthService) Register(name, email, password string) (*user.User, *sessi
 		return nil, nil, err
 	}
 
-	if err := s.userRepo.Save(u); err != nil {
+	sess, err := session.NewSession(u.ID(), client, s.lifetime)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	sess, err := session.NewSession(u.ID())
+	err = s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		if err := repos.Users.Save(ctx, u); err != nil {
+			return err
+		}
+		if err := repos.Sessions.Save(ctx, sess); err != nil {
+			return err
+		}
+		after := map[string]any{"name": u.Name(), "email": email}
+		return recordAudit(ctx, repos.Audit, u.ID(), u.ID(), audit.ActionUserRegistered, u.ID().String(), nil, after)
+	})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if err := s.sessionRepo.Save(sess); err != nil {
-		return nil, nil, err
-	}
+	s.publisher.Publish(ctx, event.UserRegistered{Base: event.NewBase(u.ID()), UserName: u.Name(), Email: email})
 
 	return u, sess, nil
 }
 
-func (s *AuthService) Login(email, password string) (*user.User, *session.Session, error) {
+// CreateUser adds an account without signing it in, for administration
+// outside the web UI. Without a password the user chooses one on their
+// first login.
+func (s *AuthService) CreateUser(ctx context.Context, name, email, password string) (*user.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CreateUser")
+	defer span.End()
+
+	email, err := s.availableEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := uuid.New().String()
+	var u *user.User
+	if password == "" {
+		u, err = user.NewUser(userID, name, email)
+	} else {
+		u, err = user.NewUserWithPassword(userID, name, email, password)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		if err := repos.Users.Save(ctx, u); err != nil {
+			return err
+		}
+		after := map[string]any{"name": u.Name(), "email": email}
+		return recordAudit(ctx, repos.Audit, u.ID(), "", audit.ActionUserRegistered, u.ID().String(), nil, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publisher.Publish(ctx, event.UserRegistered{Base: event.NewBase(u.ID()), UserName: u.Name(), Email: email})
+
+	return u, nil
+}
+
+// availableEmail normalises email and checks that no account uses it yet.
+func (s *AuthService) availableEmail(ctx context.Context, email string) (string, error) {
 	email = strings.TrimSpace(strings.ToLower(email))
 
-	u, err := s.userRepo.FindByEmail(email)
+	if !isValidEmail(email) {
+		return "", ErrInvalidEmail
+	}
+
+	exists, err := s.userRepo.EmailExists(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", ErrEmailAlreadyExists
+	}
+	return email, nil
+}
+
+// FindUser resolves an account by email address or user ID.
+func (s *AuthService) FindUser(ctx context.Context, emailOrID string) (*user.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.FindUser")
+	defer span.End()
+
+	var u *user.User
+	var err error
+	if strings.Contains(emailOrID, "@") {
+		u, err = s.userRepo.FindByEmail(ctx, strings.TrimSpace(strings.ToLower(emailOrID)))
+	} else {
+		u, err = s.userRepo.FindByID(ctx, user.UserID(emailOrID))
+	}
 	if err != nil {
+		return nil, ErrAuthUserNotFound
+	}
+	return u, nil
+}
+
+// ListUsers returns the accounts that have not been deactivated.
+func (s *AuthService) ListUsers(ctx context.Context) ([]*user.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ListUsers")
+	defer span.End()
+
+	return s.userRepo.FindActive(ctx)
+}
+
+// DeactivateUser disables an account and signs it out everywhere. Its data
+// is kept.
+func (s *AuthService) DeactivateUser(ctx context.Context, userID user.UserID) error {
+	ctx, span := tracer.Start(ctx, "AuthService.DeactivateUser")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		u, err := repos.Users.FindByID(ctx, userID)
+		if err != nil {
+			return ErrAuthUserNotFound
+		}
+		if !u.IsActive() {
+			return ErrUserNotActive
+		}
+		u.Deactivate()
+		if err := repos.Users.Save(ctx, u); err != nil {
+			return err
+		}
+		if err := repos.Sessions.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionUserDeactivated, userID.String(), nil, nil)
+	})
+}
+
+// ResetPassword replaces a user's password on their behalf and signs them
+// out everywhere.
+func (s *AuthService) ResetPassword(ctx context.Context, userID user.UserID, password string) error {
+	ctx, span := tracer.Start(ctx, "AuthService.ResetPassword")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		u, err := repos.Users.FindByID(ctx, userID)
+		if err != nil {
+			return ErrAuthUserNotFound
+		}
+		if err := u.SetPassword(password); err != nil {
+			return err
+		}
+		if err := repos.Users.Save(ctx, u); err != nil {
+			return err
+		}
+		if err := repos.Sessions.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		// The password itself never goes into the audit log
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionPasswordChanged, userID.String(), nil, nil)
+	})
+}
+
+func (s *AuthService) Login(ctx context.Context, email, password string, client session.ClientInfo) (*user.User, *session.Session, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	email = strings.TrimSpace(strings.ToLower(email))
+
+	u, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || !u.IsActive() {
 		return nil, nil, ErrInvalidCredentials
 	}
 
@@ -81,74 +241,215 @@ func (s *AuthService) Login(email, password string) (*user.User, *session.Sessio
 	}
 
 	if !u.VerifyPassword(password) {
+		err := s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+			after := map[string]any{"device": session.DeviceName(client.UserAgent), "ip_address": client.IPAddress}
+			return recordAudit(ctx, repos.Audit, u.ID(), "", audit.ActionLoginFailed, u.ID().String(), nil, after)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
 		return nil, nil, ErrInvalidCredentials
 	}
 
-	sess, err := session.NewSession(u.ID())
+	sess, err := session.NewSession(u.ID(), client, s.lifetime)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if err := s.sessionRepo.Save(sess); err != nil {
+	err = s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		if err := repos.Sessions.Save(ctx, sess); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, u.ID(), u.ID(), audit.ActionLoginSucceeded, sess.ID().String(), nil, sessionSnapshot(sess))
+	})
+	if err != nil {
 		return nil, nil, err
 	}
 
 	return u, sess, nil
 }
 
-func (s *AuthService) SetPassword(email, password string) (*user.User, *session.Session, error) {
-	email = strings.TrimSpace(strings.ToLower(email))
+// PasswordClaim returns a token allowing a user created without a password,
+// who has just proven nothing more than knowing their email, to choose one
+// within session.ClaimTTL.
+func (s *AuthService) PasswordClaim(userID user.UserID) string {
+	return session.SignClaim(s.claimKey, userID, time.Now().Add(session.ClaimTTL))
+}
+
+// ClaimingUser returns the user a password claim was issued for, as long as
+// the claim is valid and they still have no password.
+func (s *AuthService) ClaimingUser(ctx context.Context, claim string) (*user.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ClaimingUser")
+	defer span.End()
 
-	u, err := s.userRepo.FindByEmail(email)
+	userID, err := session.VerifyClaim(s.claimKey, claim, time.Now())
 	if err != nil {
-		return nil, nil, ErrAuthUserNotFound
+		return nil, err
 	}
 
-	if err := u.SetPassword(password); err != nil {
-		return nil, nil, err
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil || !u.IsActive() {
+		return nil, ErrAuthUserNotFound
+	}
+	if u.HasPassword() {
+		return nil, ErrPasswordAlreadySet
 	}
+	return u, nil
+}
+
+// SetPassword lets the user a claim was issued for choose their first
+// password, signing out any other session, and signs them in.
+func (s *AuthService) SetPassword(ctx context.Context, claim, password string, client session.ClientInfo) (*user.User, *session.Session, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.SetPassword")
+	defer span.End()
 
-	if err := s.userRepo.Save(u); err != nil {
+	userID, err := session.VerifyClaim(s.claimKey, claim, time.Now())
+	if err != nil {
 		return nil, nil, err
 	}
 
-	sess, err := session.NewSession(u.ID())
+	sess, err := session.NewSession(userID, client, s.lifetime)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if err := s.sessionRepo.Save(sess); err != nil {
+	var u *user.User
+	err = s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		u, err = repos.Users.FindByID(ctx, userID)
+		if err != nil || !u.IsActive() {
+			return ErrAuthUserNotFound
+		}
+		// A claim only sets a first password; changing one takes the
+		// current password or an admin reset.
+		if u.HasPassword() {
+			return ErrPasswordAlreadySet
+		}
+		if err := u.SetPassword(password); err != nil {
+			return err
+		}
+		if err := repos.Users.Save(ctx, u); err != nil {
+			return err
+		}
+		if err := repos.Sessions.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := repos.Sessions.Save(ctx, sess); err != nil {
+			return err
+		}
+		// The password itself never goes into the audit log
+		return recordAudit(ctx, repos.Audit, userID, userID, audit.ActionPasswordChanged, userID.String(), nil, nil)
+	})
+	if err != nil {
 		return nil, nil, err
 	}
 
 	return u, sess, nil
 }
 
-func (s *AuthService) Logout(token string) error {
-	return s.sessionRepo.DeleteByToken(token)
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	ctx, span := tracer.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
+	return s.sessionRepo.DeleteByTokenHash(ctx, session.HashToken(token))
 }
 
-func (s *AuthService) ValidateSession(token string) (*user.User, error) {
-	sess, err := s.sessionRepo.FindByToken(token)
-	if err != nil {
-		return nil, ErrSessionExpired
+// ValidateSession resolves the session behind a token and records the
+// activity, sliding its expiry forward.
+func (s *AuthService) ValidateSession(ctx context.Context, token string, client session.ClientInfo) (*user.User, *session.Session, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ValidateSession")
+	defer span.End()
+
+	sess, err := s.sessionRepo.FindByTokenHash(ctx, session.HashToken(token))
+	if err != nil || !sess.MatchesToken(token) {
+		return nil, nil, ErrSessionExpired
 	}
 
 	if sess.IsExpired() {
-		s.sessionRepo.DeleteByToken(token)
-		return nil, ErrSessionExpired
+		s.sessionRepo.DeleteByID(ctx, sess.ID())
+		return nil, nil, ErrSessionExpired
+	}
+
+	u, err := s.userRepo.FindByID(ctx, sess.UserID())
+	if err != nil || !u.IsActive() {
+		return nil, nil, ErrAuthUserNotFound
+	}
+
+	if sess.Touch(time.Now(), client.IPAddress, s.lifetime) {
+		if err := s.sessionRepo.Save(ctx, sess); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	u, err := s.userRepo.FindByID(sess.UserID())
+	return u, sess, nil
+}
+
+// ListSessions returns the user's sessions that have not expired yet,
+// most recently used first.
+func (s *AuthService) ListSessions(ctx context.Context, userID user.UserID) ([]*session.Session, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ListSessions")
+	defer span.End()
+
+	return s.sessionRepo.FindActiveByUserID(ctx, userID)
+}
+
+// RevokeSession ends a single session belonging to the user.
+func (s *AuthService) RevokeSession(ctx context.Context, userID user.UserID, sessionID session.SessionID) error {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeSession")
+	defer span.End()
+
+	sess, err := s.sessionRepo.FindByID(ctx, sessionID)
 	if err != nil {
-		return nil, ErrAuthUserNotFound
+		return ErrSessionNotFound
 	}
 
-	return u, nil
+	if sess.UserID() != userID {
+		return ErrSessionNotFound
+	}
+
+	return s.sessionRepo.DeleteByID(ctx, sessionID)
+}
+
+// RevokeOtherSessions ends every session of the user except the current one.
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID user.UserID, current session.SessionID) error {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeOtherSessions")
+	defer span.End()
+
+	return s.sessionRepo.DeleteByUserIDExcept(ctx, userID, current)
 }
 
-func (s *AuthService) CleanupExpiredSessions() error {
-	return s.sessionRepo.DeleteExpired()
+// SetLanguage saves the interface language the user prefers; "" follows
+// the browser.
+func (s *AuthService) SetLanguage(ctx context.Context, userID user.UserID, language string) error {
+	ctx, span := tracer.Start(ctx, "AuthService.SetLanguage")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return ErrAuthUserNotFound
+	}
+
+	if err := u.SetLanguage(language); err != nil {
+		return err
+	}
+
+	return s.userRepo.Save(ctx, u)
+}
+
+// CleanupExpiredSessions deletes expired sessions and reports how many
+// were removed.
+func (s *AuthService) CleanupExpiredSessions(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CleanupExpiredSessions")
+	defer span.End()
+
+	return s.sessionRepo.DeleteExpired(ctx)
+}
+
+// CountActiveSessions reports how many sessions have not expired yet.
+func (s *AuthService) CountActiveSessions(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CountActiveSessions")
+	defer span.End()
+
+	return s.sessionRepo.CountActive(ctx)
 }
 
 func isValidEmail(email string) bool {
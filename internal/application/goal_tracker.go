@@ -1,11 +1,15 @@
 package application
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
 	"time"
 
+	"github.com/google/uuid"
+	"peso/internal/domain/audit"
+	"peso/internal/domain/event"
 	"peso/internal/domain/goal"
 	"peso/internal/domain/user"
 	"peso/internal/domain/weight"
@@ -28,6 +32,8 @@ type GoalTracker struct {
 	userRepo   interfaces.UserRepository
 	weightRepo interfaces.WeightRepository
 	goalRepo   interfaces.GoalRepository
+	uow        interfaces.UnitOfWork
+	publisher  interfaces.EventPublisher
 }
 
 var (
@@ -45,18 +51,23 @@ const (
 )
 
 // NewGoalTracker creates a new goal tracker service
-func NewGoalTracker(userRepo interfaces.UserRepository, weightRepo interfaces.WeightRepository, goalRepo interfaces.GoalRepository) *GoalTracker {
+func NewGoalTracker(userRepo interfaces.UserRepository, weightRepo interfaces.WeightRepository, goalRepo interfaces.GoalRepository, uow interfaces.UnitOfWork, publisher interfaces.EventPublisher) *GoalTracker {
 	return &GoalTracker{
 		userRepo:   userRepo,
 		weightRepo: weightRepo,
 		goalRepo:   goalRepo,
+		uow:        uow,
+		publisher:  publisher,
 	}
 }
 
 // SetGoal sets a new goal for a user
-func (gt *GoalTracker) SetGoal(userID user.UserID, targetWeight weight.WeightValue, unit weight.WeightUnit, targetDate goal.TargetDate, description string) (*goal.Goal, error) {
+func (gt *GoalTracker) SetGoal(ctx context.Context, userID user.UserID, targetWeight weight.WeightValue, unit weight.WeightUnit, targetDate goal.TargetDate, description string) (*goal.Goal, error) {
+	ctx, span := tracer.Start(ctx, "GoalTracker.SetGoal")
+	defer span.End()
+
 	// Verify user exists and is active
-	u, err := gt.userRepo.FindByID(userID)
+	u, err := gt.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err.Error())
 	}
@@ -66,7 +77,7 @@ func (gt *GoalTracker) SetGoal(userID user.UserID, targetWeight weight.WeightVal
 	}
 
 	// Get current weight
-	currentWeightRecord, err := gt.weightRepo.FindLatestByUserID(userID)
+	currentWeightRecord, err := gt.weightRepo.FindLatestByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrNoCurrentWeight, err.Error())
 	}
@@ -77,12 +88,6 @@ func (gt *GoalTracker) SetGoal(userID user.UserID, targetWeight weight.WeightVal
 		return nil, ErrSameWeight
 	}
 
-	// Check if user already has an active goal
-	existingGoal, err := gt.goalRepo.FindActiveByUserID(userID)
-	if err == nil && existingGoal != nil {
-		return nil, ErrActiveGoalExists
-	}
-
 	// Validate goal is realistic (max 2kg per week)
 	daysUntilGoal := targetDate.DaysUntil()
 	weeksUntilGoal := float64(daysUntilGoal) / 7.0
@@ -92,26 +97,48 @@ func (gt *GoalTracker) SetGoal(userID user.UserID, targetWeight weight.WeightVal
 		return nil, ErrUnrealisticGoal
 	}
 
-	// Generate unique goal ID
-	goalID := fmt.Sprintf("goal_%s_%d", userID.String(), time.Now().UnixNano())
-
-	// Create goal
-	newGoal, err := goal.NewGoal(goalID, userID, targetWeight, unit, targetDate, description)
+	newGoal, err := goal.NewGoal(uuid.NewString(), userID, targetWeight, unit, targetDate, description)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create goal: %w", err)
 	}
 
-	// Save goal
-	if err := gt.goalRepo.Save(newGoal); err != nil {
-		return nil, fmt.Errorf("failed to save goal: %w", err)
+	// Check for an existing active goal and save in one transaction; the
+	// unique index catches anything that still slips through
+	err = gt.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		existingGoal, err := repos.Goals.FindActiveByUserID(ctx, userID)
+		if err == nil && existingGoal != nil {
+			return ErrActiveGoalExists
+		}
+
+		if err := repos.Goals.Create(ctx, newGoal); err != nil {
+			if errors.Is(err, interfaces.ErrConflict) {
+				return ErrActiveGoalExists
+			}
+			return fmt.Errorf("failed to save goal: %w", err)
+		}
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionGoalSet, newGoal.ID().String(), nil, goalSnapshot(newGoal))
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	gt.publisher.Publish(ctx, event.GoalSet{
+		Base:         event.NewBase(userID),
+		GoalID:       newGoal.ID().String(),
+		TargetWeight: newGoal.TargetWeight().Float64(),
+		Unit:         newGoal.Unit().String(),
+		TargetDate:   newGoal.TargetDate().String(),
+	})
+
 	return newGoal, nil
 }
 
 // GetActiveGoal gets the active goal for a user
-func (gt *GoalTracker) GetActiveGoal(userID user.UserID) (*goal.Goal, error) {
-	activeGoal, err := gt.goalRepo.FindActiveByUserID(userID)
+func (gt *GoalTracker) GetActiveGoal(ctx context.Context, userID user.UserID) (*goal.Goal, error) {
+	ctx, span := tracer.Start(ctx, "GoalTracker.GetActiveGoal")
+	defer span.End()
+
+	activeGoal, err := gt.goalRepo.FindActiveByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrNoActiveGoal, err.Error())
 	}
@@ -120,19 +147,22 @@ func (gt *GoalTracker) GetActiveGoal(userID user.UserID) (*goal.Goal, error) {
 }
 
 // GetStartingWeightForGoal gets the weight closest to when the goal was created
-func (gt *GoalTracker) GetStartingWeightForGoal(userID user.UserID, goalCreatedAt time.Time) (*weight.Weight, error) {
+func (gt *GoalTracker) GetStartingWeightForGoal(ctx context.Context, userID user.UserID, goalCreatedAt time.Time) (*weight.Weight, error) {
+	ctx, span := tracer.Start(ctx, "GoalTracker.GetStartingWeightForGoal")
+	defer span.End()
+
 	// Look for weights around the goal creation date (±7 days)
 	from := goalCreatedAt.AddDate(0, 0, -7)
 	to := goalCreatedAt.AddDate(0, 0, 7)
 
-	weights, err := gt.weightRepo.FindByUserIDAndPeriod(userID, from, to)
+	weights, err := gt.weightRepo.FindByUserIDAndPeriod(ctx, userID, from, to)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(weights) == 0 {
 		// Fallback to latest weight before goal creation
-		return gt.weightRepo.FindLatestByUserID(userID)
+		return gt.weightRepo.FindLatestByUserID(ctx, userID)
 	}
 
 	// Find the weight closest to goal creation date
@@ -154,15 +184,18 @@ func (gt *GoalTracker) GetStartingWeightForGoal(userID user.UserID, goalCreatedA
 }
 
 // CalculateProgress calculates progress towards the user's active goal
-func (gt *GoalTracker) CalculateProgress(userID user.UserID) (GoalProgress, error) {
+func (gt *GoalTracker) CalculateProgress(ctx context.Context, userID user.UserID) (GoalProgress, error) {
+	ctx, span := tracer.Start(ctx, "GoalTracker.CalculateProgress")
+	defer span.End()
+
 	// Get active goal
-	activeGoal, err := gt.GetActiveGoal(userID)
+	activeGoal, err := gt.GetActiveGoal(ctx, userID)
 	if err != nil {
 		return GoalProgress{}, err
 	}
 
 	// Get current weight
-	currentWeightRecord, err := gt.weightRepo.FindLatestByUserID(userID)
+	currentWeightRecord, err := gt.weightRepo.FindLatestByUserID(ctx, userID)
 	if err != nil {
 		return GoalProgress{}, fmt.Errorf("%w: %s", ErrNoCurrentWeight, err.Error())
 	}
@@ -207,20 +240,133 @@ func (gt *GoalTracker) CalculateProgress(userID user.UserID) (GoalProgress, erro
 }
 
 // DeactivateGoal deactivates a specific goal
-func (gt *GoalTracker) DeactivateGoal(goalID goal.GoalID) error {
+func (gt *GoalTracker) DeactivateGoal(ctx context.Context, goalID goal.GoalID) error {
+	ctx, span := tracer.Start(ctx, "GoalTracker.DeactivateGoal")
+	defer span.End()
+
 	// Find the goal
-	g, err := gt.goalRepo.FindByID(goalID)
+	g, err := gt.goalRepo.FindByID(ctx, goalID)
 	if err != nil {
 		return fmt.Errorf("%w: %s", ErrGoalNotFound, err.Error())
 	}
 
+	before := goalSnapshot(g)
+
 	// Deactivate it
 	g.Deactivate()
 
 	// Save the updated goal
-	if err := gt.goalRepo.Save(g); err != nil {
-		return fmt.Errorf("failed to deactivate goal: %w", err)
+	return gt.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		if err := repos.Goals.Save(ctx, g); err != nil {
+			return fmt.Errorf("failed to deactivate goal: %w", err)
+		}
+		return recordAudit(ctx, repos.Audit, g.UserID(), "", audit.ActionGoalDeactivated, goalID.String(), before, goalSnapshot(g))
+	})
+}
+
+// DeleteGoal removes a goal belonging to the user
+func (gt *GoalTracker) DeleteGoal(ctx context.Context, userID user.UserID, goalID goal.GoalID) error {
+	ctx, span := tracer.Start(ctx, "GoalTracker.DeleteGoal")
+	defer span.End()
+
+	// The ownership check and the delete share a transaction, so the goal
+	// cannot change hands in between
+	err := gt.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		g, err := repos.Goals.FindByID(ctx, goalID)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrGoalNotFound, err.Error())
+		}
+		if g.UserID() != userID {
+			return ErrGoalNotFound
+		}
+
+		if err := repos.Goals.Delete(ctx, goalID); err != nil {
+			return fmt.Errorf("failed to delete goal: %w", err)
+		}
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionGoalDeleted, goalID.String(), goalSnapshot(g), nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	gt.publisher.Publish(ctx, event.GoalDeleted{Base: event.NewBase(userID), GoalID: goalID.String()})
+	return nil
+}
+
+// RestoreGoal brings back a deleted goal. An active goal cannot be restored
+// once the user has set a new one.
+func (gt *GoalTracker) RestoreGoal(ctx context.Context, userID user.UserID, goalID goal.GoalID) error {
+	ctx, span := tracer.Start(ctx, "GoalTracker.RestoreGoal")
+	defer span.End()
+
+	err := gt.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		if err := repos.Goals.Restore(ctx, userID, goalID); err != nil {
+			if errors.Is(err, interfaces.ErrConflict) {
+				return ErrActiveGoalExists
+			}
+			return fmt.Errorf("failed to restore goal: %w", err)
+		}
+
+		g, err := repos.Goals.FindByID(ctx, goalID)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrGoalNotFound, err.Error())
+		}
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionGoalRestored, goalID.String(), nil, goalSnapshot(g))
+	})
+	if err != nil {
+		return err
+	}
+
+	gt.publisher.Publish(ctx, event.GoalRestored{Base: event.NewBase(userID), GoalID: goalID.String()})
+	return nil
+}
+
+// PurgeDeleted permanently removes goals deleted before the cutoff
+func (gt *GoalTracker) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "GoalTracker.PurgeDeleted")
+	defer span.End()
+
+	return gt.goalRepo.PurgeDeleted(ctx, before)
+}
+
+// OnWeightRecorded raises GoalAchieved when a new measurement is the first
+// to reach the user's active goal. Measurements recorded out of order, with
+// a newer one already on file, are ignored.
+func (gt *GoalTracker) OnWeightRecorded(ctx context.Context, e event.WeightRecorded) error {
+	ctx, span := tracer.Start(ctx, "GoalTracker.OnWeightRecorded")
+	defer span.End()
+
+	activeGoal, err := gt.goalRepo.FindActiveByUserID(ctx, e.UserID)
+	if err != nil {
+		return nil
+	}
+
+	recent, err := gt.weightRepo.FindByUserID(ctx, e.UserID, 2)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve recent weights: %w", err)
+	}
+	if len(recent) == 0 || recent[0].ID().String() != e.WeightID {
+		return nil
+	}
+
+	start, err := gt.GetStartingWeightForGoal(ctx, e.UserID, activeGoal.CreatedAt())
+	if err != nil {
+		return fmt.Errorf("failed to retrieve starting weight: %w", err)
+	}
+
+	if !activeGoal.IsReachedBy(start.Value(), recent[0].Value()) {
+		return nil
+	}
+	if len(recent) > 1 && activeGoal.IsReachedBy(start.Value(), recent[1].Value()) {
+		return nil
 	}
 
+	gt.publisher.Publish(ctx, event.GoalAchieved{
+		Base:         event.NewBase(e.UserID),
+		GoalID:       activeGoal.ID().String(),
+		TargetWeight: activeGoal.TargetWeight().Float64(),
+		Weight:       recent[0].Value().Float64(),
+		Unit:         activeGoal.Unit().String(),
+	})
 	return nil
 }
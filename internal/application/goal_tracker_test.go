@@ -1,13 +1,17 @@
 package application
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"peso/internal/domain/event"
 	"peso/internal/domain/goal"
 	"peso/internal/domain/user"
 	"peso/internal/domain/weight"
+	"peso/internal/interfaces"
 )
 
 type MockGoalRepository struct {
@@ -22,7 +26,15 @@ func NewMockGoalRepository() *MockGoalRepository {
 	}
 }
 
-func (m *MockGoalRepository) Save(g *goal.Goal) error {
+func (m *MockGoalRepository) Create(ctx context.Context, g *goal.Goal) error {
+	m.calls["Create"] = append(m.calls["Create"], g)
+	if err, ok := m.data["CreateError"]; ok {
+		return err.(error)
+	}
+	return nil
+}
+
+func (m *MockGoalRepository) Save(ctx context.Context, g *goal.Goal) error {
 	m.calls["Save"] = append(m.calls["Save"], g)
 	if err, ok := m.data["SaveError"]; ok {
 		return err.(error)
@@ -30,7 +42,7 @@ func (m *MockGoalRepository) Save(g *goal.Goal) error {
 	return nil
 }
 
-func (m *MockGoalRepository) FindByID(id goal.GoalID) (*goal.Goal, error) {
+func (m *MockGoalRepository) FindByID(ctx context.Context, id goal.GoalID) (*goal.Goal, error) {
 	m.calls["FindByID"] = append(m.calls["FindByID"], id)
 	if err, ok := m.data["FindByIDError"]; ok {
 		return nil, err.(error)
@@ -41,7 +53,7 @@ func (m *MockGoalRepository) FindByID(id goal.GoalID) (*goal.Goal, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *MockGoalRepository) FindActiveByUserID(userID user.UserID) (*goal.Goal, error) {
+func (m *MockGoalRepository) FindActiveByUserID(ctx context.Context, userID user.UserID) (*goal.Goal, error) {
 	m.calls["FindActiveByUserID"] = append(m.calls["FindActiveByUserID"], userID)
 	if err, ok := m.data["FindActiveByUserIDError"]; ok {
 		return nil, err.(error)
@@ -52,7 +64,7 @@ func (m *MockGoalRepository) FindActiveByUserID(userID user.UserID) (*goal.Goal,
 	return nil, errors.New("no active goal")
 }
 
-func (m *MockGoalRepository) FindByUserID(userID user.UserID) ([]*goal.Goal, error) {
+func (m *MockGoalRepository) FindByUserID(ctx context.Context, userID user.UserID) ([]*goal.Goal, error) {
 	m.calls["FindByUserID"] = append(m.calls["FindByUserID"], userID)
 	if err, ok := m.data["FindByUserIDError"]; ok {
 		return nil, err.(error)
@@ -63,7 +75,7 @@ func (m *MockGoalRepository) FindByUserID(userID user.UserID) ([]*goal.Goal, err
 	return nil, errors.New("not found")
 }
 
-func (m *MockGoalRepository) DeactivateByUserID(userID user.UserID) error {
+func (m *MockGoalRepository) DeactivateByUserID(ctx context.Context, userID user.UserID) error {
 	m.calls["DeactivateByUserID"] = append(m.calls["DeactivateByUserID"], userID)
 	if err, ok := m.data["DeactivateByUserIDError"]; ok {
 		return err.(error)
@@ -71,7 +83,7 @@ func (m *MockGoalRepository) DeactivateByUserID(userID user.UserID) error {
 	return nil
 }
 
-func (m *MockGoalRepository) Delete(id goal.GoalID) error {
+func (m *MockGoalRepository) Delete(ctx context.Context, id goal.GoalID) error {
 	m.calls["Delete"] = append(m.calls["Delete"], id)
 	if err, ok := m.data["DeleteError"]; ok {
 		return err.(error)
@@ -79,6 +91,25 @@ func (m *MockGoalRepository) Delete(id goal.GoalID) error {
 	return nil
 }
 
+func (m *MockGoalRepository) Restore(ctx context.Context, userID user.UserID, id goal.GoalID) error {
+	m.calls["Restore"] = append(m.calls["Restore"], userID, id)
+	if err, ok := m.data["RestoreError"]; ok {
+		return err.(error)
+	}
+	return nil
+}
+
+func (m *MockGoalRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	m.calls["PurgeDeleted"] = append(m.calls["PurgeDeleted"], before)
+	if err, ok := m.data["PurgeDeletedError"]; ok {
+		return 0, err.(error)
+	}
+	if n, ok := m.data["PurgeDeletedResult"]; ok {
+		return n.(int64), nil
+	}
+	return 0, nil
+}
+
 func TestGoalTracker_SetGoal(t *testing.T) {
 	mockUserRepo := NewMockUserRepository()
 	mockWeightRepo := NewMockWeightRepository()
@@ -156,6 +187,17 @@ func TestGoalTracker_SetGoal(t *testing.T) {
 			expectedErr: true,
 			errorMsg:    "user already has an active goal",
 		},
+		{
+			name: "concurrent active goal rejected by store",
+			setupMocks: func() {
+				mockUserRepo.data["FindByIDResult"] = testUser
+				mockWeightRepo.data["FindLatestByUserIDResult"] = currentWeight
+				mockGoalRepo.data["FindActiveByUserIDError"] = errors.New("no active goal")
+				mockGoalRepo.data["CreateError"] = fmt.Errorf("failed to create goal: %w", interfaces.ErrConflict)
+			},
+			expectedErr: true,
+			errorMsg:    "user already has an active goal",
+		},
 	}
 
 	for _, tt := range tests {
@@ -170,9 +212,10 @@ func TestGoalTracker_SetGoal(t *testing.T) {
 
 			tt.setupMocks()
 
-			tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo)
+			mockPublisher := &MockEventPublisher{}
+			tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo, Goals: mockGoalRepo}), mockPublisher)
 
-			result, err := tracker.SetGoal(userID, targetWeight, unit, targetDate, description)
+			result, err := tracker.SetGoal(context.Background(), userID, targetWeight, unit, targetDate, description)
 
 			if tt.expectedErr {
 				if err == nil {
@@ -196,6 +239,9 @@ func TestGoalTracker_SetGoal(t *testing.T) {
 				if result != nil && result.TargetWeight().Float64() != targetWeight.Float64() {
 					t.Errorf("expected target weight %f but got %f", targetWeight.Float64(), result.TargetWeight().Float64())
 				}
+				if len(mockPublisher.events) != 1 || mockPublisher.events[0].Name() != event.NameGoalSet {
+					t.Errorf("expected goal.set published, got %v", mockPublisher.events)
+				}
 			}
 		})
 	}
@@ -258,9 +304,9 @@ func TestGoalTracker_CalculateProgress(t *testing.T) {
 
 			tt.setupMocks()
 
-			tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo)
+			tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo, Goals: mockGoalRepo}), &MockEventPublisher{})
 
-			progress, err := tracker.CalculateProgress(userID)
+			progress, err := tracker.CalculateProgress(context.Background(), userID)
 
 			if tt.expectedErr {
 				if err == nil {
@@ -301,9 +347,9 @@ func TestGoalTracker_GetActiveGoal(t *testing.T) {
 
 	mockGoalRepo.data["FindActiveByUserIDResult"] = testGoal
 
-	tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo)
+	tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo, Goals: mockGoalRepo}), &MockEventPublisher{})
 
-	foundGoal, err := tracker.GetActiveGoal(userID)
+	foundGoal, err := tracker.GetActiveGoal(context.Background(), userID)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -331,11 +377,173 @@ func TestGoalTracker_DeactivateGoal(t *testing.T) {
 
 	mockGoalRepo.data["FindByIDResult"] = testGoal
 
-	tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo)
+	tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo, Goals: mockGoalRepo}), &MockEventPublisher{})
 
-	err := tracker.DeactivateGoal(goalID)
+	err := tracker.DeactivateGoal(context.Background(), goalID)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestGoalTracker_DeleteGoal(t *testing.T) {
+	goalID, _ := goal.NewGoalID("g1")
+	userID, _ := user.NewUserID("giada")
+	otherID, _ := user.NewUserID("marta")
+	targetWeight, _ := weight.NewWeightValue(65.0)
+	unit, _ := weight.NewWeightUnit("kg")
+	targetDate, _ := goal.NewTargetDate(2030, 6, 15)
+
+	testGoal, _ := goal.NewGoal(goalID.String(), userID, targetWeight, unit, targetDate, "test goal")
+
+	tests := []struct {
+		name          string
+		userID        user.UserID
+		expectErr     bool
+		expectDeleted bool
+	}{
+		{name: "owner deletes goal", userID: userID, expectDeleted: true},
+		{name: "other user cannot delete goal", userID: otherID, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := NewMockUserRepository()
+			mockWeightRepo := NewMockWeightRepository()
+			mockGoalRepo := NewMockGoalRepository()
+			mockGoalRepo.data["FindByIDResult"] = testGoal
+
+			// Only the unit of work sees the goal: the lookup must happen
+			// in the same transaction as the delete.
+			tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, NewMockGoalRepository(), NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo, Goals: mockGoalRepo}), &MockEventPublisher{})
+
+			err := tracker.DeleteGoal(context.Background(), tt.userID, goalID)
+
+			if tt.expectErr && !errors.Is(err, ErrGoalNotFound) {
+				t.Errorf("expected ErrGoalNotFound, got %v", err)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if deleted := len(mockGoalRepo.calls["Delete"]) > 0; deleted != tt.expectDeleted {
+				t.Errorf("expected deleted=%v, got %v", tt.expectDeleted, deleted)
+			}
+		})
+	}
+}
+
+func TestGoalTracker_RestoreGoal(t *testing.T) {
+	goalID, _ := goal.NewGoalID("g1")
+	userID, _ := user.NewUserID("giada")
+	targetWeight, _ := weight.NewWeightValue(65.0)
+	unit, _ := weight.NewWeightUnit("kg")
+	targetDate, _ := goal.NewTargetDate(2030, 6, 15)
+
+	testGoal, _ := goal.NewGoal(goalID.String(), userID, targetWeight, unit, targetDate, "test goal")
+
+	tests := []struct {
+		name        string
+		restoreErr  error
+		expectedErr error
+	}{
+		{name: "successful restore"},
+		{name: "another goal became active", restoreErr: fmt.Errorf("failed to restore goal: %w", interfaces.ErrConflict), expectedErr: ErrActiveGoalExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := NewMockUserRepository()
+			mockWeightRepo := NewMockWeightRepository()
+			mockGoalRepo := NewMockGoalRepository()
+			mockAuditRepo := NewMockAuditRepository()
+			mockGoalRepo.data["FindByIDResult"] = testGoal
+			if tt.restoreErr != nil {
+				mockGoalRepo.data["RestoreError"] = tt.restoreErr
+			}
+
+			tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo, Goals: mockGoalRepo, Audit: mockAuditRepo}), &MockEventPublisher{})
+
+			err := tracker.RestoreGoal(context.Background(), userID, goalID)
+
+			if tt.expectedErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			wantEvents := 0
+			if tt.expectedErr == nil {
+				wantEvents = 1
+			}
+			if len(mockAuditRepo.events) != wantEvents {
+				t.Errorf("expected %d audit events, got %d", wantEvents, len(mockAuditRepo.events))
+			}
+		})
+	}
+}
+
+func TestGoalTracker_OnWeightRecorded(t *testing.T) {
+	userID, _ := user.NewUserID("giada")
+	kg, _ := weight.NewWeightUnit("kg")
+	targetWeight, _ := weight.NewWeightValue(70.0)
+	targetDate, _ := goal.NewTargetDate(2030, 6, 15)
+	activeGoal, _ := goal.NewGoal("g1", userID, targetWeight, kg, targetDate, "")
+
+	newWeight := func(id string, value float64, daysAgo int) *weight.Weight {
+		v, _ := weight.NewWeightValue(value)
+		w, _ := weight.NewWeight(id, userID, v, kg, time.Now().AddDate(0, 0, -daysAgo), "")
+		return w
+	}
+	start := newWeight("w0", 80.0, 1)
+
+	tests := []struct {
+		name         string
+		recent       []*weight.Weight
+		noGoal       bool
+		wantAchieved bool
+	}{
+		{name: "first weight below target", recent: []*weight.Weight{newWeight("w2", 69.8, 0), newWeight("w1", 70.4, 1)}, wantAchieved: true},
+		{name: "still above target", recent: []*weight.Weight{newWeight("w2", 70.2, 0), newWeight("w1", 70.4, 1)}},
+		{name: "already reached before", recent: []*weight.Weight{newWeight("w2", 69.5, 0), newWeight("w1", 69.8, 1)}},
+		{name: "older measurement recorded late", recent: []*weight.Weight{newWeight("w9", 71.0, 0), newWeight("w2", 69.8, 1)}},
+		{name: "no active goal", recent: []*weight.Weight{newWeight("w2", 69.8, 0)}, noGoal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := NewMockUserRepository()
+			mockWeightRepo := NewMockWeightRepository()
+			mockGoalRepo := NewMockGoalRepository()
+			mockPublisher := &MockEventPublisher{}
+
+			if tt.noGoal {
+				mockGoalRepo.data["FindActiveByUserIDError"] = errors.New("no active goal")
+			} else {
+				mockGoalRepo.data["FindActiveByUserIDResult"] = activeGoal
+			}
+			mockWeightRepo.data["FindByUserIDResult"] = tt.recent
+			mockWeightRepo.data["FindByUserIDAndPeriodResult"] = []*weight.Weight{start}
+
+			tracker := NewGoalTracker(mockUserRepo, mockWeightRepo, mockGoalRepo, NewMockUnitOfWork(interfaces.Repositories{}), mockPublisher)
+
+			err := tracker.OnWeightRecorded(context.Background(), event.WeightRecorded{Base: event.NewBase(userID), WeightID: "w2"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !tt.wantAchieved {
+				if len(mockPublisher.events) != 0 {
+					t.Errorf("expected no events, got %v", mockPublisher.events)
+				}
+				return
+			}
+			if len(mockPublisher.events) != 1 {
+				t.Fatalf("expected 1 event, got %d", len(mockPublisher.events))
+			}
+			achieved, ok := mockPublisher.events[0].(event.GoalAchieved)
+			if !ok || achieved.GoalID != "g1" || achieved.Weight != 69.8 {
+				t.Errorf("expected GoalAchieved for g1 at 69.8, got %#v", mockPublisher.events[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"peso/internal/domain/idempotency"
+	"peso/internal/domain/user"
+	"peso/internal/interfaces"
+)
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// Idempotency remembers the responses to requests sent with an idempotency
+// key, so that a retry gets the original response instead of repeating the
+// request
+type Idempotency struct {
+	repo interfaces.IdempotencyRepository
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock serialises the requests made with one key. refs counts the
+// holder and the waiters, so the entry can be dropped once nobody uses it.
+type keyLock struct {
+	held chan struct{}
+	refs int
+}
+
+// NewIdempotency creates a new idempotency store
+func NewIdempotency(repo interfaces.IdempotencyRepository) *Idempotency {
+	return &Idempotency{
+		repo:  repo,
+		locks: make(map[string]*keyLock),
+	}
+}
+
+// Lock waits until no other request with the same key is being served in
+// this process, so that a double submit waits for the first request and
+// then replays its response instead of racing it. The returned function
+// releases the key.
+func (i *Idempotency) Lock(ctx context.Context, userID user.UserID, key string) (func(), error) {
+	ctx, span := tracer.Start(ctx, "Idempotency.Lock")
+	defer span.End()
+
+	id := userID.String() + "\x00" + key
+
+	i.mu.Lock()
+	l := i.locks[id]
+	if l == nil {
+		l = &keyLock{held: make(chan struct{}, 1)}
+		i.locks[id] = l
+	}
+	l.refs++
+	i.mu.Unlock()
+
+	release := func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(i.locks, id)
+		}
+	}
+
+	select {
+	case l.held <- struct{}{}:
+		return func() {
+			<-l.held
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// Lookup returns the record stored for key, or nil if the key is unused. It
+// fails with ErrIdempotencyKeyReused when the key was used for a request
+// with a different hash.
+func (i *Idempotency) Lookup(ctx context.Context, userID user.UserID, key, requestHash string) (*idempotency.Record, error) {
+	ctx, span := tracer.Start(ctx, "Idempotency.Lookup")
+	defer span.End()
+
+	if err := idempotency.ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	rec, err := i.repo.Find(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+	if rec != nil && !rec.Matches(requestHash) {
+		return nil, ErrIdempotencyKeyReused
+	}
+	return rec, nil
+}
+
+// Remember stores the response to the first request made with key. If a
+// concurrent request stored it first, that one wins.
+func (i *Idempotency) Remember(ctx context.Context, userID user.UserID, key, requestHash, resourceID string, statusCode int, headers map[string]string, response []byte) error {
+	ctx, span := tracer.Start(ctx, "Idempotency.Remember")
+	defer span.End()
+
+	rec, err := idempotency.NewRecord(userID, key, requestHash, resourceID, statusCode, headers, response)
+	if err != nil {
+		return err
+	}
+	if err := i.repo.Save(ctx, rec); err != nil && !errors.Is(err, interfaces.ErrConflict) {
+		return fmt.Errorf("failed to remember idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Purge forgets keys used before the cutoff; requests repeating them are
+// served again as new.
+func (i *Idempotency) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "Idempotency.Purge")
+	defer span.End()
+
+	return i.repo.Purge(ctx, before)
+}
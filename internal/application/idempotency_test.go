@@ -0,0 +1,114 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"peso/internal/domain/idempotency"
+	"peso/internal/domain/user"
+	"peso/internal/interfaces"
+)
+
+type MockIdempotencyRepository struct {
+	records map[string]*idempotency.Record
+	data    map[string]interface{}
+}
+
+func NewMockIdempotencyRepository() *MockIdempotencyRepository {
+	return &MockIdempotencyRepository{
+		records: make(map[string]*idempotency.Record),
+		data:    make(map[string]interface{}),
+	}
+}
+
+func (m *MockIdempotencyRepository) Find(ctx context.Context, userID user.UserID, key string) (*idempotency.Record, error) {
+	if err, ok := m.data["FindError"]; ok {
+		return nil, err.(error)
+	}
+	return m.records[userID.String()+"/"+key], nil
+}
+
+func (m *MockIdempotencyRepository) Save(ctx context.Context, rec *idempotency.Record) error {
+	id := rec.UserID().String() + "/" + rec.Key()
+	if _, ok := m.records[id]; ok {
+		return interfaces.ErrConflict
+	}
+	m.records[id] = rec
+	return nil
+}
+
+func (m *MockIdempotencyRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	for id, rec := range m.records {
+		if rec.CreatedAt().Before(before) {
+			delete(m.records, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestIdempotency_LookupAndRemember(t *testing.T) {
+	repo := NewMockIdempotencyRepository()
+	store := NewIdempotency(repo)
+	ctx := context.Background()
+
+	if rec, err := store.Lookup(ctx, "giada", "k1", "hash-1"); err != nil || rec != nil {
+		t.Fatalf("expected an unused key, got %v (err %v)", rec, err)
+	}
+	if err := store.Remember(ctx, "giada", "k1", "hash-1", "", 200, nil, []byte(`{"results":[]}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A concurrent request that lost the race must not fail
+	if err := store.Remember(ctx, "giada", "k1", "hash-1", "", 200, nil, []byte(`{}`)); err != nil {
+		t.Errorf("expected a second Remember to be ignored, got %v", err)
+	}
+
+	rec, err := store.Lookup(ctx, "giada", "k1", "hash-1")
+	if err != nil || rec == nil || string(rec.Response()) != `{"results":[]}` {
+		t.Fatalf("expected the first response back, got %v (err %v)", rec, err)
+	}
+	if _, err := store.Lookup(ctx, "giada", "k1", "hash-2"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+	if _, err := store.Lookup(ctx, "giada", "", "hash-1"); !errors.Is(err, idempotency.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestIdempotency_Lock(t *testing.T) {
+	store := NewIdempotency(NewMockIdempotencyRepository())
+	ctx := context.Background()
+
+	unlock, err := store.Lock(ctx, "giada", "k1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Other keys and other users are not blocked
+	other, err := store.Lock(ctx, "marco", "k1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other()
+
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(waitCtx, "giada", "k1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a second lock on the same key to wait, got %v", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		next, _ := store.Lock(ctx, "giada", "k1")
+		acquired <- next
+	}()
+	unlock()
+	(<-acquired)()
+
+	if n := len(store.locks); n != 0 {
+		t.Errorf("expected released keys to be forgotten, got %d", n)
+	}
+}
@@ -0,0 +1,7 @@
+package application
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts a span for each service method. Spans are dropped unless
+// tracing is enabled.
+var tracer = otel.Tracer("peso/internal/application")
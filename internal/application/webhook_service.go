@@ -0,0 +1,217 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"peso/internal/domain/audit"
+	"peso/internal/domain/event"
+	"peso/internal/domain/user"
+	"peso/internal/domain/webhook"
+	"peso/internal/interfaces"
+)
+
+const (
+	maxWebhooksPerUser     = 10
+	defaultDeliveryHistory = 20
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrTooManyWebhooks = errors.New("maximum number of webhooks reached")
+)
+
+// webhookPayload is the JSON body POSTed to a webhook
+type webhookPayload struct {
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// WebhookService manages users' webhooks and queues deliveries for the
+// domain events they subscribed to. Deliveries are sent by a dispatcher
+// reading the queue.
+type WebhookService struct {
+	webhookRepo  interfaces.WebhookRepository
+	deliveryRepo interfaces.WebhookDeliveryRepository
+	uow          interfaces.UnitOfWork
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(webhookRepo interfaces.WebhookRepository, deliveryRepo interfaces.WebhookDeliveryRepository, uow interfaces.UnitOfWork) *WebhookService {
+	return &WebhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		uow:          uow,
+	}
+}
+
+// Register adds a webhook for userID receiving the given event types
+func (s *WebhookService) Register(ctx context.Context, userID user.UserID, url string, events []string) (*webhook.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Register")
+	defer span.End()
+
+	w, err := webhook.NewWebhook(userID, url, events)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		existing, err := repos.Webhooks.FindByUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to count webhooks: %w", err)
+		}
+		if len(existing) >= maxWebhooksPerUser {
+			return ErrTooManyWebhooks
+		}
+
+		if err := repos.Webhooks.Save(ctx, w); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionWebhookCreated, w.ID().String(), nil, webhookSnapshot(w))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// List returns the webhooks of a user, oldest first
+func (s *WebhookService) List(ctx context.Context, userID user.UserID) ([]*webhook.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.List")
+	defer span.End()
+
+	webhooks, err := s.webhookRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// Delete removes one of the user's webhooks and its pending deliveries
+func (s *WebhookService) Delete(ctx context.Context, userID user.UserID, id webhook.WebhookID) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.Delete")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		w, err := s.owned(ctx, repos.Webhooks, userID, id)
+		if err != nil {
+			return err
+		}
+
+		if err := repos.Webhooks.Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionWebhookDeleted, id.String(), webhookSnapshot(w), nil)
+	})
+}
+
+// SendTest queues a webhook.test delivery so users can check their
+// receiver without waiting for a real event
+func (s *WebhookService) SendTest(ctx context.Context, userID user.UserID, id webhook.WebhookID) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.SendTest")
+	defer span.End()
+
+	w, err := s.owned(ctx, s.webhookRepo, userID, id)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		event.Base
+		Message string `json:"message"`
+	}{
+		Base:    event.NewBase(userID),
+		Message: "This is a test event from Peso",
+	}
+	d, err := newDelivery(w, webhook.EventTest, data.OccurredAt, data)
+	if err != nil {
+		return err
+	}
+
+	if err := s.deliveryRepo.Enqueue(ctx, d); err != nil {
+		return fmt.Errorf("failed to queue test event: %w", err)
+	}
+	return nil
+}
+
+// Deliveries returns the most recent deliveries of one of the user's
+// webhooks, newest first
+func (s *WebhookService) Deliveries(ctx context.Context, userID user.UserID, id webhook.WebhookID, limit int) ([]*webhook.Delivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Deliveries")
+	defer span.End()
+
+	if _, err := s.owned(ctx, s.webhookRepo, userID, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryHistory
+	}
+
+	deliveries, err := s.deliveryRepo.FindByWebhookID(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// OnEvent queues a delivery of e for every webhook of its user that
+// subscribed to it. The queue is written in one transaction, so either
+// every webhook gets the event or none does.
+func (s *WebhookService) OnEvent(ctx context.Context, e event.Event) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.OnEvent")
+	defer span.End()
+
+	webhooks, err := s.webhookRepo.FindByUserID(ctx, e.Subject())
+	if err != nil {
+		return fmt.Errorf("failed to find webhooks: %w", err)
+	}
+
+	var deliveries []*webhook.Delivery
+	for _, w := range webhooks {
+		if !w.Subscribes(e.Name()) {
+			continue
+		}
+		d, err := newDelivery(w, e.Name(), e.Time(), e)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return s.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		for _, d := range deliveries {
+			if err := repos.WebhookDeliveries.Enqueue(ctx, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// owned loads a webhook and checks that it belongs to userID
+func (s *WebhookService) owned(ctx context.Context, repo interfaces.WebhookRepository, userID user.UserID, id webhook.WebhookID) (*webhook.Webhook, error) {
+	w, err := repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, err.Error())
+	}
+	if w.UserID() != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return w, nil
+}
+
+func newDelivery(w *webhook.Webhook, eventName string, occurredAt time.Time, data any) (*webhook.Delivery, error) {
+	payload, err := json.Marshal(webhookPayload{Type: eventName, OccurredAt: occurredAt.UTC(), Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return webhook.NewDelivery(w.ID(), w.UserID(), eventName, payload), nil
+}
@@ -0,0 +1,188 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"peso/internal/domain/audit"
+	"peso/internal/domain/event"
+	"peso/internal/domain/user"
+	"peso/internal/domain/webhook"
+	"peso/internal/interfaces"
+)
+
+type MockWebhookRepository struct {
+	webhooks map[webhook.WebhookID]*webhook.Webhook
+	data     map[string]interface{}
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{
+		webhooks: make(map[webhook.WebhookID]*webhook.Webhook),
+		data:     make(map[string]interface{}),
+	}
+}
+
+func (m *MockWebhookRepository) Save(ctx context.Context, w *webhook.Webhook) error {
+	if err, ok := m.data["SaveError"]; ok {
+		return err.(error)
+	}
+	m.webhooks[w.ID()] = w
+	return nil
+}
+
+func (m *MockWebhookRepository) FindByID(ctx context.Context, id webhook.WebhookID) (*webhook.Webhook, error) {
+	if w, ok := m.webhooks[id]; ok {
+		return w, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *MockWebhookRepository) FindByUserID(ctx context.Context, userID user.UserID) ([]*webhook.Webhook, error) {
+	var result []*webhook.Webhook
+	for _, w := range m.webhooks {
+		if w.UserID() == userID {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id webhook.WebhookID) error {
+	delete(m.webhooks, id)
+	return nil
+}
+
+type MockWebhookDeliveryRepository struct {
+	queued []*webhook.Delivery
+}
+
+func (m *MockWebhookDeliveryRepository) Enqueue(ctx context.Context, d *webhook.Delivery) error {
+	m.queued = append(m.queued, d)
+	return nil
+}
+
+func (m *MockWebhookDeliveryRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*webhook.Delivery, error) {
+	return m.queued, nil
+}
+
+func (m *MockWebhookDeliveryRepository) RecordAttempt(ctx context.Context, d *webhook.Delivery, a webhook.Attempt) error {
+	return nil
+}
+
+func (m *MockWebhookDeliveryRepository) FindByWebhookID(ctx context.Context, id webhook.WebhookID, limit int) ([]*webhook.Delivery, error) {
+	var result []*webhook.Delivery
+	for _, d := range m.queued {
+		if d.WebhookID() == id {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+func newTestWebhookService() (*WebhookService, *MockWebhookRepository, *MockWebhookDeliveryRepository, *MockAuditRepository) {
+	hooks := NewMockWebhookRepository()
+	deliveries := &MockWebhookDeliveryRepository{}
+	auditRepo := NewMockAuditRepository()
+	uow := NewMockUnitOfWork(interfaces.Repositories{Webhooks: hooks, WebhookDeliveries: deliveries, Audit: auditRepo})
+	return NewWebhookService(hooks, deliveries, uow), hooks, deliveries, auditRepo
+}
+
+func TestWebhookService_Register(t *testing.T) {
+	service, hooks, _, auditRepo := newTestWebhookService()
+	ctx := context.Background()
+
+	w, err := service.Register(ctx, "giada", "http://homeassistant.local:8123/api/webhook/peso", []string{event.NameGoalAchieved})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auditRepo.events) != 1 || auditRepo.events[0].Action() != audit.ActionWebhookCreated {
+		t.Errorf("expected webhook.created audit event, got %d events", len(auditRepo.events))
+	}
+	if contains(string(auditRepo.events[0].After()), w.Secret()) {
+		t.Error("expected the secret to stay out of the audit log")
+	}
+
+	if _, err := service.Register(ctx, "giada", "not a url", []string{event.NameGoalAchieved}); !errors.Is(err, webhook.ErrInvalidURL) {
+		t.Errorf("expected ErrInvalidURL, got %v", err)
+	}
+
+	for len(hooks.webhooks) < maxWebhooksPerUser {
+		if _, err := service.Register(ctx, "giada", "https://example.com/hook", []string{event.NameGoalSet}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := service.Register(ctx, "giada", "https://example.com/hook", []string{event.NameGoalSet}); !errors.Is(err, ErrTooManyWebhooks) {
+		t.Errorf("expected ErrTooManyWebhooks, got %v", err)
+	}
+}
+
+func TestWebhookService_Ownership(t *testing.T) {
+	service, _, deliveries, _ := newTestWebhookService()
+	ctx := context.Background()
+
+	w, err := service.Register(ctx, "giada", "https://example.com/hook", []string{event.NameGoalSet})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := service.SendTest(ctx, "emilio", w.ID()); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound sending another user's test event, got %v", err)
+	}
+	if err := service.Delete(ctx, "emilio", w.ID()); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound deleting another user's webhook, got %v", err)
+	}
+
+	if err := service.SendTest(ctx, "giada", w.ID()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deliveries.queued) != 1 || deliveries.queued[0].Event() != webhook.EventTest {
+		t.Fatalf("expected a queued test event, got %d deliveries", len(deliveries.queued))
+	}
+
+	if err := service.Delete(ctx, "giada", w.ID()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWebhookService_OnEvent(t *testing.T) {
+	service, _, deliveries, _ := newTestWebhookService()
+	ctx := context.Background()
+
+	if _, err := service.Register(ctx, "giada", "https://example.com/goals", []string{event.NameGoalAchieved}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Register(ctx, "giada", "https://example.com/all", []string{event.NameGoalAchieved, event.NameWeightRecorded}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Register(ctx, "emilio", "https://example.com/emilio", []string{event.NameWeightRecorded}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorded := event.WeightRecorded{Base: event.NewBase("giada"), WeightID: "w1", Value: 70.2, Unit: "kg"}
+	if err := service.OnEvent(ctx, recorded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(deliveries.queued) != 1 {
+		t.Fatalf("expected 1 delivery for giada's subscribed webhook, got %d", len(deliveries.queued))
+	}
+
+	var payload struct {
+		Type string `json:"type"`
+		Data struct {
+			UserID   string  `json:"user_id"`
+			WeightID string  `json:"weight_id"`
+			Value    float64 `json:"value"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(deliveries.queued[0].Payload(), &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload.Type != event.NameWeightRecorded || payload.Data.UserID != "giada" || payload.Data.WeightID != "w1" || payload.Data.Value != 70.2 {
+		t.Errorf("unexpected payload %s", deliveries.queued[0].Payload())
+	}
+}
@@ -1,10 +1,16 @@
 package application
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/google/uuid"
+	"peso/internal/domain/audit"
+	"peso/internal/domain/event"
+	"peso/internal/domain/idempotency"
 	"peso/internal/domain/user"
 	"peso/internal/domain/weight"
 	"peso/internal/interfaces"
@@ -46,6 +52,10 @@ type WeightTrend struct {
 type WeightTracker struct {
 	userRepo   interfaces.UserRepository
 	weightRepo interfaces.WeightRepository
+	uow        interfaces.UnitOfWork
+	publisher  interfaces.EventPublisher
+
+	maxDailyRecordings int
 }
 
 var (
@@ -54,20 +64,35 @@ var (
 	ErrMaxDailyRecordings = errors.New("maximum daily weight recordings exceeded")
 )
 
-const maxDailyWeightRecordings = 10
+// DefaultMaxDailyRecordings is how many weights a user may record per day
+// unless configured otherwise.
+const DefaultMaxDailyRecordings = 10
 
 // NewWeightTracker creates a new weight tracker service
-func NewWeightTracker(userRepo interfaces.UserRepository, weightRepo interfaces.WeightRepository) *WeightTracker {
+func NewWeightTracker(userRepo interfaces.UserRepository, weightRepo interfaces.WeightRepository, uow interfaces.UnitOfWork, publisher interfaces.EventPublisher) *WeightTracker {
 	return &WeightTracker{
 		userRepo:   userRepo,
 		weightRepo: weightRepo,
+		uow:        uow,
+		publisher:  publisher,
+
+		maxDailyRecordings: DefaultMaxDailyRecordings,
 	}
 }
 
+// SetMaxDailyRecordings changes how many weights a user may record per
+// calendar day.
+func (wt *WeightTracker) SetMaxDailyRecordings(n int) {
+	wt.maxDailyRecordings = n
+}
+
 // RecordWeight records a new weight measurement for a user
-func (wt *WeightTracker) RecordWeight(userID user.UserID, value weight.WeightValue, unit weight.WeightUnit, measuredAt time.Time, notes string) (*weight.Weight, error) {
+func (wt *WeightTracker) RecordWeight(ctx context.Context, userID user.UserID, value weight.WeightValue, unit weight.WeightUnit, measuredAt time.Time, notes string) (*weight.Weight, error) {
+	ctx, span := tracer.Start(ctx, "WeightTracker.RecordWeight")
+	defer span.End()
+
 	// Verify user exists and is active
-	u, err := wt.userRepo.FindByID(userID)
+	u, err := wt.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err.Error())
 	}
@@ -76,39 +101,197 @@ func (wt *WeightTracker) RecordWeight(userID user.UserID, value weight.WeightVal
 		return nil, ErrUserNotActive
 	}
 
-	// Check daily recording limit
-	dayStart := time.Date(measuredAt.Year(), measuredAt.Month(), measuredAt.Day(), 0, 0, 0, 0, measuredAt.Location())
-	dailyCount, err := wt.weightRepo.CountByUserIDAndDate(userID, dayStart)
+	w, err := weight.NewWeight(uuid.NewString(), userID, value, unit, measuredAt, notes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check daily recording count: %w", err)
+		return nil, fmt.Errorf("failed to create weight record: %w", err)
 	}
 
-	if dailyCount >= maxDailyWeightRecordings {
-		return nil, ErrMaxDailyRecordings
+	err = wt.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		return wt.saveWeight(ctx, repos, w)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate a unique ID for the weight record
-	weightID := fmt.Sprintf("weight_%s_%d", userID.String(), time.Now().UnixNano())
+	wt.publishRecorded(ctx, w)
+	return w, nil
+}
+
+// WeightEntry is a measurement queued by a client, typically while
+// offline. Key identifies it across retries.
+type WeightEntry struct {
+	Key        string
+	Value      float64
+	Unit       string
+	MeasuredAt time.Time
+	Notes      string
+}
+
+// EntryStatus is the outcome of one queued measurement
+type EntryStatus string
+
+const (
+	EntryCreated   EntryStatus = "created"
+	EntryDuplicate EntryStatus = "duplicate"
+	EntryRejected  EntryStatus = "rejected"
+)
+
+// WeightEntryResult reports what happened to one queued measurement
+type WeightEntryResult struct {
+	Key      string
+	Status   EntryStatus
+	WeightID string
+	Err      error
+}
 
-	// Create weight record
-	w, err := weight.NewWeight(weightID, userID, value, unit, measuredAt, notes)
+var ErrMissingMeasurementTime = errors.New("measurement time is required")
+
+// RecordWeights records queued measurements, each at most once per key: an
+// entry whose key was already recorded is reported as a duplicate of the
+// original weight. Entries that cannot be recorded are rejected without
+// affecting the others. An error means the batch was interrupted; the
+// entries recorded so far stay recorded, so it is safe to retry.
+func (wt *WeightTracker) RecordWeights(ctx context.Context, userID user.UserID, entries []WeightEntry) ([]WeightEntryResult, error) {
+	ctx, span := tracer.Start(ctx, "WeightTracker.RecordWeights")
+	defer span.End()
+
+	u, err := wt.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create weight record: %w", err)
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err.Error())
 	}
 
-	// Save to repository
-	if err := wt.weightRepo.Save(w); err != nil {
-		return nil, fmt.Errorf("failed to save weight record: %w", err)
+	if !u.IsActive() {
+		return nil, ErrUserNotActive
 	}
 
-	return w, nil
+	results := make([]WeightEntryResult, 0, len(entries))
+	for _, entry := range entries {
+		result, err := wt.recordEntry(ctx, userID, entry)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (wt *WeightTracker) recordEntry(ctx context.Context, userID user.UserID, entry WeightEntry) (WeightEntryResult, error) {
+	result := WeightEntryResult{Key: entry.Key}
+	reject := func(err error) (WeightEntryResult, error) {
+		result.Status = EntryRejected
+		result.Err = err
+		return result, nil
+	}
+
+	if err := idempotency.ValidateKey(entry.Key); err != nil {
+		return reject(err)
+	}
+	if entry.MeasuredAt.IsZero() {
+		return reject(ErrMissingMeasurementTime)
+	}
+	value, err := weight.NewWeightValue(entry.Value)
+	if err != nil {
+		return reject(err)
+	}
+	unit, err := weight.NewWeightUnit(entry.Unit)
+	if err != nil {
+		return reject(err)
+	}
+
+	measuredAt := entry.MeasuredAt.Local()
+	w, err := weight.NewWeight(uuid.NewString(), userID, value, unit, measuredAt, entry.Notes)
+	if err != nil {
+		return reject(err)
+	}
+
+	hash := idempotency.Fingerprint("weight", strconv.FormatFloat(value.Float64(), 'f', -1, 64), unit.String(), measuredAt.UTC().Format(time.RFC3339Nano), entry.Notes)
+
+	// A concurrent request with the same key makes the insert conflict;
+	// the second attempt then finds its record.
+	for attempt := 0; ; attempt++ {
+		err = wt.uow.Do(ctx, func(repos interfaces.Repositories) error {
+			existing, err := repos.Idempotency.Find(ctx, userID, entry.Key)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				if !existing.Matches(hash) {
+					return ErrIdempotencyKeyReused
+				}
+				result.Status = EntryDuplicate
+				result.WeightID = existing.ResourceID()
+				return nil
+			}
+
+			if err := wt.saveWeight(ctx, repos, w); err != nil {
+				return err
+			}
+			rec, err := idempotency.NewRecord(userID, entry.Key, hash, w.ID().String(), 0, nil, nil)
+			if err != nil {
+				return err
+			}
+			result.Status = EntryCreated
+			result.WeightID = w.ID().String()
+			return repos.Idempotency.Save(ctx, rec)
+		})
+		if errors.Is(err, interfaces.ErrConflict) && attempt == 0 {
+			continue
+		}
+		break
+	}
+
+	switch {
+	case errors.Is(err, ErrMaxDailyRecordings), errors.Is(err, ErrIdempotencyKeyReused):
+		return reject(err)
+	case err != nil:
+		return WeightEntryResult{}, err
+	}
+
+	if result.Status == EntryCreated {
+		wt.publishRecorded(ctx, w)
+	}
+	return result, nil
+}
+
+// saveWeight counts and inserts in one transaction so concurrent requests
+// cannot both pass the daily limit check
+func (wt *WeightTracker) saveWeight(ctx context.Context, repos interfaces.Repositories, w *weight.Weight) error {
+	measuredAt := w.MeasuredAt()
+	dayStart := time.Date(measuredAt.Year(), measuredAt.Month(), measuredAt.Day(), 0, 0, 0, 0, measuredAt.Location())
+	dailyCount, err := repos.Weights.CountByUserIDAndDate(ctx, w.UserID(), dayStart)
+	if err != nil {
+		return fmt.Errorf("failed to check daily recording count: %w", err)
+	}
+
+	if dailyCount >= wt.maxDailyRecordings {
+		return ErrMaxDailyRecordings
+	}
+
+	if err := repos.Weights.Save(ctx, w); err != nil {
+		return fmt.Errorf("failed to save weight record: %w", err)
+	}
+	return recordAudit(ctx, repos.Audit, w.UserID(), "", audit.ActionWeightRecorded, w.ID().String(), nil, weightSnapshot(w))
+}
+
+func (wt *WeightTracker) publishRecorded(ctx context.Context, w *weight.Weight) {
+	wt.publisher.Publish(ctx, event.WeightRecorded{
+		Base:       event.NewBase(w.UserID()),
+		WeightID:   w.ID().String(),
+		Value:      w.Value().Float64(),
+		Unit:       w.Unit().String(),
+		MeasuredAt: w.MeasuredAt(),
+		Notes:      w.Notes(),
+	})
 }
 
 // GetWeightHistory retrieves weight history for a user within a time period
-func (wt *WeightTracker) GetWeightHistory(userID user.UserID, period TimePeriod) ([]*weight.Weight, error) {
+func (wt *WeightTracker) GetWeightHistory(ctx context.Context, userID user.UserID, period TimePeriod) ([]*weight.Weight, error) {
+	ctx, span := tracer.Start(ctx, "WeightTracker.GetWeightHistory")
+	defer span.End()
+
 	from, to := wt.getPeriodBounds(period)
 
-	weights, err := wt.weightRepo.FindByUserIDAndPeriod(userID, from, to)
+	weights, err := wt.weightRepo.FindByUserIDAndPeriod(ctx, userID, from, to)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve weight history: %w", err)
 	}
@@ -117,15 +300,18 @@ func (wt *WeightTracker) GetWeightHistory(userID user.UserID, period TimePeriod)
 }
 
 // GetRecentWeights retrieves the most recent N weights for a user (descending by date)
-func (wt *WeightTracker) GetRecentWeights(userID user.UserID, limit int) ([]*weight.Weight, error) {
+func (wt *WeightTracker) GetRecentWeights(ctx context.Context, userID user.UserID, limit int) ([]*weight.Weight, error) {
+	ctx, span := tracer.Start(ctx, "WeightTracker.GetRecentWeights")
+	defer span.End()
+
 	// Verify user exists
-	if _, err := wt.userRepo.FindByID(userID); err != nil {
+	if _, err := wt.userRepo.FindByID(ctx, userID); err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err.Error())
 	}
 	if limit <= 0 {
 		limit = 10
 	}
-	ws, err := wt.weightRepo.FindByUserID(userID, limit)
+	ws, err := wt.weightRepo.FindByUserID(ctx, userID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve recent weights: %w", err)
 	}
@@ -133,12 +319,15 @@ func (wt *WeightTracker) GetRecentWeights(userID user.UserID, limit int) ([]*wei
 }
 
 // GetLatestWeight returns the most recent weight for a user
-func (wt *WeightTracker) GetLatestWeight(userID user.UserID) (*weight.Weight, error) {
+func (wt *WeightTracker) GetLatestWeight(ctx context.Context, userID user.UserID) (*weight.Weight, error) {
+	ctx, span := tracer.Start(ctx, "WeightTracker.GetLatestWeight")
+	defer span.End()
+
 	// Verify user exists
-	if _, err := wt.userRepo.FindByID(userID); err != nil {
+	if _, err := wt.userRepo.FindByID(ctx, userID); err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err.Error())
 	}
-	w, err := wt.weightRepo.FindLatestByUserID(userID)
+	w, err := wt.weightRepo.FindLatestByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve latest weight: %w", err)
 	}
@@ -146,8 +335,11 @@ func (wt *WeightTracker) GetLatestWeight(userID user.UserID) (*weight.Weight, er
 }
 
 // CalculateWeightTrend calculates weight trend over a time period
-func (wt *WeightTracker) CalculateWeightTrend(userID user.UserID, period TimePeriod) (WeightTrend, error) {
-	weights, err := wt.GetWeightHistory(userID, period)
+func (wt *WeightTracker) CalculateWeightTrend(ctx context.Context, userID user.UserID, period TimePeriod) (WeightTrend, error) {
+	ctx, span := tracer.Start(ctx, "WeightTracker.CalculateWeightTrend")
+	defer span.End()
+
+	weights, err := wt.GetWeightHistory(ctx, userID, period)
 	if err != nil {
 		return WeightTrend{}, err
 	}
@@ -224,29 +416,82 @@ func (wt *WeightTracker) getPeriodBounds(period TimePeriod) (from, to time.Time)
 }
 
 // DeleteWeight removes a weight record
-func (wt *WeightTracker) DeleteWeight(userID user.UserID, weightID weight.WeightID) error {
-	// Verify user exists
-	if _, err := wt.userRepo.FindByID(userID); err != nil {
-		return fmt.Errorf("%w: %s", ErrUserNotFound, err.Error())
-	}
-
-	// Get weight to verify it belongs to the user
-	w, err := wt.weightRepo.FindByID(weightID)
+func (wt *WeightTracker) DeleteWeight(ctx context.Context, userID user.UserID, weightID weight.WeightID) error {
+	ctx, span := tracer.Start(ctx, "WeightTracker.DeleteWeight")
+	defer span.End()
+
+	// The ownership check and the delete share a transaction, so the
+	// record cannot change hands in between
+	err := wt.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		if _, err := repos.Users.FindByID(ctx, userID); err != nil {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, err.Error())
+		}
+
+		w, err := repos.Weights.FindByID(ctx, weightID)
+		if err != nil {
+			return fmt.Errorf("weight not found: %w", err)
+		}
+		if w.UserID() != userID {
+			return fmt.Errorf("weight does not belong to user")
+		}
+
+		if err := repos.Weights.Delete(ctx, weightID); err != nil {
+			return fmt.Errorf("failed to delete weight: %w", err)
+		}
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionWeightDeleted, weightID.String(), weightSnapshot(w), nil)
+	})
 	if err != nil {
-		return fmt.Errorf("weight not found: %w", err)
+		return err
 	}
 
-	if w.UserID() != userID {
-		return fmt.Errorf("weight does not belong to user")
-	}
+	wt.publisher.Publish(ctx, event.WeightDeleted{Base: event.NewBase(userID), WeightID: weightID.String()})
+	return nil
+}
 
-	if err := wt.weightRepo.Delete(weightID); err != nil {
-		return fmt.Errorf("failed to delete weight: %w", err)
+// RestoreWeight brings back a deleted weight record. The daily limit is
+// checked again because other records may have been added in the meantime.
+func (wt *WeightTracker) RestoreWeight(ctx context.Context, userID user.UserID, weightID weight.WeightID) error {
+	ctx, span := tracer.Start(ctx, "WeightTracker.RestoreWeight")
+	defer span.End()
+
+	err := wt.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		if err := repos.Weights.Restore(ctx, userID, weightID); err != nil {
+			return fmt.Errorf("failed to restore weight: %w", err)
+		}
+
+		w, err := repos.Weights.FindByID(ctx, weightID)
+		if err != nil {
+			return fmt.Errorf("weight not found: %w", err)
+		}
+
+		measuredAt := w.MeasuredAt()
+		dayStart := time.Date(measuredAt.Year(), measuredAt.Month(), measuredAt.Day(), 0, 0, 0, 0, measuredAt.Location())
+		dailyCount, err := repos.Weights.CountByUserIDAndDate(ctx, userID, dayStart)
+		if err != nil {
+			return fmt.Errorf("failed to check daily recording count: %w", err)
+		}
+
+		if dailyCount > wt.maxDailyRecordings {
+			return ErrMaxDailyRecordings
+		}
+		return recordAudit(ctx, repos.Audit, userID, "", audit.ActionWeightRestored, weightID.String(), nil, weightSnapshot(w))
+	})
+	if err != nil {
+		return err
 	}
 
+	wt.publisher.Publish(ctx, event.WeightRestored{Base: event.NewBase(userID), WeightID: weightID.String()})
 	return nil
 }
 
+// PurgeDeleted permanently removes weight records deleted before the cutoff
+func (wt *WeightTracker) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "WeightTracker.PurgeDeleted")
+	defer span.End()
+
+	return wt.weightRepo.PurgeDeleted(ctx, before)
+}
+
 // abs returns the absolute value of a float64
 func abs(x float64) float64 {
 	if x < 0 {
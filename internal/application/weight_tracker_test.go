@@ -1,12 +1,17 @@
 package application
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
+	"peso/internal/domain/audit"
+	"peso/internal/domain/event"
 	"peso/internal/domain/user"
 	"peso/internal/domain/weight"
+	"peso/internal/interfaces"
 )
 
 // Mock repositories
@@ -22,7 +27,7 @@ func NewMockUserRepository() *MockUserRepository {
 	}
 }
 
-func (m *MockUserRepository) Save(u *user.User) error {
+func (m *MockUserRepository) Save(ctx context.Context, u *user.User) error {
 	m.calls["Save"] = append(m.calls["Save"], u)
 	if err, ok := m.data["SaveError"]; ok {
 		return err.(error)
@@ -30,7 +35,7 @@ func (m *MockUserRepository) Save(u *user.User) error {
 	return nil
 }
 
-func (m *MockUserRepository) FindByID(id user.UserID) (*user.User, error) {
+func (m *MockUserRepository) FindByID(ctx context.Context, id user.UserID) (*user.User, error) {
 	m.calls["FindByID"] = append(m.calls["FindByID"], id)
 	if err, ok := m.data["FindByIDError"]; ok {
 		return nil, err.(error)
@@ -41,7 +46,7 @@ func (m *MockUserRepository) FindByID(id user.UserID) (*user.User, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *MockUserRepository) FindByName(name string) (*user.User, error) {
+func (m *MockUserRepository) FindByName(ctx context.Context, name string) (*user.User, error) {
 	m.calls["FindByName"] = append(m.calls["FindByName"], name)
 	if err, ok := m.data["FindByNameError"]; ok {
 		return nil, err.(error)
@@ -52,7 +57,7 @@ func (m *MockUserRepository) FindByName(name string) (*user.User, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *MockUserRepository) FindActive() ([]*user.User, error) {
+func (m *MockUserRepository) FindActive(ctx context.Context) ([]*user.User, error) {
 	m.calls["FindActive"] = append(m.calls["FindActive"], nil)
 	if err, ok := m.data["FindActiveError"]; ok {
 		return nil, err.(error)
@@ -63,7 +68,7 @@ func (m *MockUserRepository) FindActive() ([]*user.User, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *MockUserRepository) Exists(id user.UserID) (bool, error) {
+func (m *MockUserRepository) Exists(ctx context.Context, id user.UserID) (bool, error) {
 	m.calls["Exists"] = append(m.calls["Exists"], id)
 	if err, ok := m.data["ExistsError"]; ok {
 		return false, err.(error)
@@ -74,7 +79,7 @@ func (m *MockUserRepository) Exists(id user.UserID) (bool, error) {
 	return false, nil
 }
 
-func (m *MockUserRepository) FindByEmail(email string) (*user.User, error) {
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
 	m.calls["FindByEmail"] = append(m.calls["FindByEmail"], email)
 	if err, ok := m.data["FindByEmailError"]; ok {
 		return nil, err.(error)
@@ -85,7 +90,7 @@ func (m *MockUserRepository) FindByEmail(email string) (*user.User, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *MockUserRepository) EmailExists(email string) (bool, error) {
+func (m *MockUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
 	m.calls["EmailExists"] = append(m.calls["EmailExists"], email)
 	if err, ok := m.data["EmailExistsError"]; ok {
 		return false, err.(error)
@@ -96,6 +101,58 @@ func (m *MockUserRepository) EmailExists(email string) (bool, error) {
 	return false, nil
 }
 
+// MockUnitOfWork runs the callback directly against the mock repositories.
+type MockUnitOfWork struct {
+	repos interfaces.Repositories
+	calls int
+}
+
+func NewMockUnitOfWork(repos interfaces.Repositories) *MockUnitOfWork {
+	if repos.Audit == nil {
+		repos.Audit = NewMockAuditRepository()
+	}
+	return &MockUnitOfWork{repos: repos}
+}
+
+func (m *MockUnitOfWork) Do(ctx context.Context, fn func(repos interfaces.Repositories) error) error {
+	m.calls++
+	return fn(m.repos)
+}
+
+type MockAuditRepository struct {
+	events []*audit.Event
+	data   map[string]interface{}
+}
+
+func NewMockAuditRepository() *MockAuditRepository {
+	return &MockAuditRepository{data: make(map[string]interface{})}
+}
+
+func (m *MockAuditRepository) Append(ctx context.Context, e *audit.Event) error {
+	if err, ok := m.data["AppendError"]; ok {
+		return err.(error)
+	}
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *MockAuditRepository) Find(ctx context.Context, q interfaces.AuditQuery) ([]*audit.Event, error) {
+	m.data["FindQuery"] = q
+	if err, ok := m.data["FindError"]; ok {
+		return nil, err.(error)
+	}
+	return m.events, nil
+}
+
+// MockEventPublisher records published events.
+type MockEventPublisher struct {
+	events []event.Event
+}
+
+func (m *MockEventPublisher) Publish(ctx context.Context, events ...event.Event) {
+	m.events = append(m.events, events...)
+}
+
 type MockWeightRepository struct {
 	calls map[string][]interface{}
 	data  map[string]interface{}
@@ -108,7 +165,7 @@ func NewMockWeightRepository() *MockWeightRepository {
 	}
 }
 
-func (m *MockWeightRepository) Save(w *weight.Weight) error {
+func (m *MockWeightRepository) Save(ctx context.Context, w *weight.Weight) error {
 	m.calls["Save"] = append(m.calls["Save"], w)
 	if err, ok := m.data["SaveError"]; ok {
 		return err.(error)
@@ -116,7 +173,7 @@ func (m *MockWeightRepository) Save(w *weight.Weight) error {
 	return nil
 }
 
-func (m *MockWeightRepository) FindByID(id weight.WeightID) (*weight.Weight, error) {
+func (m *MockWeightRepository) FindByID(ctx context.Context, id weight.WeightID) (*weight.Weight, error) {
 	m.calls["FindByID"] = append(m.calls["FindByID"], id)
 	if err, ok := m.data["FindByIDError"]; ok {
 		return nil, err.(error)
@@ -127,7 +184,7 @@ func (m *MockWeightRepository) FindByID(id weight.WeightID) (*weight.Weight, err
 	return nil, errors.New("not found")
 }
 
-func (m *MockWeightRepository) FindByUserID(userID user.UserID, limit int) ([]*weight.Weight, error) {
+func (m *MockWeightRepository) FindByUserID(ctx context.Context, userID user.UserID, limit int) ([]*weight.Weight, error) {
 	m.calls["FindByUserID"] = append(m.calls["FindByUserID"], userID, limit)
 	if err, ok := m.data["FindByUserIDError"]; ok {
 		return nil, err.(error)
@@ -138,7 +195,7 @@ func (m *MockWeightRepository) FindByUserID(userID user.UserID, limit int) ([]*w
 	return nil, errors.New("not found")
 }
 
-func (m *MockWeightRepository) FindByUserIDAndPeriod(userID user.UserID, from, to time.Time) ([]*weight.Weight, error) {
+func (m *MockWeightRepository) FindByUserIDAndPeriod(ctx context.Context, userID user.UserID, from, to time.Time) ([]*weight.Weight, error) {
 	m.calls["FindByUserIDAndPeriod"] = append(m.calls["FindByUserIDAndPeriod"], userID, from, to)
 	if err, ok := m.data["FindByUserIDAndPeriodError"]; ok {
 		return nil, err.(error)
@@ -149,7 +206,7 @@ func (m *MockWeightRepository) FindByUserIDAndPeriod(userID user.UserID, from, t
 	return nil, errors.New("not found")
 }
 
-func (m *MockWeightRepository) FindLatestByUserID(userID user.UserID) (*weight.Weight, error) {
+func (m *MockWeightRepository) FindLatestByUserID(ctx context.Context, userID user.UserID) (*weight.Weight, error) {
 	m.calls["FindLatestByUserID"] = append(m.calls["FindLatestByUserID"], userID)
 	if err, ok := m.data["FindLatestByUserIDError"]; ok {
 		return nil, err.(error)
@@ -160,7 +217,7 @@ func (m *MockWeightRepository) FindLatestByUserID(userID user.UserID) (*weight.W
 	return nil, errors.New("not found")
 }
 
-func (m *MockWeightRepository) CountByUserIDAndDate(userID user.UserID, date time.Time) (int, error) {
+func (m *MockWeightRepository) CountByUserIDAndDate(ctx context.Context, userID user.UserID, date time.Time) (int, error) {
 	m.calls["CountByUserIDAndDate"] = append(m.calls["CountByUserIDAndDate"], userID, date)
 	if err, ok := m.data["CountByUserIDAndDateError"]; ok {
 		return 0, err.(error)
@@ -171,7 +228,7 @@ func (m *MockWeightRepository) CountByUserIDAndDate(userID user.UserID, date tim
 	return 0, nil
 }
 
-func (m *MockWeightRepository) Delete(id weight.WeightID) error {
+func (m *MockWeightRepository) Delete(ctx context.Context, id weight.WeightID) error {
 	m.calls["Delete"] = append(m.calls["Delete"], id)
 	if err, ok := m.data["DeleteError"]; ok {
 		return err.(error)
@@ -179,6 +236,25 @@ func (m *MockWeightRepository) Delete(id weight.WeightID) error {
 	return nil
 }
 
+func (m *MockWeightRepository) Restore(ctx context.Context, userID user.UserID, id weight.WeightID) error {
+	m.calls["Restore"] = append(m.calls["Restore"], userID, id)
+	if err, ok := m.data["RestoreError"]; ok {
+		return err.(error)
+	}
+	return nil
+}
+
+func (m *MockWeightRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	m.calls["PurgeDeleted"] = append(m.calls["PurgeDeleted"], before)
+	if err, ok := m.data["PurgeDeletedError"]; ok {
+		return 0, err.(error)
+	}
+	if n, ok := m.data["PurgeDeletedResult"]; ok {
+		return n.(int64), nil
+	}
+	return 0, nil
+}
+
 func TestWeightTracker_RecordWeight(t *testing.T) {
 	userID, _ := user.NewUserID("giada")
 	value, _ := weight.NewWeightValue(70.5)
@@ -194,6 +270,7 @@ func TestWeightTracker_RecordWeight(t *testing.T) {
 		value        weight.WeightValue
 		unit         weight.WeightUnit
 		measuredAt   time.Time
+		maxDaily     int
 		expectErr    bool
 		errorMessage string
 	}{
@@ -248,6 +325,20 @@ func TestWeightTracker_RecordWeight(t *testing.T) {
 			expectErr:    true,
 			errorMessage: "maximum daily weight recordings exceeded",
 		},
+		{
+			name: "configured daily limit",
+			setupMocks: func(ur *MockUserRepository, wr *MockWeightRepository) {
+				ur.data["FindByIDResult"] = testUser
+				wr.data["CountByUserIDAndDateResult"] = 2
+			},
+			userID:       userID,
+			value:        value,
+			unit:         unit,
+			measuredAt:   measuredAt,
+			maxDaily:     2,
+			expectErr:    true,
+			errorMessage: "maximum daily weight recordings exceeded",
+		},
 	}
 
 	for _, tt := range tests {
@@ -255,11 +346,18 @@ func TestWeightTracker_RecordWeight(t *testing.T) {
 			mockUserRepo := NewMockUserRepository()
 			mockWeightRepo := NewMockWeightRepository()
 
+			mockAuditRepo := NewMockAuditRepository()
+			mockPublisher := &MockEventPublisher{}
+
 			tt.setupMocks(mockUserRepo, mockWeightRepo)
 
-			tracker := NewWeightTracker(mockUserRepo, mockWeightRepo)
+			tracker := NewWeightTracker(mockUserRepo, mockWeightRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo, Audit: mockAuditRepo}), mockPublisher)
+			if tt.maxDaily > 0 {
+				tracker.SetMaxDailyRecordings(tt.maxDaily)
+			}
 
-			result, err := tracker.RecordWeight(tt.userID, tt.value, tt.unit, tt.measuredAt, "")
+			ctx := audit.WithMetadata(context.Background(), audit.Metadata{ActorID: tt.userID, RequestID: "req-1"})
+			result, err := tracker.RecordWeight(ctx, tt.userID, tt.value, tt.unit, tt.measuredAt, "")
 
 			if tt.expectErr {
 				if err == nil {
@@ -270,6 +368,9 @@ func TestWeightTracker_RecordWeight(t *testing.T) {
 				if result != nil {
 					t.Errorf("expected nil result but got %v", result)
 				}
+				if len(mockPublisher.events) != 0 {
+					t.Errorf("expected no events published, got %d", len(mockPublisher.events))
+				}
 			} else {
 				if err != nil {
 					t.Errorf("unexpected error: %v", err)
@@ -283,6 +384,189 @@ func TestWeightTracker_RecordWeight(t *testing.T) {
 				if result != nil && result.Value().Float64() != tt.value.Float64() {
 					t.Errorf("expected value %f but got %f", tt.value.Float64(), result.Value().Float64())
 				}
+				if len(mockAuditRepo.events) != 1 {
+					t.Fatalf("expected 1 audit event, got %d", len(mockAuditRepo.events))
+				}
+				auditEvent := mockAuditRepo.events[0]
+				if auditEvent.Action() != audit.ActionWeightRecorded || auditEvent.ActorID() != tt.userID || auditEvent.RequestID() != "req-1" {
+					t.Errorf("expected weight.recorded by %s in req-1, got %s by %s in %q", tt.userID, auditEvent.Action(), auditEvent.ActorID(), auditEvent.RequestID())
+				}
+				if len(mockPublisher.events) != 1 {
+					t.Fatalf("expected 1 event published, got %d", len(mockPublisher.events))
+				}
+				recorded, ok := mockPublisher.events[0].(event.WeightRecorded)
+				if !ok || recorded.WeightID != result.ID().String() || recorded.Subject() != tt.userID {
+					t.Errorf("expected WeightRecorded for %s, got %#v", result.ID(), mockPublisher.events[0])
+				}
+			}
+		})
+	}
+}
+
+func TestWeightTracker_RecordWeights(t *testing.T) {
+	testUser, _ := user.NewUser("giada", "Giada", "")
+	userRepo := NewMockUserRepository()
+	userRepo.data["FindByIDResult"] = testUser
+	weightRepo := NewMockWeightRepository()
+	keys := NewMockIdempotencyRepository()
+	publisher := &MockEventPublisher{}
+	tracker := NewWeightTracker(userRepo, weightRepo, NewMockUnitOfWork(interfaces.Repositories{Users: userRepo, Weights: weightRepo, Idempotency: keys}), publisher)
+
+	measuredAt := time.Now().Add(-2 * time.Hour)
+	entries := []WeightEntry{
+		{Key: "k1", Value: 72.4, Unit: "kg", MeasuredAt: measuredAt},
+		{Key: "k2", Value: 0, Unit: "kg", MeasuredAt: measuredAt},
+		{Key: "k3", Value: 72.1, Unit: "kg", MeasuredAt: time.Now().AddDate(0, 0, 2)},
+		{Key: "", Value: 72.1, Unit: "kg", MeasuredAt: measuredAt},
+		{Key: "k4", Value: 72.1, Unit: "kg"},
+	}
+
+	results, err := tracker.RecordWeights(context.Background(), "giada", entries)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []EntryStatus{EntryCreated, EntryRejected, EntryRejected, EntryRejected, EntryRejected}
+	for i, res := range results {
+		if res.Status != want[i] {
+			t.Errorf("entry %d: expected %s, got %s (%v)", i, want[i], res.Status, res.Err)
+		}
+	}
+	if len(weightRepo.calls["Save"]) != 1 || len(publisher.events) != 1 {
+		t.Fatalf("expected 1 weight saved and published, got %d and %d", len(weightRepo.calls["Save"]), len(publisher.events))
+	}
+	firstID := results[0].WeightID
+
+	// The queue resends k1 after losing the response, along with an entry
+	// that reuses k1 for a different measurement
+	results, err = tracker.RecordWeights(context.Background(), "giada", []WeightEntry{
+		{Key: "k1", Value: 72.4, Unit: "kg", MeasuredAt: measuredAt},
+		{Key: "k1", Value: 80, Unit: "kg", MeasuredAt: measuredAt},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Status != EntryDuplicate || results[0].WeightID != firstID {
+		t.Errorf("expected a duplicate of %s, got %s %s", firstID, results[0].Status, results[0].WeightID)
+	}
+	if results[1].Status != EntryRejected || !errors.Is(results[1].Err, ErrIdempotencyKeyReused) {
+		t.Errorf("expected the reused key to be rejected, got %s (%v)", results[1].Status, results[1].Err)
+	}
+	if len(weightRepo.calls["Save"]) != 1 || len(publisher.events) != 1 {
+		t.Errorf("expected retries not to record again, got %d saves and %d events", len(weightRepo.calls["Save"]), len(publisher.events))
+	}
+
+	keys.data["FindError"] = errors.New("database is locked")
+	if _, err := tracker.RecordWeights(context.Background(), "giada", entries[:1]); err == nil {
+		t.Error("expected storage errors to fail the batch")
+	}
+}
+
+func TestWeightTracker_DeleteWeight(t *testing.T) {
+	userID, _ := user.NewUserID("giada")
+	otherID, _ := user.NewUserID("marta")
+	weightID, _ := weight.NewWeightID("w1")
+	value, _ := weight.NewWeightValue(70.5)
+	unit, _ := weight.NewWeightUnit("kg")
+	testWeight, _ := weight.NewWeight(weightID.String(), userID, value, unit, time.Now(), "")
+
+	tests := []struct {
+		name          string
+		userID        user.UserID
+		expectErr     bool
+		expectDeleted bool
+	}{
+		{name: "owner deletes weight", userID: userID, expectDeleted: true},
+		{name: "other user cannot delete weight", userID: otherID, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := NewMockUserRepository()
+			mockUserRepo.data["FindByIDResult"], _ = user.NewUser(tt.userID.String(), "test", "test@example.com")
+			mockWeightRepo := NewMockWeightRepository()
+			mockWeightRepo.data["FindByIDResult"] = testWeight
+
+			// Only the unit of work sees the records: the lookups must
+			// happen in the same transaction as the delete.
+			tracker := NewWeightTracker(NewMockUserRepository(), NewMockWeightRepository(), NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo}), &MockEventPublisher{})
+
+			err := tracker.DeleteWeight(context.Background(), tt.userID, weightID)
+
+			if tt.expectErr && err == nil {
+				t.Error("expected error but got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if deleted := len(mockWeightRepo.calls["Delete"]) > 0; deleted != tt.expectDeleted {
+				t.Errorf("expected deleted=%v, got %v", tt.expectDeleted, deleted)
+			}
+		})
+	}
+}
+
+func TestWeightTracker_RestoreWeight(t *testing.T) {
+	userID, _ := user.NewUserID("giada")
+	weightID, _ := weight.NewWeightID("w1")
+	value, _ := weight.NewWeightValue(70.5)
+	unit, _ := weight.NewWeightUnit("kg")
+	testWeight, _ := weight.NewWeight(weightID.String(), userID, value, unit, time.Now().AddDate(0, 0, -1), "")
+
+	tests := []struct {
+		name         string
+		setupMocks   func(*MockWeightRepository)
+		expectErr    bool
+		errorMessage string
+	}{
+		{
+			name: "successful restore",
+			setupMocks: func(wr *MockWeightRepository) {
+				wr.data["FindByIDResult"] = testWeight
+				wr.data["CountByUserIDAndDateResult"] = 3
+			},
+		},
+		{
+			name: "weight not in trash",
+			setupMocks: func(wr *MockWeightRepository) {
+				wr.data["RestoreError"] = errors.New("deleted weight not found: w1")
+			},
+			expectErr:    true,
+			errorMessage: "deleted weight not found",
+		},
+		{
+			name: "day filled up since deletion",
+			setupMocks: func(wr *MockWeightRepository) {
+				wr.data["FindByIDResult"] = testWeight
+				wr.data["CountByUserIDAndDateResult"] = 11
+			},
+			expectErr:    true,
+			errorMessage: "maximum daily weight recordings exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := NewMockUserRepository()
+			mockWeightRepo := NewMockWeightRepository()
+
+			tt.setupMocks(mockWeightRepo)
+
+			tracker := NewWeightTracker(mockUserRepo, mockWeightRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo}), &MockEventPublisher{})
+
+			err := tracker.RestoreWeight(context.Background(), userID, weightID)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				} else if !contains(err.Error(), tt.errorMessage) {
+					t.Errorf("expected error containing %q but got %q", tt.errorMessage, err.Error())
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			// The mock records the arguments of every call in one list.
+			if want := []interface{}{userID, weightID}; !slices.Equal(mockWeightRepo.calls["Restore"], want) {
+				t.Errorf("expected Restore to be called once with %v, got %v", want, mockWeightRepo.calls["Restore"])
 			}
 		})
 	}
@@ -302,9 +586,9 @@ func TestWeightTracker_GetWeightHistory(t *testing.T) {
 
 	mockWeightRepo.data["FindByUserIDAndPeriodResult"] = expectedWeights
 
-	tracker := NewWeightTracker(mockUserRepo, mockWeightRepo)
+	tracker := NewWeightTracker(mockUserRepo, mockWeightRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo}), &MockEventPublisher{})
 
-	weights, err := tracker.GetWeightHistory(userID, period)
+	weights, err := tracker.GetWeightHistory(context.Background(), userID, period)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -335,9 +619,9 @@ func TestWeightTracker_CalculateWeightTrend(t *testing.T) {
 
 	mockWeightRepo.data["FindByUserIDAndPeriodResult"] = weights
 
-	tracker := NewWeightTracker(mockUserRepo, mockWeightRepo)
+	tracker := NewWeightTracker(mockUserRepo, mockWeightRepo, NewMockUnitOfWork(interfaces.Repositories{Users: mockUserRepo, Weights: mockWeightRepo}), &MockEventPublisher{})
 
-	trend, err := tracker.CalculateWeightTrend(userID, period)
+	trend, err := tracker.CalculateWeightTrend(context.Background(), userID, period)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
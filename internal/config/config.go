@@ -1,24 +1,296 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"peso/internal/domain/session"
+)
 
 type Config struct {
+	// File is the configuration file that was read, if any.
+	File string
+
 	Port     string
 	DBPath   string
 	LogLevel string
+
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout bound
+	// the phases of an HTTP connection, so slow or stalled clients cannot
+	// hold it open. Live update streams are exempt from the read and write
+	// timeouts.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// MaxHeaderBytes caps request headers and MaxBodyBytes request bodies.
+	MaxHeaderBytes int
+	MaxBodyBytes   int
+
+	// DrainDelay keeps serving after shutdown starts, with /ready failing,
+	// so load balancers stop routing new requests first. ShutdownTimeout
+	// then bounds how long in-flight requests and background jobs get to
+	// finish, including DrainDelay.
+	DrainDelay      time.Duration
+	ShutdownTimeout time.Duration
+
+	// DBDriver is "sqlite" (default, stored at DBPath) or "postgres"
+	// (connected through DBDSN).
+	DBDriver string
+	DBDSN    string
+
+	// SessionCleanupInterval is how often expired sessions are purged.
+	// Zero only purges them at startup.
+	SessionCleanupInterval time.Duration
+
+	// SessionIdleTimeout is how long a session survives without activity
+	// and SessionMaxLifetime how long activity can keep it alive.
+	SessionIdleTimeout time.Duration
+	SessionMaxLifetime time.Duration
+
+	// MaxDailyWeightRecordings caps the weights a user may record per day.
+	MaxDailyWeightRecordings int
+
+	// TrustProxy honours X-Forwarded-For and X-Forwarded-Proto headers for
+	// client IP and HTTPS detection. Only enable it when peso is reachable
+	// exclusively through a reverse proxy that sets them.
+	TrustProxy bool
+
+	// HSTSMaxAge is advertised via Strict-Transport-Security on HTTPS
+	// requests. Zero disables the header.
+	HSTSMaxAge     time.Duration
+	FrameOptions   string
+	ReferrerPolicy string
+	// CSPReportOnly reports Content-Security-Policy violations without
+	// enforcing the policy.
+	CSPReportOnly bool
+
+	// QueryTimeout bounds the database work a single request may do.
+	// Zero disables it.
+	QueryTimeout time.Duration
+	// SlowQueryThreshold logs statements slower than this. Zero disables it.
+	SlowQueryThreshold time.Duration
+
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set. The files
+	// are re-read when they change on disk.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSMinVersion is "1.2" or "1.3".
+	TLSMinVersion string
+	// HTTPRedirectPort, when set alongside TLS, serves plain HTTP redirects
+	// to the HTTPS port.
+	HTTPRedirectPort string
+
+	// AdminToken is the bearer token for /admin endpoints. Empty disables
+	// them.
+	AdminToken string
+
+	// BackupDir receives SQLite snapshots. BackupInterval schedules them
+	// while serving; zero only allows manual backups. The BackupKeep*
+	// settings are the retention rules applied after each scheduled backup.
+	BackupDir        string
+	BackupInterval   time.Duration
+	BackupKeepLast   int
+	BackupKeepDaily  int
+	BackupKeepWeekly int
+
+	// DeletedRetention is how long deleted weights and goals stay
+	// restorable before they are purged for good. Zero keeps them forever.
+	DeletedRetention time.Duration
+
+	// IdempotencyRetention is how long idempotency keys, and the responses
+	// replayed for them, are kept. Zero keeps them forever.
+	IdempotencyRetention time.Duration
+
+	// MetricsUsername and MetricsPassword protect /metrics with HTTP basic
+	// authentication. Leaving both empty serves it unauthenticated.
+	MetricsUsername string
+	MetricsPassword string
+
+	// TrustTraceparent uses the trace ID of an incoming W3C traceparent
+	// header as the request ID when the client sent no X-Request-ID.
+	TrustTraceparent bool
+
+	// TracingEnabled exports OpenTelemetry spans over OTLP/HTTP. The
+	// exporter reads the standard OTEL_EXPORTER_OTLP_* and OTEL_TRACES_*
+	// variables.
+	TracingEnabled bool
+
+	// DefaultLocale is the interface language for visitors whose browser
+	// asks for none of the supported ones and who have not chosen one.
+	DefaultLocale string
+
+	// WebhookPollInterval is how often the webhook delivery queue is
+	// checked for due deliveries. Zero disables outgoing webhooks.
+	WebhookPollInterval time.Duration
+
+	// WebhookAllowPrivateNetworks lets webhooks reach private LAN
+	// addresses, such as a home automation server. Loopback and link-local
+	// addresses are refused regardless.
+	WebhookAllowPrivateNetworks bool
+
+	// MailerHost is the SMTP server mail is sent through on MailerPort,
+	// authenticating with MailerUsername and MailerPassword when they are
+	// set, from the MailerFrom address. An empty host disables mail.
+	MailerHost     string
+	MailerPort     int
+	MailerUsername string
+	MailerPassword string
+	MailerFrom     string
+}
+
+// DBSource is the path or connection string passed to the database driver.
+func (c *Config) DBSource() string {
+	if c.DBDriver == "postgres" {
+		return c.DBDSN
+	}
+	return c.DBPath
 }
 
-func Load() *Config {
+// TLSEnabled reports whether peso should terminate TLS itself.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() *Config {
 	return &Config{
-		Port:     getEnv("PORT", "8080"),
-		DBPath:   getEnv("DB_PATH", "./peso.db"),
-		LogLevel: getEnv("LOG_LEVEL", "info"),
+		Port:                     "8080",
+		DBPath:                   "./peso.db",
+		LogLevel:                 "info",
+		ReadHeaderTimeout:        5 * time.Second,
+		ReadTimeout:              30 * time.Second,
+		WriteTimeout:             30 * time.Second,
+		IdleTimeout:              2 * time.Minute,
+		MaxHeaderBytes:           64 << 10,
+		MaxBodyBytes:             1 << 20,
+		ShutdownTimeout:          15 * time.Second,
+		DBDriver:                 "sqlite",
+		SessionCleanupInterval:   time.Hour,
+		SessionIdleTimeout:       session.IdleTimeout,
+		SessionMaxLifetime:       session.MaxLifetime,
+		MaxDailyWeightRecordings: 10,
+		HSTSMaxAge:               365 * 24 * time.Hour,
+		FrameOptions:             "DENY",
+		ReferrerPolicy:           "strict-origin-when-cross-origin",
+		QueryTimeout:             5 * time.Second,
+		SlowQueryThreshold:       200 * time.Millisecond,
+		TLSMinVersion:            "1.2",
+		BackupDir:                "./backups",
+		BackupKeepLast:           7,
+		BackupKeepDaily:          7,
+		BackupKeepWeekly:         4,
+		DeletedRetention:         30 * 24 * time.Hour,
+		IdempotencyRetention:     7 * 24 * time.Hour,
+		WebhookPollInterval:      5 * time.Second,
+		MailerPort:               587,
+		DefaultLocale:            "it",
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the YAML file named by --config or PESO_CONFIG, the
+// environment and the command-line flags at the start of args. Secrets can
+// also be read from the file named by their *_FILE variable. It returns the
+// arguments following the flags, and every invalid setting at once.
+func Load(args []string) (*Config, []string, error) {
+	c := Default()
+	settings := c.settings()
+
+	flags := flag.NewFlagSet("peso", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: peso [flags] [command]\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	file := flags.String("config", os.Getenv("PESO_CONFIG"), "YAML configuration file (env PESO_CONFIG)")
+	// Flags win over the file and the environment, so they are only
+	// applied once those have been read.
+	var assigned []*flagAssignment
+	for _, s := range settings {
+		flags.Var(&flagAssignment{setting: s, assigned: &assigned}, s.flagName(), s.usage+" (env "+s.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *file != "" {
+		if err := c.loadFile(*file, settings); err != nil {
+			return nil, nil, err
+		}
+		c.File = *file
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if err := s.loadEnv(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, a := range assigned {
+		if err := a.setting.value.Set(a.raw); err != nil {
+			errs = append(errs, fmt.Errorf("--%s: %w", a.setting.flagName(), err))
+		}
 	}
+	if err := c.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return c, flags.Args(), nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// loadEnv applies the setting's environment variable, or for secrets the
+// contents of the file named by its *_FILE variant. Empty variables are
+// ignored.
+func (s *setting) loadEnv() error {
+	value := os.Getenv(s.env)
+	if s.secret {
+		if path := os.Getenv(s.env + "_FILE"); path != "" {
+			if value != "" {
+				return fmt.Errorf("%s and %s_FILE are both set", s.env, s.env)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s_FILE: %w", s.env, err)
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+	}
+	if value == "" {
+		return nil
+	}
+	if err := s.value.Set(value); err != nil {
+		return fmt.Errorf("%s: %w", s.env, err)
 	}
-	return defaultValue
+	return nil
+}
+
+// flagAssignment records a flag until the file and environment have been
+// applied.
+type flagAssignment struct {
+	setting  *setting
+	raw      string
+	assigned *[]*flagAssignment
+}
+
+func (a *flagAssignment) Set(raw string) error {
+	*a.assigned = append(*a.assigned, &flagAssignment{setting: a.setting, raw: raw})
+	return nil
+}
+
+func (a *flagAssignment) String() string {
+	if a.setting == nil {
+		return ""
+	}
+	return a.setting.value.String()
+}
+
+func (a *flagAssignment) IsBoolFlag() bool {
+	_, ok := a.setting.value.(*boolValue)
+	return ok
 }
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := writeFile(t, "peso.yaml", `
+server:
+  port: 9000
+database:
+  path: /data/peso.db
+  query_timeout: 2s
+limits:
+  max_daily_weight_recordings: 3
+`)
+	t.Setenv("PESO_CONFIG", file)
+	t.Setenv("DB_PATH", "/env/peso.db")
+	t.Setenv("QUERY_TIMEOUT", "3s")
+
+	cfg, args, err := Load([]string{"--query-timeout", "4s", "--tracing-enabled", "migrate", "up"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.File != file {
+		t.Errorf("expected file %s, got %s", file, cfg.File)
+	}
+	if cfg.Port != "9000" {
+		t.Errorf("expected port from file, got %s", cfg.Port)
+	}
+	if cfg.DBPath != "/env/peso.db" {
+		t.Errorf("expected DB path from env, got %s", cfg.DBPath)
+	}
+	if cfg.QueryTimeout != 4*time.Second {
+		t.Errorf("expected query timeout from flag, got %v", cfg.QueryTimeout)
+	}
+	if !cfg.TracingEnabled {
+		t.Error("expected tracing enabled by bare boolean flag")
+	}
+	if cfg.MaxDailyWeightRecordings != 3 {
+		t.Errorf("expected 3 daily recordings, got %d", cfg.MaxDailyWeightRecordings)
+	}
+	if cfg.LogLevel != "info" {
+		t.Errorf("expected default log level, got %s", cfg.LogLevel)
+	}
+	if strings.Join(args, " ") != "migrate up" {
+		t.Errorf("expected remaining args 'migrate up', got %v", args)
+	}
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	secret := writeFile(t, "admin_token", "s3cret\n")
+
+	t.Run("read from file", func(t *testing.T) {
+		t.Setenv("ADMIN_TOKEN_FILE", secret)
+		cfg, _, err := Load(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.AdminToken != "s3cret" {
+			t.Errorf("expected token without trailing newline, got %q", cfg.AdminToken)
+		}
+	})
+
+	t.Run("both set", func(t *testing.T) {
+		t.Setenv("ADMIN_TOKEN_FILE", secret)
+		t.Setenv("ADMIN_TOKEN", "other")
+		if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "ADMIN_TOKEN and ADMIN_TOKEN_FILE are both set") {
+			t.Errorf("expected conflict error, got %v", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("DB_DSN_FILE", filepath.Join(t.TempDir(), "missing"))
+		if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "DB_DSN_FILE") {
+			t.Errorf("expected DB_DSN_FILE error, got %v", err)
+		}
+	})
+}
+
+func TestLoad_FileErrors(t *testing.T) {
+	file := writeFile(t, "peso.yaml", `
+server:
+  prot: 9000
+sessions:
+  idle_timeout: 30d
+tls: [1.3]
+`)
+
+	_, _, err := Load([]string{"--config", file})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{
+		`peso.yaml:3: unknown setting "server.prot"`,
+		`peso.yaml:5: sessions.idle_timeout: "30d" is not a duration`,
+		`peso.yaml:6: unknown setting "tls"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+}
+
+func TestLoad_EnvAndFlagErrors(t *testing.T) {
+	t.Setenv("BACKUP_KEEP_LAST", "-1")
+
+	_, _, err := Load([]string{"--log-level", "verbose", "--port", "http"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{
+		"BACKUP_KEEP_LAST: must not be negative",
+		`LOG_LEVEL: "verbose" is not one of`,
+		`PORT: "http" is not a port number`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{name: "defaults", modify: func(*Config) {}},
+		{name: "postgres without DSN", modify: func(c *Config) { c.DBDriver = "postgres" }, want: "DB_DSN: must be set for postgres"},
+		{name: "unknown driver", modify: func(c *Config) { c.DBDriver = "mysql" }, want: "DB_DRIVER"},
+		{name: "certificate without key", modify: func(c *Config) { c.TLSCertFile = "cert.pem" }, want: "TLS_CERT_FILE: must be set together with TLS_KEY_FILE"},
+		{name: "redirect without TLS", modify: func(c *Config) { c.HTTPRedirectPort = "80" }, want: "HTTP_REDIRECT_PORT: requires"},
+		{name: "unsupported TLS version", modify: func(c *Config) { c.TLSMinVersion = "1.1" }, want: "TLS_MIN_VERSION"},
+		{name: "empty frame options disables the header", modify: func(c *Config) { c.FrameOptions = "" }},
+		{name: "invalid frame options", modify: func(c *Config) { c.FrameOptions = "ALLOW-FROM x" }, want: "FRAME_OPTIONS"},
+		{name: "invalid referrer policy", modify: func(c *Config) { c.ReferrerPolicy = "never" }, want: "REFERRER_POLICY"},
+		{name: "idle longer than lifetime", modify: func(c *Config) { c.SessionIdleTimeout = 2 * c.SessionMaxLifetime }, want: "SESSION_IDLE_TIMEOUT: must not exceed"},
+		{name: "lifetime beyond cookie", modify: func(c *Config) { c.SessionMaxLifetime = 2 * 365 * 24 * time.Hour }, want: "SESSION_MAX_LIFETIME"},
+		{name: "no daily recordings", modify: func(c *Config) { c.MaxDailyWeightRecordings = 0 }, want: "MAX_DAILY_WEIGHT_RECORDINGS"},
+		{name: "metrics user without password", modify: func(c *Config) { c.MetricsUsername = "prometheus" }, want: "METRICS_USERNAME"},
+		{name: "drain delay beyond shutdown timeout", modify: func(c *Config) { c.DrainDelay = c.ShutdownTimeout }, want: "DRAIN_DELAY: must be shorter than SHUTDOWN_TIMEOUT"},
+		{name: "query timeout beyond write timeout", modify: func(c *Config) { c.WriteTimeout = c.QueryTimeout }, want: "QUERY_TIMEOUT: must be shorter than WRITE_TIMEOUT"},
+		{name: "write timeout disabled", modify: func(c *Config) { c.WriteTimeout = 0 }},
+		{name: "tiny header limit", modify: func(c *Config) { c.MaxHeaderBytes = 100 }, want: "MAX_HEADER_BYTES"},
+		{name: "unsupported locale", modify: func(c *Config) { c.DefaultLocale = "fr" }, want: "DEFAULT_LOCALE"},
+		{name: "mailer", modify: func(c *Config) {
+			c.MailerHost, c.MailerUsername, c.MailerPassword, c.MailerFrom = "smtp.example.com", "peso", "s3cret", "Peso <peso@example.com>"
+		}},
+		{name: "mailer without host", modify: func(c *Config) { c.MailerFrom = "peso@example.com" }, want: "MAILER_HOST: must be set"},
+		{name: "mailer without sender", modify: func(c *Config) { c.MailerHost = "smtp.example.com" }, want: "MAILER_FROM"},
+		{name: "mailer port out of range", modify: func(c *Config) {
+			c.MailerHost, c.MailerFrom, c.MailerPort = "smtp.example.com", "peso@example.com", 0
+		}, want: "MAILER_PORT"},
+		{name: "mailer user without password", modify: func(c *Config) {
+			c.MailerHost, c.MailerFrom, c.MailerUsername = "smtp.example.com", "peso@example.com", "peso"
+		}, want: "MAILER_USERNAME: must be set together with MAILER_PASSWORD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.modify(c)
+			err := c.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	t.Setenv("METRICS_USERNAME", "prometheus")
+	t.Setenv("METRICS_PASSWORD", "s3cret")
+	t.Setenv("REFERRER_POLICY", "no-referrer")
+	t.Setenv("SESSION_IDLE_TIMEOUT", "36h")
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "s3cret") {
+		t.Errorf("expected password to be redacted in:\n%s", out)
+	}
+	for _, want := range []string{
+		"metrics:\n  username: prometheus # METRICS_USERNAME\n  password: '[redacted]' # METRICS_PASSWORD\n",
+		"  port: \"8080\" # PORT\n",
+		"  idle_timeout: 36h0m0s # SESSION_IDLE_TIMEOUT\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	// The printed file loads back into the same configuration, apart from
+	// the redacted secret.
+	for _, key := range []string{"METRICS_USERNAME", "METRICS_PASSWORD", "REFERRER_POLICY", "SESSION_IDLE_TIMEOUT"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("METRICS_PASSWORD", "s3cret")
+	reloaded, _, err := Load([]string{"--config", writeFile(t, "peso.yaml", out)})
+	if err != nil {
+		t.Fatalf("failed to load printed configuration: %v", err)
+	}
+	reloaded.File = cfg.File
+	if *reloaded != *cfg {
+		t.Errorf("expected %+v, got %+v", cfg, reloaded)
+	}
+}
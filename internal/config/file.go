@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the value of secrets in printed configuration.
+const redacted = "[redacted]"
+
+// loadFile applies a YAML file of sections holding settings, as written by
+// Print. Unknown keys are rejected so typos do not go unnoticed.
+func (c *Config) loadFile(path string, settings []*setting) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	byKey := make(map[string]*setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+
+	var errs []error
+	var walk func(prefix string, node *yaml.Node)
+	walk = func(prefix string, node *yaml.Node) {
+		switch {
+		case node.Kind == yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				if prefix != "" {
+					key = prefix + "." + key
+				}
+				walk(key, node.Content[i+1])
+			}
+		case prefix == "":
+			errs = append(errs, fmt.Errorf("%s:%d: expected sections of settings", path, node.Line))
+		case byKey[prefix] == nil:
+			errs = append(errs, fmt.Errorf("%s:%d: unknown setting %q", path, node.Line, prefix))
+		case node.Kind != yaml.ScalarNode:
+			errs = append(errs, fmt.Errorf("%s:%d: %s: expected a single value", path, node.Line, prefix))
+		case node.Tag == "!!null":
+			// An empty key keeps the default.
+		default:
+			if err := byKey[prefix].value.Set(node.Value); err != nil {
+				errs = append(errs, fmt.Errorf("%s:%d: %s: %w", path, node.Line, prefix, err))
+			}
+		}
+	}
+	walk("", doc.Content[0])
+	return errors.Join(errs...)
+}
+
+// Print writes the configuration as a YAML file that Load accepts, each
+// setting annotated with its environment variable. Secrets that are set
+// are redacted.
+func (c *Config) Print(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	section := ""
+	for _, s := range c.settings() {
+		name, key, _ := strings.Cut(s.key, ".")
+		if name != section {
+			if section != "" {
+				bw.WriteByte('\n')
+			}
+			fmt.Fprintf(bw, "%s:\n", name)
+			section = name
+		}
+
+		value := s.value.String()
+		if s.secret && value != "" {
+			value = redacted
+		}
+		if _, ok := s.value.(*stringValue); ok {
+			value = quote(value)
+		}
+		fmt.Fprintf(bw, "  %s: %s # %s\n", key, value, s.env)
+	}
+	return bw.Flush()
+}
+
+// quote renders a string as a YAML scalar that reads back unchanged.
+func quote(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
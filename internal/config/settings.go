@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting binds one Config field to its key in the configuration file, its
+// environment variable and its command-line flag.
+type setting struct {
+	key    string // section.name in the configuration file
+	env    string
+	usage  string
+	secret bool
+	value  value
+}
+
+// value is a flag.Value that parses settings from every source.
+type value interface {
+	String() string
+	Set(string) error
+}
+
+// flagName derives the flag from the environment variable, so DB_PATH is
+// set with --db-path.
+func (s *setting) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(s.env, "_", "-"))
+}
+
+// settings lists every configurable field of c in the order they are
+// printed.
+func (c *Config) settings() []*setting {
+	return []*setting{
+		{key: "server.port", env: "PORT", usage: "port to listen on", value: (*stringValue)(&c.Port)},
+		{key: "server.read_header_timeout", env: "READ_HEADER_TIMEOUT", usage: "time allowed to send request headers", value: (*durationValue)(&c.ReadHeaderTimeout)},
+		{key: "server.read_timeout", env: "READ_TIMEOUT", usage: "time allowed to send a whole request, 0 disables it", value: (*durationValue)(&c.ReadTimeout)},
+		{key: "server.write_timeout", env: "WRITE_TIMEOUT", usage: "time allowed to answer a request, 0 disables it", value: (*durationValue)(&c.WriteTimeout)},
+		{key: "server.idle_timeout", env: "IDLE_TIMEOUT", usage: "how long idle keep-alive connections stay open", value: (*durationValue)(&c.IdleTimeout)},
+		{key: "server.max_header_bytes", env: "MAX_HEADER_BYTES", usage: "largest accepted request headers", value: (*intValue)(&c.MaxHeaderBytes)},
+		{key: "server.max_body_bytes", env: "MAX_BODY_BYTES", usage: "largest accepted request body, 0 disables the limit", value: (*intValue)(&c.MaxBodyBytes)},
+		{key: "server.drain_delay", env: "DRAIN_DELAY", usage: "keep serving this long after shutdown starts, with /ready failing", value: (*durationValue)(&c.DrainDelay)},
+		{key: "server.shutdown_timeout", env: "SHUTDOWN_TIMEOUT", usage: "time allowed for requests and jobs to finish on shutdown", value: (*durationValue)(&c.ShutdownTimeout)},
+		{key: "server.trust_proxy", env: "TRUST_PROXY", usage: "trust X-Forwarded-For and X-Forwarded-Proto", value: (*boolValue)(&c.TrustProxy)},
+		{key: "server.trust_traceparent", env: "TRUST_TRACEPARENT", usage: "use the traceparent trace ID as request ID", value: (*boolValue)(&c.TrustTraceparent)},
+
+		{key: "tls.cert_file", env: "TLS_CERT_FILE", usage: "serve HTTPS with this certificate", value: (*stringValue)(&c.TLSCertFile)},
+		{key: "tls.key_file", env: "TLS_KEY_FILE", usage: "private key of the TLS certificate", value: (*stringValue)(&c.TLSKeyFile)},
+		{key: "tls.min_version", env: "TLS_MIN_VERSION", usage: "minimum TLS version, 1.2 or 1.3", value: (*stringValue)(&c.TLSMinVersion)},
+		{key: "tls.http_redirect_port", env: "HTTP_REDIRECT_PORT", usage: "also redirect plain HTTP on this port to HTTPS", value: (*stringValue)(&c.HTTPRedirectPort)},
+
+		{key: "security.hsts_max_age", env: "HSTS_MAX_AGE", usage: "Strict-Transport-Security max-age, 0 disables it", value: (*durationValue)(&c.HSTSMaxAge)},
+		{key: "security.frame_options", env: "FRAME_OPTIONS", usage: "X-Frame-Options, DENY or SAMEORIGIN", value: (*stringValue)(&c.FrameOptions)},
+		{key: "security.referrer_policy", env: "REFERRER_POLICY", usage: "Referrer-Policy", value: (*stringValue)(&c.ReferrerPolicy)},
+		{key: "security.csp_report_only", env: "CSP_REPORT_ONLY", usage: "report Content-Security-Policy violations without enforcing it", value: (*boolValue)(&c.CSPReportOnly)},
+
+		{key: "database.driver", env: "DB_DRIVER", usage: "sqlite or postgres", value: (*stringValue)(&c.DBDriver)},
+		{key: "database.path", env: "DB_PATH", usage: "SQLite database file", value: (*stringValue)(&c.DBPath)},
+		{key: "database.dsn", env: "DB_DSN", usage: "PostgreSQL connection string", secret: true, value: (*stringValue)(&c.DBDSN)},
+		{key: "database.query_timeout", env: "QUERY_TIMEOUT", usage: "deadline for the database work of a request, 0 disables it", value: (*durationValue)(&c.QueryTimeout)},
+		{key: "database.slow_query_threshold", env: "SLOW_QUERY_THRESHOLD", usage: "log statements slower than this, 0 disables it", value: (*durationValue)(&c.SlowQueryThreshold)},
+
+		{key: "sessions.idle_timeout", env: "SESSION_IDLE_TIMEOUT", usage: "sign out after this long without activity", value: (*durationValue)(&c.SessionIdleTimeout)},
+		{key: "sessions.max_lifetime", env: "SESSION_MAX_LIFETIME", usage: "sign out this long after signing in, at most 8760h", value: (*durationValue)(&c.SessionMaxLifetime)},
+		{key: "sessions.cleanup_interval", env: "SESSION_CLEANUP_INTERVAL", usage: "how often expired sessions are purged", value: (*durationValue)(&c.SessionCleanupInterval)},
+
+		{key: "limits.max_daily_weight_recordings", env: "MAX_DAILY_WEIGHT_RECORDINGS", usage: "weights a user may record per day", value: (*intValue)(&c.MaxDailyWeightRecordings)},
+
+		{key: "retention.deleted", env: "DELETED_RETENTION", usage: "how long deleted weights and goals can be restored, 0 forever", value: (*durationValue)(&c.DeletedRetention)},
+		{key: "retention.idempotency", env: "IDEMPOTENCY_RETENTION", usage: "how long idempotency keys are kept, 0 forever", value: (*durationValue)(&c.IdempotencyRetention)},
+
+		{key: "backup.dir", env: "BACKUP_DIR", usage: "where SQLite snapshots are written", value: (*stringValue)(&c.BackupDir)},
+		{key: "backup.interval", env: "BACKUP_INTERVAL", usage: "take a snapshot this often, 0 disables it", value: (*durationValue)(&c.BackupInterval)},
+		{key: "backup.keep_last", env: "BACKUP_KEEP_LAST", usage: "keep the newest N snapshots", value: (*intValue)(&c.BackupKeepLast)},
+		{key: "backup.keep_daily", env: "BACKUP_KEEP_DAILY", usage: "keep the newest snapshot of each of the last N days", value: (*intValue)(&c.BackupKeepDaily)},
+		{key: "backup.keep_weekly", env: "BACKUP_KEEP_WEEKLY", usage: "keep the newest snapshot of each of the last N weeks", value: (*intValue)(&c.BackupKeepWeekly)},
+
+		{key: "webhooks.poll_interval", env: "WEBHOOK_POLL_INTERVAL", usage: "how often queued webhooks are sent, 0 disables them", value: (*durationValue)(&c.WebhookPollInterval)},
+		{key: "webhooks.allow_private_networks", env: "WEBHOOK_ALLOW_PRIVATE_NETWORKS", usage: "let webhooks reach private LAN addresses", value: (*boolValue)(&c.WebhookAllowPrivateNetworks)},
+
+		{key: "mailer.host", env: "MAILER_HOST", usage: "SMTP server mail is sent through, empty disables mail", value: (*stringValue)(&c.MailerHost)},
+		{key: "mailer.port", env: "MAILER_PORT", usage: "port of the SMTP server", value: (*intValue)(&c.MailerPort)},
+		{key: "mailer.username", env: "MAILER_USERNAME", usage: "user to sign in to the SMTP server as", value: (*stringValue)(&c.MailerUsername)},
+		{key: "mailer.password", env: "MAILER_PASSWORD", usage: "password for the SMTP server", secret: true, value: (*stringValue)(&c.MailerPassword)},
+		{key: "mailer.from", env: "MAILER_FROM", usage: "sender address of mail", value: (*stringValue)(&c.MailerFrom)},
+
+		{key: "admin.token", env: "ADMIN_TOKEN", usage: "bearer token for /admin, empty disables it", secret: true, value: (*stringValue)(&c.AdminToken)},
+
+		{key: "metrics.username", env: "METRICS_USERNAME", usage: "basic auth user for /metrics", value: (*stringValue)(&c.MetricsUsername)},
+		{key: "metrics.password", env: "METRICS_PASSWORD", usage: "basic auth password for /metrics", secret: true, value: (*stringValue)(&c.MetricsPassword)},
+
+		{key: "tracing.enabled", env: "TRACING_ENABLED", usage: "export OpenTelemetry traces over OTLP/HTTP", value: (*boolValue)(&c.TracingEnabled)},
+
+		{key: "log.level", env: "LOG_LEVEL", usage: "debug, info, warn or error", value: (*stringValue)(&c.LogLevel)},
+
+		{key: "i18n.default_locale", env: "DEFAULT_LOCALE", usage: "interface language when the browser asks for none supported", value: (*stringValue)(&c.DefaultLocale)},
+	}
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", s)
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+// intValue accepts non-negative integers.
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a whole number", s)
+	}
+	if n < 0 {
+		return errors.New("must not be negative")
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+// durationValue accepts non-negative Go durations such as 90s or 720h.
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration such as 30s or 24h", s)
+	}
+	if d < 0 {
+		return errors.New("must not be negative")
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string {
+	return time.Duration(*v).String()
+}
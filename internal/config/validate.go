@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+
+	"peso/internal/domain/session"
+	"peso/internal/infrastructure/i18n"
+)
+
+var (
+	logLevels        = []string{"debug", "info", "warn", "warning", "error"}
+	frameOptions     = []string{"DENY", "SAMEORIGIN"}
+	tlsVersions      = []string{"1.2", "1.3"}
+	referrerPolicies = []string{
+		"no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin",
+		"same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url",
+	}
+)
+
+// Validate reports every setting that is out of range or inconsistent with
+// another, named by its environment variable.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(env, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", env, fmt.Sprintf(format, args...)))
+	}
+
+	if !validPort(c.Port) {
+		fail("PORT", "%q is not a port number", c.Port)
+	}
+	if c.ReadHeaderTimeout <= 0 {
+		fail("READ_HEADER_TIMEOUT", "must be positive")
+	}
+	if c.IdleTimeout <= 0 {
+		fail("IDLE_TIMEOUT", "must be positive")
+	}
+	if c.WriteTimeout > 0 && c.QueryTimeout >= c.WriteTimeout {
+		fail("QUERY_TIMEOUT", "must be shorter than WRITE_TIMEOUT, or errors cannot be answered in time")
+	}
+	if c.MaxHeaderBytes < 4<<10 {
+		fail("MAX_HEADER_BYTES", "must be at least 4096")
+	}
+	if c.ShutdownTimeout <= 0 {
+		fail("SHUTDOWN_TIMEOUT", "must be positive")
+	} else if c.DrainDelay >= c.ShutdownTimeout {
+		fail("DRAIN_DELAY", "must be shorter than SHUTDOWN_TIMEOUT")
+	}
+	if !slices.Contains(logLevels, strings.ToLower(c.LogLevel)) {
+		fail("LOG_LEVEL", "%q is not one of %s", c.LogLevel, strings.Join(logLevels, ", "))
+	}
+	if _, ok := i18n.Parse(c.DefaultLocale); !ok {
+		fail("DEFAULT_LOCALE", "unsupported locale %q", c.DefaultLocale)
+	}
+
+	switch c.DBDriver {
+	case "sqlite":
+		if c.DBPath == "" {
+			fail("DB_PATH", "must be set for sqlite")
+		}
+	case "postgres":
+		if c.DBDSN == "" {
+			fail("DB_DSN", "must be set for postgres")
+		}
+	default:
+		fail("DB_DRIVER", "%q is not sqlite or postgres", c.DBDriver)
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		fail("TLS_CERT_FILE", "must be set together with TLS_KEY_FILE")
+	}
+	if !slices.Contains(tlsVersions, c.TLSMinVersion) {
+		fail("TLS_MIN_VERSION", "%q is not 1.2 or 1.3", c.TLSMinVersion)
+	}
+	if c.HTTPRedirectPort != "" {
+		switch {
+		case !c.TLSEnabled():
+			fail("HTTP_REDIRECT_PORT", "requires TLS_CERT_FILE and TLS_KEY_FILE")
+		case !validPort(c.HTTPRedirectPort):
+			fail("HTTP_REDIRECT_PORT", "%q is not a port number", c.HTTPRedirectPort)
+		case c.HTTPRedirectPort == c.Port:
+			fail("HTTP_REDIRECT_PORT", "must differ from PORT")
+		}
+	}
+
+	if c.FrameOptions != "" && !slices.Contains(frameOptions, strings.ToUpper(c.FrameOptions)) {
+		fail("FRAME_OPTIONS", "%q is not DENY or SAMEORIGIN", c.FrameOptions)
+	}
+	if c.ReferrerPolicy != "" && !slices.Contains(referrerPolicies, c.ReferrerPolicy) {
+		fail("REFERRER_POLICY", "%q is not a referrer policy", c.ReferrerPolicy)
+	}
+
+	if c.SessionIdleTimeout <= 0 {
+		fail("SESSION_IDLE_TIMEOUT", "must be positive")
+	}
+	if c.SessionMaxLifetime <= 0 || c.SessionMaxLifetime > session.MaxLifetime {
+		fail("SESSION_MAX_LIFETIME", "must be positive and at most %s", session.MaxLifetime)
+	}
+	if c.SessionIdleTimeout > c.SessionMaxLifetime {
+		fail("SESSION_IDLE_TIMEOUT", "must not exceed SESSION_MAX_LIFETIME")
+	}
+	if c.MaxDailyWeightRecordings < 1 {
+		fail("MAX_DAILY_WEIGHT_RECORDINGS", "must be at least 1")
+	}
+
+	if (c.MetricsUsername == "") != (c.MetricsPassword == "") {
+		fail("METRICS_USERNAME", "must be set together with METRICS_PASSWORD")
+	}
+
+	if c.MailerHost == "" {
+		if c.MailerUsername != "" || c.MailerPassword != "" || c.MailerFrom != "" {
+			fail("MAILER_HOST", "must be set to send mail")
+		}
+	} else {
+		if !validPort(strconv.Itoa(c.MailerPort)) {
+			fail("MAILER_PORT", "%d is not a port number", c.MailerPort)
+		}
+		if _, err := mail.ParseAddress(c.MailerFrom); err != nil {
+			fail("MAILER_FROM", "%q is not an email address", c.MailerFrom)
+		}
+	}
+	if (c.MailerUsername == "") != (c.MailerPassword == "") {
+		fail("MAILER_USERNAME", "must be set together with MAILER_PASSWORD")
+	}
+
+	return errors.Join(errs...)
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
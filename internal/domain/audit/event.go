@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"peso/internal/domain/user"
+)
+
+// Action names a change recorded in the audit log as "<target>.<verb>".
+type Action string
+
+const (
+	ActionWeightRecorded Action = "weight.recorded"
+	ActionWeightDeleted  Action = "weight.deleted"
+	ActionWeightRestored Action = "weight.restored"
+
+	ActionGoalSet         Action = "goal.set"
+	ActionGoalDeactivated Action = "goal.deactivated"
+	ActionGoalDeleted     Action = "goal.deleted"
+	ActionGoalRestored    Action = "goal.restored"
+
+	ActionUserRegistered  Action = "user.registered"
+	ActionPasswordChanged Action = "user.password_changed"
+	ActionUserDeactivated Action = "user.deactivated"
+	ActionLoginSucceeded  Action = "user.login_succeeded"
+	ActionLoginFailed     Action = "user.login_failed"
+
+	ActionWebhookCreated Action = "webhook.created"
+	ActionWebhookDeleted Action = "webhook.deleted"
+)
+
+var (
+	ErrEmptyUserID   = errors.New("audit event must belong to a user")
+	ErrInvalidAction = errors.New("invalid audit action")
+	ErrEmptyTargetID = errors.New("audit event must have a target")
+)
+
+// TargetType is the kind of record the action applies to.
+func (a Action) TargetType() string {
+	target, _, _ := strings.Cut(string(a), ".")
+	return target
+}
+
+func (a Action) valid() bool {
+	target, verb, ok := strings.Cut(string(a), ".")
+	return ok && target != "" && verb != ""
+}
+
+// Event is an immutable entry in the audit log. UserID is the user whose
+// data changed and ActorID the user who changed it; the actor is empty for
+// changes made outside a signed-in request. Before and After are JSON
+// snapshots of the target, either of which may be empty.
+type Event struct {
+	id         int64
+	userID     user.UserID
+	actorID    user.UserID
+	action     Action
+	targetID   string
+	before     json.RawMessage
+	after      json.RawMessage
+	requestID  string
+	occurredAt time.Time
+}
+
+// NewEvent creates an event that has not been stored yet. before and after
+// are marshalled to JSON; nil leaves the snapshot empty.
+func NewEvent(userID, actorID user.UserID, action Action, targetID string, before, after any, requestID string) (*Event, error) {
+	if userID == "" {
+		return nil, ErrEmptyUserID
+	}
+	if !action.valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAction, action)
+	}
+	if targetID == "" {
+		return nil, ErrEmptyTargetID
+	}
+
+	beforeJSON, err := marshalSnapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	afterJSON, err := marshalSnapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		userID:     userID,
+		actorID:    actorID,
+		action:     action,
+		targetID:   targetID,
+		before:     beforeJSON,
+		after:      afterJSON,
+		requestID:  requestID,
+		occurredAt: time.Now(),
+	}, nil
+}
+
+// ReconstructEvent rebuilds a stored event
+func ReconstructEvent(id int64, userID, actorID user.UserID, action Action, targetID string, before, after json.RawMessage, requestID string, occurredAt time.Time) *Event {
+	return &Event{
+		id:         id,
+		userID:     userID,
+		actorID:    actorID,
+		action:     action,
+		targetID:   targetID,
+		before:     before,
+		after:      after,
+		requestID:  requestID,
+		occurredAt: occurredAt,
+	}
+}
+
+func marshalSnapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	return b, nil
+}
+
+// ID is assigned by the store and increases with every event; it is zero
+// before the event is appended.
+func (e *Event) ID() int64 {
+	return e.id
+}
+
+func (e *Event) UserID() user.UserID {
+	return e.userID
+}
+
+func (e *Event) ActorID() user.UserID {
+	return e.actorID
+}
+
+func (e *Event) Action() Action {
+	return e.action
+}
+
+func (e *Event) TargetType() string {
+	return e.action.TargetType()
+}
+
+func (e *Event) TargetID() string {
+	return e.targetID
+}
+
+func (e *Event) Before() json.RawMessage {
+	return e.before
+}
+
+func (e *Event) After() json.RawMessage {
+	return e.after
+}
+
+func (e *Event) RequestID() string {
+	return e.requestID
+}
+
+func (e *Event) OccurredAt() time.Time {
+	return e.occurredAt
+}
+
+// Metadata describes the request a change is made in.
+type Metadata struct {
+	ActorID   user.UserID
+	RequestID string
+}
+
+type metadataKey struct{}
+
+// WithMetadata attaches request metadata for the audit events recorded
+// while handling ctx.
+func WithMetadata(ctx context.Context, m Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, m)
+}
+
+// MetadataFromContext returns the metadata attached by WithMetadata, or the
+// zero value.
+func MetadataFromContext(ctx context.Context) Metadata {
+	m, _ := ctx.Value(metadataKey{}).(Metadata)
+	return m
+}
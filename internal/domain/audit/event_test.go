@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"peso/internal/domain/user"
+)
+
+func TestNewEvent(t *testing.T) {
+	userID, _ := user.NewUserID("giada")
+
+	tests := []struct {
+		name      string
+		userID    user.UserID
+		action    Action
+		targetID  string
+		expectErr error
+	}{
+		{name: "valid event", userID: userID, action: ActionWeightRecorded, targetID: "w1"},
+		{name: "missing user", userID: "", action: ActionWeightRecorded, targetID: "w1", expectErr: ErrEmptyUserID},
+		{name: "action without target type", userID: userID, action: "recorded", targetID: "w1", expectErr: ErrInvalidAction},
+		{name: "missing target", userID: userID, action: ActionGoalSet, targetID: "", expectErr: ErrEmptyTargetID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEvent(tt.userID, tt.userID, tt.action, tt.targetID, nil, map[string]any{"value": 70.5}, "req-1")
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected error %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if e.Before() != nil {
+				t.Errorf("expected empty before snapshot, got %s", e.Before())
+			}
+			if string(e.After()) != `{"value":70.5}` {
+				t.Errorf("expected after snapshot {\"value\":70.5}, got %s", e.After())
+			}
+			if e.TargetType() != "weight" {
+				t.Errorf("expected target type weight, got %s", e.TargetType())
+			}
+		})
+	}
+}
+
+func TestMetadataFromContext(t *testing.T) {
+	if m := MetadataFromContext(context.Background()); m != (Metadata{}) {
+		t.Errorf("expected zero metadata, got %+v", m)
+	}
+
+	want := Metadata{ActorID: "giada", RequestID: "req-1"}
+	if m := MetadataFromContext(WithMetadata(context.Background(), want)); m != want {
+		t.Errorf("expected %+v, got %+v", want, m)
+	}
+}
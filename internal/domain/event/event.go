@@ -0,0 +1,122 @@
+package event
+
+import (
+	"time"
+
+	"peso/internal/domain/user"
+)
+
+// Event names, also used as the type in serialized payloads.
+const (
+	NameWeightRecorded = "weight.recorded"
+	NameWeightDeleted  = "weight.deleted"
+	NameWeightRestored = "weight.restored"
+	NameGoalSet        = "goal.set"
+	NameGoalAchieved   = "goal.achieved"
+	NameGoalDeleted    = "goal.deleted"
+	NameGoalRestored   = "goal.restored"
+	NameUserRegistered = "user.registered"
+)
+
+// Event is something that happened in the domain. Events are published
+// after the change that raised them has been committed.
+type Event interface {
+	Name() string
+	Subject() user.UserID
+	Time() time.Time
+}
+
+// Base carries the fields shared by every event.
+type Base struct {
+	UserID     user.UserID `json:"user_id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+// NewBase stamps an event for userID with the current time.
+func NewBase(userID user.UserID) Base {
+	return Base{UserID: userID, OccurredAt: time.Now()}
+}
+
+// Subject is the user whose data the event is about.
+func (b Base) Subject() user.UserID {
+	return b.UserID
+}
+
+func (b Base) Time() time.Time {
+	return b.OccurredAt
+}
+
+// WeightRecorded is raised when a new measurement is saved.
+type WeightRecorded struct {
+	Base
+	WeightID   string    `json:"weight_id"`
+	Value      float64   `json:"value"`
+	Unit       string    `json:"unit"`
+	MeasuredAt time.Time `json:"measured_at"`
+	Notes      string    `json:"notes,omitempty"`
+}
+
+func (WeightRecorded) Name() string { return NameWeightRecorded }
+
+// WeightDeleted is raised when a measurement is deleted.
+type WeightDeleted struct {
+	Base
+	WeightID string `json:"weight_id"`
+}
+
+func (WeightDeleted) Name() string { return NameWeightDeleted }
+
+// WeightRestored is raised when a deleted measurement is brought back.
+type WeightRestored struct {
+	Base
+	WeightID string `json:"weight_id"`
+}
+
+func (WeightRestored) Name() string { return NameWeightRestored }
+
+// GoalSet is raised when a user sets a new goal.
+type GoalSet struct {
+	Base
+	GoalID       string  `json:"goal_id"`
+	TargetWeight float64 `json:"target_weight"`
+	Unit         string  `json:"unit"`
+	TargetDate   string  `json:"target_date"`
+}
+
+func (GoalSet) Name() string { return NameGoalSet }
+
+// GoalAchieved is raised when a measurement first reaches the active goal.
+type GoalAchieved struct {
+	Base
+	GoalID       string  `json:"goal_id"`
+	TargetWeight float64 `json:"target_weight"`
+	Weight       float64 `json:"weight"`
+	Unit         string  `json:"unit"`
+}
+
+func (GoalAchieved) Name() string { return NameGoalAchieved }
+
+// GoalDeleted is raised when a goal is deleted.
+type GoalDeleted struct {
+	Base
+	GoalID string `json:"goal_id"`
+}
+
+func (GoalDeleted) Name() string { return NameGoalDeleted }
+
+// GoalRestored is raised when a deleted goal is brought back.
+type GoalRestored struct {
+	Base
+	GoalID string `json:"goal_id"`
+}
+
+func (GoalRestored) Name() string { return NameGoalRestored }
+
+// UserRegistered is raised when a new account is created.
+type UserRegistered struct {
+	Base
+	UserName string `json:"name"`
+	Email    string `json:"email"`
+}
+
+func (UserRegistered) Name() string { return NameUserRegistered }
@@ -59,6 +59,21 @@ func NewGoal(id string, userID user.UserID, targetWeight weight.WeightValue, uni
 	}, nil
 }
 
+// ReconstructGoal rebuilds a stored goal, keeping its state and timestamps.
+func ReconstructGoal(id GoalID, userID user.UserID, targetWeight weight.WeightValue, unit weight.WeightUnit, targetDate TargetDate, description string, active bool, createdAt, updatedAt time.Time) *Goal {
+	return &Goal{
+		id:           id,
+		userID:       userID,
+		targetWeight: targetWeight,
+		unit:         unit,
+		targetDate:   targetDate,
+		description:  description,
+		active:       active,
+		createdAt:    createdAt,
+		updatedAt:    updatedAt,
+	}
+}
+
 func (g *Goal) ID() GoalID {
 	return g.id
 }
@@ -117,3 +132,13 @@ func (g *Goal) IsExpired() bool {
 func (g *Goal) DaysRemaining() int {
 	return g.targetDate.DaysUntil()
 }
+
+// IsReachedBy reports whether current meets the target for a user who
+// started at start: at or below it when losing weight, at or above it when
+// gaining.
+func (g *Goal) IsReachedBy(start, current weight.WeightValue) bool {
+	if start.Float64() >= g.targetWeight.Float64() {
+		return current.Float64() <= g.targetWeight.Float64()
+	}
+	return current.Float64() >= g.targetWeight.Float64()
+}
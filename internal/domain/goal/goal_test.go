@@ -253,3 +253,36 @@ func TestGoal_DaysRemaining(t *testing.T) {
 		t.Errorf("expected 30 days remaining but got %d", days)
 	}
 }
+
+func TestGoal_IsReachedBy(t *testing.T) {
+	userID, _ := user.NewUserID("giada")
+	targetWeight, _ := weight.NewWeightValue(65.0)
+	unit, _ := weight.NewWeightUnit("kg")
+	targetDate, _ := NewTargetDate(2030, 12, 31)
+
+	goal, err := NewGoal("goal_123", userID, targetWeight, unit, targetDate, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		start    weight.WeightValue
+		current  weight.WeightValue
+		expected bool
+	}{
+		{name: "losing, above target", start: 70, current: 66, expected: false},
+		{name: "losing, target reached", start: 70, current: 65, expected: true},
+		{name: "losing, below target", start: 70, current: 64.5, expected: true},
+		{name: "gaining, below target", start: 60, current: 64, expected: false},
+		{name: "gaining, above target", start: 60, current: 65.2, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goal.IsReachedBy(tt.start, tt.current); got != tt.expected {
+				t.Errorf("expected %v but got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"peso/internal/domain/user"
+)
+
+// HeaderName is the request header clients send their key in.
+const HeaderName = "Idempotency-Key"
+
+// FormField carries the key for plain HTML forms, which cannot set headers.
+const FormField = "idempotency_key"
+
+// ReplayedHeader marks a response replayed from a stored record.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength bounds client-chosen keys.
+const MaxKeyLength = 255
+
+var ErrInvalidKey = errors.New("idempotency key must be 1-255 printable ASCII characters")
+
+// Record remembers the outcome of a request sent with an idempotency key,
+// so that retrying it returns the same result instead of repeating it.
+type Record struct {
+	userID      user.UserID
+	key         string
+	requestHash string
+	resourceID  string
+	statusCode  int
+	headers     map[string]string
+	response    []byte
+	createdAt   time.Time
+}
+
+// ValidateKey checks a client-supplied key.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrInvalidKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// Fingerprint hashes the parts of a request that must match for a retry to
+// count as the same request.
+func Fingerprint(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewRecord stores the result of the first request made with key.
+// resourceID is the record the request created, if any; headers are the
+// response headers to send again on replay.
+func NewRecord(userID user.UserID, key, requestHash, resourceID string, statusCode int, headers map[string]string, response []byte) (*Record, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	return &Record{
+		userID:      userID,
+		key:         key,
+		requestHash: requestHash,
+		resourceID:  resourceID,
+		statusCode:  statusCode,
+		headers:     headers,
+		response:    response,
+		createdAt:   time.Now(),
+	}, nil
+}
+
+// ReconstructRecord rebuilds a record loaded from storage.
+func ReconstructRecord(userID user.UserID, key, requestHash, resourceID string, statusCode int, headers map[string]string, response []byte, createdAt time.Time) *Record {
+	return &Record{
+		userID:      userID,
+		key:         key,
+		requestHash: requestHash,
+		resourceID:  resourceID,
+		statusCode:  statusCode,
+		headers:     headers,
+		response:    response,
+		createdAt:   createdAt,
+	}
+}
+
+func (r *Record) UserID() user.UserID {
+	return r.userID
+}
+
+func (r *Record) Key() string {
+	return r.key
+}
+
+func (r *Record) RequestHash() string {
+	return r.requestHash
+}
+
+func (r *Record) ResourceID() string {
+	return r.resourceID
+}
+
+func (r *Record) StatusCode() int {
+	return r.statusCode
+}
+
+func (r *Record) Headers() map[string]string {
+	return r.headers
+}
+
+func (r *Record) Response() []byte {
+	return r.response
+}
+
+func (r *Record) CreatedAt() time.Time {
+	return r.createdAt
+}
+
+// Matches reports whether a retry with requestHash is the request this
+// record was made for.
+func (r *Record) Matches(requestHash string) bool {
+	return r.requestHash == requestHash
+}
@@ -0,0 +1,46 @@
+package idempotency
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "uuid", key: "3f0c8c1e-8d5e-4f6a-9a57-2f1d8f7c9b10", wantErr: false},
+		{name: "empty", key: "", wantErr: true},
+		{name: "too long", key: strings.Repeat("k", MaxKeyLength+1), wantErr: true},
+		{name: "space", key: "two words", wantErr: true},
+		{name: "non ascii", key: "chiave-è", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint("a", "bc") == Fingerprint("ab", "c") {
+		t.Error("expected part boundaries to change the fingerprint")
+	}
+	if Fingerprint("weight", "72.4") != Fingerprint("weight", "72.4") {
+		t.Error("expected the same parts to give the same fingerprint")
+	}
+
+	rec, err := NewRecord("giada", "k1", Fingerprint("weight", "72.4"), "w1", 0, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !rec.Matches(Fingerprint("weight", "72.4")) || rec.Matches(Fingerprint("weight", "72.5")) {
+		t.Error("expected the record to match only its own request")
+	}
+}
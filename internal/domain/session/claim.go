@@ -0,0 +1,64 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"peso/internal/domain/user"
+)
+
+// ClaimTTL is how long a user created without a password has to choose
+// one after signing in with their email.
+const ClaimTTL = 5 * time.Minute
+
+var ErrInvalidClaim = errors.New("invalid or expired password claim")
+
+// NewClaimKey returns a random key for signing password claims.
+func NewClaimKey() []byte {
+	key := make([]byte, tokenLength)
+	rand.Read(key) // never fails since Go 1.24
+	return key
+}
+
+// SignClaim returns a token allowing userID to set a first password until
+// expiresAt, as "<user id>.<unix seconds>.<hex HMAC-SHA256>".
+func SignClaim(key []byte, userID user.UserID, expiresAt time.Time) string {
+	payload := userID.String() + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + claimMAC(key, payload)
+}
+
+// VerifyClaim returns the user a claim was signed for, provided it has not
+// expired at now.
+func VerifyClaim(key []byte, claim string, now time.Time) (user.UserID, error) {
+	i := strings.LastIndexByte(claim, '.')
+	if i < 0 {
+		return "", ErrInvalidClaim
+	}
+	payload, mac := claim[:i], claim[i+1:]
+	if !hmac.Equal([]byte(mac), []byte(claimMAC(key, payload))) {
+		return "", ErrInvalidClaim
+	}
+
+	id, expiry, ok := strings.Cut(payload, ".")
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if !ok || err != nil || !now.Before(time.Unix(unix, 0)) {
+		return "", ErrInvalidClaim
+	}
+	userID, err := user.NewUserID(id)
+	if err != nil {
+		return "", ErrInvalidClaim
+	}
+	return userID, nil
+}
+
+func claimMAC(key []byte, payload string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"peso/internal/domain/user"
+)
+
+func TestVerifyClaim(t *testing.T) {
+	userID, _ := user.NewUserID("6f1c2f0e-3b57-4a8e-9d0c-1f2e3d4c5b6a")
+	key := NewClaimKey()
+	otherKey := NewClaimKey()
+	now := time.Now()
+	claim := SignClaim(key, userID, now.Add(ClaimTTL))
+
+	tests := []struct {
+		name    string
+		key     []byte
+		claim   string
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", key: key, claim: claim, now: now},
+		{name: "expired", key: key, claim: claim, now: now.Add(ClaimTTL + time.Second), wantErr: true},
+		{name: "other key", key: otherKey, claim: claim, now: now, wantErr: true},
+		{name: "other user", key: key, claim: strings.Replace(claim, userID.String(), "giada", 1), now: now, wantErr: true},
+		{name: "bare email", key: key, claim: "giada@example.com", now: now, wantErr: true},
+		{name: "empty", key: key, claim: "", now: now, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyClaim(tt.key, tt.claim, tt.now)
+			if tt.wantErr {
+				if err != ErrInvalidClaim {
+					t.Errorf("expected ErrInvalidClaim, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != userID {
+				t.Errorf("expected user %s, got %s", userID, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package session
+
+import "strings"
+
+// DeviceName derives a short, human friendly label such as "Firefox · Linux"
+// from a User-Agent header. It returns an empty string when neither the
+// browser nor the platform can be recognised.
+func DeviceName(userAgent string) string {
+	browser := browserName(userAgent)
+	platform := platformName(userAgent)
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " · " + platform
+	case browser != "":
+		return browser
+	default:
+		return platform
+	}
+}
+
+func browserName(ua string) string {
+	// Order matters: most browsers also advertise the engines they derive from.
+	switch {
+	case strings.Contains(ua, "Edg/"), strings.Contains(ua, "EdgA/"), strings.Contains(ua, "EdgiOS/"):
+		return "Edge"
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		return "Opera"
+	case strings.Contains(ua, "SamsungBrowser/"):
+		return "Samsung Internet"
+	case strings.Contains(ua, "Firefox/"), strings.Contains(ua, "FxiOS/"):
+		return "Firefox"
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"):
+		return "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		return "Safari"
+	case strings.HasPrefix(ua, "curl/"):
+		return "curl"
+	}
+	return ""
+}
+
+func platformName(ua string) string {
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		return "iPhone"
+	case strings.Contains(ua, "iPad"):
+		return "iPad"
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		return "macOS"
+	case strings.Contains(ua, "CrOS"):
+		return "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	}
+	return ""
+}
@@ -2,7 +2,10 @@ package session
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -10,8 +13,20 @@ import (
 )
 
 const (
-	tokenLength   = 32
-	defaultExpiry = 30 * 24 * time.Hour // 30 days
+	tokenLength = 32
+
+	// IdleTimeout is the default for how long a session survives without
+	// any activity.
+	IdleTimeout = 30 * 24 * time.Hour // 30 days
+
+	// MaxLifetime is the default, and the upper bound, for how long a
+	// session can be kept alive by activity. Cookies are issued for this
+	// long and expiry is enforced server-side.
+	MaxLifetime = 365 * 24 * time.Hour // 1 year
+
+	// touchInterval throttles last-seen updates so that not every request
+	// results in a database write.
+	touchInterval = 5 * time.Minute
 )
 
 var (
@@ -19,15 +34,41 @@ var (
 	ErrInvalidToken   = errors.New("invalid session token")
 )
 
+// Lifetime bounds how long sessions last.
+type Lifetime struct {
+	// Idle is how long a session survives without any activity. Every
+	// authenticated request slides the expiry forward by this amount.
+	Idle time.Duration
+	// Max caps how long a session can be kept alive by activity.
+	Max time.Duration
+}
+
+// DefaultLifetime applies unless configured otherwise.
+var DefaultLifetime = Lifetime{Idle: IdleTimeout, Max: MaxLifetime}
+
+// ClientInfo describes the device a session was opened from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Session is an authenticated login on one device. Only a SHA-256 of the
+// bearer token is kept; the plaintext token exists solely on a freshly
+// created session so it can be handed to the client once.
 type Session struct {
-	id        SessionID
-	userID    user.UserID
-	token     string
-	expiresAt time.Time
-	createdAt time.Time
+	id         SessionID
+	userID     user.UserID
+	token      string
+	tokenHash  string
+	userAgent  string
+	ipAddress  string
+	deviceName string
+	expiresAt  time.Time
+	createdAt  time.Time
+	lastSeenAt time.Time
 }
 
-func NewSession(userID user.UserID) (*Session, error) {
+func NewSession(userID user.UserID, client ClientInfo, lifetime Lifetime) (*Session, error) {
 	token, err := generateToken()
 	if err != nil {
 		return nil, err
@@ -35,21 +76,30 @@ func NewSession(userID user.UserID) (*Session, error) {
 
 	now := time.Now()
 	return &Session{
-		id:        NewSessionID(),
-		userID:    userID,
-		token:     token,
-		expiresAt: now.Add(defaultExpiry),
-		createdAt: now,
+		id:         NewSessionID(),
+		userID:     userID,
+		token:      token,
+		tokenHash:  HashToken(token),
+		userAgent:  client.UserAgent,
+		ipAddress:  client.IPAddress,
+		deviceName: DeviceName(client.UserAgent),
+		expiresAt:  now.Add(min(lifetime.Idle, lifetime.Max)),
+		createdAt:  now,
+		lastSeenAt: now,
 	}, nil
 }
 
-func ReconstructSession(id SessionID, userID user.UserID, token string, expiresAt, createdAt time.Time) *Session {
+func ReconstructSession(id SessionID, userID user.UserID, tokenHash, userAgent, ipAddress, deviceName string, expiresAt, createdAt, lastSeenAt time.Time) *Session {
 	return &Session{
-		id:        id,
-		userID:    userID,
-		token:     token,
-		expiresAt: expiresAt,
-		createdAt: createdAt,
+		id:         id,
+		userID:     userID,
+		tokenHash:  tokenHash,
+		userAgent:  userAgent,
+		ipAddress:  ipAddress,
+		deviceName: deviceName,
+		expiresAt:  expiresAt,
+		createdAt:  createdAt,
+		lastSeenAt: lastSeenAt,
 	}
 }
 
@@ -61,10 +111,34 @@ func (s *Session) UserID() user.UserID {
 	return s.userID
 }
 
+// Token returns the plaintext bearer token. It is empty for sessions
+// loaded from storage.
 func (s *Session) Token() string {
 	return s.token
 }
 
+func (s *Session) TokenHash() string {
+	return s.tokenHash
+}
+
+// MatchesToken reports whether token hashes to this session's token hash,
+// comparing in constant time.
+func (s *Session) MatchesToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(s.tokenHash)) == 1
+}
+
+func (s *Session) UserAgent() string {
+	return s.userAgent
+}
+
+func (s *Session) IPAddress() string {
+	return s.ipAddress
+}
+
+func (s *Session) DeviceName() string {
+	return s.deviceName
+}
+
 func (s *Session) ExpiresAt() time.Time {
 	return s.expiresAt
 }
@@ -73,6 +147,10 @@ func (s *Session) CreatedAt() time.Time {
 	return s.createdAt
 }
 
+func (s *Session) LastSeenAt() time.Time {
+	return s.lastSeenAt
+}
+
 func (s *Session) IsExpired() bool {
 	return time.Now().After(s.expiresAt)
 }
@@ -81,6 +159,35 @@ func (s *Session) IsValid() bool {
 	return !s.IsExpired()
 }
 
+// Touch records activity on the session and slides its expiry forward,
+// never past lifetime.Max from creation. It reports whether anything changed
+// so callers only persist when needed.
+func (s *Session) Touch(now time.Time, ipAddress string, lifetime Lifetime) bool {
+	if now.Sub(s.lastSeenAt) < touchInterval && (ipAddress == "" || ipAddress == s.ipAddress) {
+		return false
+	}
+
+	s.lastSeenAt = now
+	if ipAddress != "" {
+		s.ipAddress = ipAddress
+	}
+
+	expiresAt := now.Add(lifetime.Idle)
+	if hardLimit := s.createdAt.Add(lifetime.Max); expiresAt.After(hardLimit) {
+		expiresAt = hardLimit
+	}
+	s.expiresAt = expiresAt
+
+	return true
+}
+
+// HashToken returns the hex encoded SHA-256 of a bearer token, the form in
+// which tokens are stored and looked up.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func generateToken() (string, error) {
 	bytes := make([]byte, tokenLength)
 	if _, err := rand.Read(bytes); err != nil {
@@ -0,0 +1,191 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"peso/internal/domain/user"
+)
+
+func TestSession_NewSession(t *testing.T) {
+	userID, _ := user.NewUserID("giada")
+	client := ClientInfo{
+		UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0",
+		IPAddress: "192.168.1.10",
+	}
+
+	s, err := NewSession(userID, client, DefaultLifetime)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Token() == "" {
+		t.Error("expected token to be generated")
+	}
+	if s.TokenHash() == s.Token() || s.TokenHash() != HashToken(s.Token()) {
+		t.Error("expected token hash to be the SHA-256 of the token")
+	}
+	if !s.MatchesToken(s.Token()) {
+		t.Error("expected session to match its own token")
+	}
+	if s.MatchesToken(s.Token() + "x") {
+		t.Error("expected session not to match a different token")
+	}
+	if s.UserID() != userID {
+		t.Errorf("expected user ID %s, got %s", userID, s.UserID())
+	}
+	if s.IPAddress() != client.IPAddress {
+		t.Errorf("expected IP %s, got %s", client.IPAddress, s.IPAddress())
+	}
+	if s.DeviceName() != "Firefox · Linux" {
+		t.Errorf("expected device name 'Firefox · Linux', got %q", s.DeviceName())
+	}
+	if !s.LastSeenAt().Equal(s.CreatedAt()) {
+		t.Error("expected last seen to equal creation time")
+	}
+	if got := s.ExpiresAt().Sub(s.CreatedAt()); got != IdleTimeout {
+		t.Errorf("expected expiry %v after creation, got %v", IdleTimeout, got)
+	}
+}
+
+func TestSession_Touch(t *testing.T) {
+	userID, _ := user.NewUserID("giada")
+	created := time.Now().Add(-10 * 24 * time.Hour)
+	lastSeen := created
+
+	tests := []struct {
+		name        string
+		createdAt   time.Time
+		now         time.Time
+		ip          string
+		lifetime    Lifetime
+		wantChanged bool
+		wantExpiry  time.Time
+	}{
+		{
+			name:        "recent activity is not persisted again",
+			createdAt:   created,
+			now:         lastSeen.Add(time.Minute),
+			ip:          "10.0.0.1",
+			wantChanged: false,
+			wantExpiry:  lastSeen.Add(IdleTimeout),
+		},
+		{
+			name:        "ip change is recorded immediately",
+			createdAt:   created,
+			now:         lastSeen.Add(time.Minute),
+			ip:          "10.0.0.2",
+			wantChanged: true,
+			wantExpiry:  lastSeen.Add(time.Minute).Add(IdleTimeout),
+		},
+		{
+			name:        "activity slides expiry",
+			createdAt:   created,
+			now:         lastSeen.Add(time.Hour),
+			ip:          "10.0.0.1",
+			wantChanged: true,
+			wantExpiry:  lastSeen.Add(time.Hour).Add(IdleTimeout),
+		},
+		{
+			name:        "expiry never exceeds max lifetime",
+			createdAt:   lastSeen.Add(-MaxLifetime + time.Hour),
+			now:         lastSeen.Add(time.Hour),
+			ip:          "10.0.0.1",
+			wantChanged: true,
+			wantExpiry:  lastSeen.Add(time.Hour),
+		},
+		{
+			name:        "configured lifetime",
+			createdAt:   lastSeen.Add(-time.Hour),
+			now:         lastSeen.Add(time.Hour),
+			ip:          "10.0.0.1",
+			lifetime:    Lifetime{Idle: 2 * time.Hour, Max: 3 * time.Hour},
+			wantChanged: true,
+			wantExpiry:  lastSeen.Add(2 * time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := ReconstructSession(NewSessionID(), userID, HashToken("token"), "", "10.0.0.1", "", lastSeen.Add(IdleTimeout), tt.createdAt, lastSeen)
+
+			lifetime := tt.lifetime
+			if lifetime == (Lifetime{}) {
+				lifetime = DefaultLifetime
+			}
+			changed := s.Touch(tt.now, tt.ip, lifetime)
+			if changed != tt.wantChanged {
+				t.Errorf("expected changed=%v, got %v", tt.wantChanged, changed)
+			}
+			if !s.ExpiresAt().Equal(tt.wantExpiry) {
+				t.Errorf("expected expiry %v, got %v", tt.wantExpiry, s.ExpiresAt())
+			}
+			if tt.wantChanged && s.IPAddress() != tt.ip {
+				t.Errorf("expected IP %s, got %s", tt.ip, s.IPAddress())
+			}
+		})
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	// echo -n "token" | sha256sum
+	want := "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0"
+	if got := HashToken("token"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	s := ReconstructSession(NewSessionID(), "giada", want, "", "", "", time.Now().Add(time.Hour), time.Now(), time.Now())
+	if s.Token() != "" {
+		t.Error("expected reconstructed session to carry no plaintext token")
+	}
+	if !s.MatchesToken("token") {
+		t.Error("expected reconstructed session to match token")
+	}
+}
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{
+			name:      "safari on iphone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want:      "Safari · iPhone",
+		},
+		{
+			name:      "chrome on android",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+			want:      "Chrome · Android",
+		},
+		{
+			name:      "edge on windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0",
+			want:      "Edge · Windows",
+		},
+		{
+			name:      "chrome on macos",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want:      "Chrome · macOS",
+		},
+		{
+			name:      "command line client",
+			userAgent: "curl/8.7.1",
+			want:      "curl",
+		},
+		{
+			name:      "unknown",
+			userAgent: "",
+			want:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeviceName(tt.userAgent); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
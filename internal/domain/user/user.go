@@ -11,13 +11,15 @@ type User struct {
 	name         string
 	email        string
 	passwordHash string
+	language     string
 	active       bool
 	createdAt    time.Time
 	updatedAt    time.Time
 }
 
 var (
-	ErrEmptyName = errors.New("user name cannot be empty")
+	ErrEmptyName       = errors.New("user name cannot be empty")
+	ErrInvalidLanguage = errors.New("language must be a two-letter ISO 639-1 code")
 )
 
 func NewUser(id, name, email string) (*User, error) {
@@ -107,6 +109,35 @@ func (u *User) UpdateName(name string) error {
 	return nil
 }
 
+// Language is the interface language the user chose, as a two-letter code,
+// or "" to follow the browser
+func (u *User) Language() string {
+	return u.language
+}
+
+func (u *User) SetLanguage(language string) error {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language != "" && !isLanguageCode(language) {
+		return ErrInvalidLanguage
+	}
+
+	u.language = language
+	u.updatedAt = time.Now()
+	return nil
+}
+
+func isLanguageCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
 func (u *User) PasswordHash() string {
 	return u.passwordHash
 }
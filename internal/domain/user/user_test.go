@@ -200,3 +200,47 @@ func TestUser_UpdateName(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_SetLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		want     string
+		wantErr  bool
+	}{
+		{name: "two-letter code", language: "en", want: "en"},
+		{name: "upper case is normalised", language: " IT ", want: "it"},
+		{name: "empty follows the browser", language: "", want: ""},
+		{name: "region tag", language: "en-GB", wantErr: true},
+		{name: "not a letter", language: "e1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := NewUser("giada", "Giada", "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := user.SetLanguage("de"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = user.SetLanguage(tt.language)
+			if tt.wantErr {
+				if err != ErrInvalidLanguage {
+					t.Errorf("expected ErrInvalidLanguage, got %v", err)
+				}
+				if user.Language() != "de" {
+					t.Errorf("expected language to remain de but got %s", user.Language())
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if user.Language() != tt.want {
+				t.Errorf("expected language %q, got %q", tt.want, user.Language())
+			}
+		})
+	}
+}
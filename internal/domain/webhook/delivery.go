@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"peso/internal/domain/user"
+)
+
+// Status is where a delivery is in its lifecycle.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusFailed    Status = "failed"
+)
+
+const (
+	// MaxAttempts is how often a delivery is tried before it is given up.
+	MaxAttempts = 10
+
+	backoffBase = 30 * time.Second
+	backoffMax  = 6 * time.Hour
+
+	maxErrorLength = 500
+)
+
+// Backoff is the wait after the given failed attempt: 30s after the first,
+// doubling each time, capped at six hours.
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+	d := backoffBase
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= backoffMax {
+			return backoffMax
+		}
+	}
+	return d
+}
+
+// Delivery is one event queued for one webhook. It stays pending until an
+// attempt succeeds or MaxAttempts have failed.
+type Delivery struct {
+	id             string
+	webhookID      WebhookID
+	userID         user.UserID
+	event          string
+	payload        []byte
+	status         Status
+	attempts       int
+	nextAttemptAt  time.Time
+	lastStatusCode int
+	lastError      string
+	createdAt      time.Time
+	updatedAt      time.Time
+}
+
+// Attempt records the outcome of sending a delivery once.
+type Attempt struct {
+	DeliveryID  string
+	Number      int
+	AttemptedAt time.Time
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+}
+
+// Succeeded reports whether the receiver accepted the delivery.
+func (a Attempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+func NewDelivery(webhookID WebhookID, userID user.UserID, eventName string, payload []byte) *Delivery {
+	now := time.Now()
+	return &Delivery{
+		id:            uuid.New().String(),
+		webhookID:     webhookID,
+		userID:        userID,
+		event:         eventName,
+		payload:       payload,
+		status:        StatusPending,
+		nextAttemptAt: now,
+		createdAt:     now,
+		updatedAt:     now,
+	}
+}
+
+func ReconstructDelivery(id string, webhookID WebhookID, userID user.UserID, eventName string, payload []byte, status Status, attempts int, nextAttemptAt time.Time, lastStatusCode int, lastError string, createdAt, updatedAt time.Time) *Delivery {
+	return &Delivery{
+		id:             id,
+		webhookID:      webhookID,
+		userID:         userID,
+		event:          eventName,
+		payload:        payload,
+		status:         status,
+		attempts:       attempts,
+		nextAttemptAt:  nextAttemptAt,
+		lastStatusCode: lastStatusCode,
+		lastError:      lastError,
+		createdAt:      createdAt,
+		updatedAt:      updatedAt,
+	}
+}
+
+func (d *Delivery) ID() string {
+	return d.id
+}
+
+func (d *Delivery) WebhookID() WebhookID {
+	return d.webhookID
+}
+
+func (d *Delivery) UserID() user.UserID {
+	return d.userID
+}
+
+func (d *Delivery) Event() string {
+	return d.event
+}
+
+func (d *Delivery) Payload() []byte {
+	return d.payload
+}
+
+func (d *Delivery) Status() Status {
+	return d.status
+}
+
+func (d *Delivery) Attempts() int {
+	return d.attempts
+}
+
+func (d *Delivery) NextAttemptAt() time.Time {
+	return d.nextAttemptAt
+}
+
+func (d *Delivery) LastStatusCode() int {
+	return d.lastStatusCode
+}
+
+func (d *Delivery) LastError() string {
+	return d.lastError
+}
+
+func (d *Delivery) CreatedAt() time.Time {
+	return d.createdAt
+}
+
+func (d *Delivery) UpdatedAt() time.Time {
+	return d.updatedAt
+}
+
+// RecordAttempt applies the outcome of sending the delivery at the given
+// time: a 2xx response delivers it, anything else schedules a retry with
+// Backoff, or fails it for good after MaxAttempts.
+func (d *Delivery) RecordAttempt(at time.Time, statusCode int, sendErr error, duration time.Duration) Attempt {
+	d.attempts++
+	d.updatedAt = at
+	d.lastStatusCode = statusCode
+
+	attempt := Attempt{
+		DeliveryID:  d.id,
+		Number:      d.attempts,
+		AttemptedAt: at,
+		StatusCode:  statusCode,
+		Duration:    duration,
+	}
+	switch {
+	case sendErr != nil:
+		attempt.Error = truncate(sendErr.Error())
+	case statusCode < 200 || statusCode >= 300:
+		attempt.Error = fmt.Sprintf("unexpected status %d", statusCode)
+	}
+	d.lastError = attempt.Error
+
+	switch {
+	case attempt.Succeeded():
+		d.status = StatusDelivered
+	case d.attempts >= MaxAttempts:
+		d.status = StatusFailed
+	default:
+		d.nextAttemptAt = at.Add(Backoff(d.attempts))
+	}
+	return attempt
+}
+
+func truncate(s string) string {
+	if len(s) <= maxErrorLength {
+		return s
+	}
+	return s[:maxErrorLength]
+}
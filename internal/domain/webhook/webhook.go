@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+
+	"peso/internal/domain/event"
+	"peso/internal/domain/user"
+)
+
+const (
+	secretLength = 32
+	maxURLLength = 2048
+)
+
+// EventTest is the event type of deliveries sent with the "send test
+// event" button. Every webhook receives it.
+const EventTest = "webhook.test"
+
+// SupportedEvents are the domain events a webhook can subscribe to.
+var SupportedEvents = []string{
+	event.NameWeightRecorded,
+	event.NameWeightDeleted,
+	event.NameGoalSet,
+	event.NameGoalAchieved,
+}
+
+var (
+	ErrInvalidURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrNoEvents         = errors.New("webhook must subscribe to at least one event")
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+)
+
+// Webhook is a URL a user wants to be notified at when selected events
+// happen to their data. Payloads are signed with the webhook's secret.
+type Webhook struct {
+	id        WebhookID
+	userID    user.UserID
+	url       string
+	secret    string
+	events    []string
+	createdAt time.Time
+}
+
+func NewWebhook(userID user.UserID, rawURL string, events []string) (*Webhook, error) {
+	if userID.IsEmpty() {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	if err := validateURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	for _, name := range events {
+		if !slices.Contains(SupportedEvents, name) {
+			return nil, ErrUnsupportedEvent
+		}
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	events = slices.Clone(events)
+	slices.Sort(events)
+
+	return &Webhook{
+		id:        NewWebhookID(),
+		userID:    userID,
+		url:       rawURL,
+		secret:    secret,
+		events:    slices.Compact(events),
+		createdAt: time.Now(),
+	}, nil
+}
+
+func ReconstructWebhook(id WebhookID, userID user.UserID, rawURL, secret string, events []string, createdAt time.Time) *Webhook {
+	return &Webhook{
+		id:        id,
+		userID:    userID,
+		url:       rawURL,
+		secret:    secret,
+		events:    events,
+		createdAt: createdAt,
+	}
+}
+
+func (w *Webhook) ID() WebhookID {
+	return w.id
+}
+
+func (w *Webhook) UserID() user.UserID {
+	return w.userID
+}
+
+func (w *Webhook) URL() string {
+	return w.url
+}
+
+// Secret is the HMAC key receivers use to verify payloads.
+func (w *Webhook) Secret() string {
+	return w.secret
+}
+
+func (w *Webhook) Events() []string {
+	return w.events
+}
+
+func (w *Webhook) CreatedAt() time.Time {
+	return w.createdAt
+}
+
+// Subscribes reports whether the webhook wants deliveries for name.
+func (w *Webhook) Subscribes(name string) bool {
+	return name == EventTest || slices.Contains(w.events, name)
+}
+
+func validateURL(rawURL string) error {
+	if len(rawURL) > maxURLLength {
+		return ErrInvalidURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	bytes := make([]byte, secretLength)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
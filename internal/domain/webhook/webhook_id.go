@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmptyWebhookID   = errors.New("webhook ID cannot be empty")
+	ErrInvalidWebhookID = errors.New("invalid webhook ID format")
+)
+
+type WebhookID struct {
+	value string
+}
+
+func NewWebhookID() WebhookID {
+	return WebhookID{value: uuid.New().String()}
+}
+
+func ParseWebhookID(id string) (WebhookID, error) {
+	trimmed := strings.TrimSpace(id)
+	if trimmed == "" {
+		return WebhookID{}, ErrEmptyWebhookID
+	}
+
+	if _, err := uuid.Parse(trimmed); err != nil {
+		return WebhookID{}, ErrInvalidWebhookID
+	}
+
+	return WebhookID{value: trimmed}, nil
+}
+
+func (id WebhookID) String() string {
+	return id.value
+}
+
+func (id WebhookID) IsEmpty() bool {
+	return id.value == ""
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"peso/internal/domain/event"
+)
+
+func TestNewWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		events  []string
+		wantErr error
+	}{
+		{name: "valid", url: "http://192.168.1.10:8123/api/webhook/peso", events: []string{event.NameGoalAchieved, event.NameWeightRecorded}},
+		{name: "relative URL", url: "/hook", events: []string{event.NameGoalSet}, wantErr: ErrInvalidURL},
+		{name: "unsupported scheme", url: "ftp://example.com/hook", events: []string{event.NameGoalSet}, wantErr: ErrInvalidURL},
+		{name: "no events", url: "https://example.com/hook", wantErr: ErrNoEvents},
+		{name: "unknown event", url: "https://example.com/hook", events: []string{event.NameUserRegistered}, wantErr: ErrUnsupportedEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewWebhook("giada", tt.url, tt.events)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(w.Secret()) < 32 {
+				t.Errorf("expected a generated secret, got %q", w.Secret())
+			}
+			if !w.Subscribes(event.NameWeightRecorded) || !w.Subscribes(EventTest) || w.Subscribes(event.NameGoalSet) {
+				t.Errorf("unexpected subscriptions %v", w.Events())
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 30 * time.Second},
+		{attempt: 2, want: time.Minute},
+		{attempt: 5, want: 8 * time.Minute},
+		{attempt: 9, want: 128 * time.Minute},
+		{attempt: 12, want: 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d): expected %s, got %s", tt.attempt, tt.want, got)
+		}
+	}
+}
+
+func TestDelivery_RecordAttempt(t *testing.T) {
+	d := NewDelivery(NewWebhookID(), "giada", event.NameGoalSet, []byte(`{}`))
+	start := time.Now()
+
+	a := d.RecordAttempt(start, 500, nil, time.Millisecond)
+	if a.Succeeded() || a.Error != "unexpected status 500" || a.Number != 1 {
+		t.Errorf("expected failed first attempt, got %+v", a)
+	}
+	if d.Status() != StatusPending || !d.NextAttemptAt().Equal(start.Add(30*time.Second)) {
+		t.Errorf("expected retry in 30s, got %s at %s", d.Status(), d.NextAttemptAt())
+	}
+
+	d.RecordAttempt(start, 0, errors.New("connection refused"), 0)
+	if d.LastError() != "connection refused" || !d.NextAttemptAt().Equal(start.Add(time.Minute)) {
+		t.Errorf("expected retry in 1m after transport error, got %q at %s", d.LastError(), d.NextAttemptAt())
+	}
+
+	d.RecordAttempt(start, 204, nil, 0)
+	if d.Status() != StatusDelivered || d.LastError() != "" {
+		t.Errorf("expected delivered, got %s (%q)", d.Status(), d.LastError())
+	}
+
+	failing := NewDelivery(NewWebhookID(), "giada", event.NameGoalSet, nil)
+	var statuses []Status
+	for range MaxAttempts {
+		failing.RecordAttempt(start, 503, nil, 0)
+		statuses = append(statuses, failing.Status())
+	}
+	if statuses[MaxAttempts-2] != StatusPending || statuses[MaxAttempts-1] != StatusFailed {
+		t.Errorf("expected failure only after %d attempts, got %v", MaxAttempts, statuses)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"type":"goal.achieved"}`)
+	header := Sign("whsec_test", time.Now(), body)
+
+	if err := Verify("whsec_test", header, body, 5*time.Minute); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+	if err := Verify("whsec_other", header, body, 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected wrong secret to fail, got %v", err)
+	}
+	if err := Verify("whsec_test", header, []byte(`{}`), 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected tampered body to fail, got %v", err)
+	}
+	old := Sign("whsec_test", time.Now().Add(-time.Hour), body)
+	if err := Verify("whsec_test", old, body, 5*time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected stale signature to fail, got %v", err)
+	}
+}
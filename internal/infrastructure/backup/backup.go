@@ -0,0 +1,324 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"peso/internal/infrastructure/persistence"
+)
+
+const (
+	filePrefix = "peso-"
+	fileSuffix = ".db"
+	timeLayout = "20060102T150405.000Z"
+)
+
+// ErrNotFound is returned for snapshot names that are not in the backup
+// directory.
+var ErrNotFound = errors.New("backup not found")
+
+// Snapshot is a verified copy of the database in the backup directory.
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Retention decides which snapshots survive a prune: the newest Last ones,
+// plus the newest snapshot of each of the last Daily days and Weekly ISO
+// weeks that have one. A zero Retention keeps everything.
+type Retention struct {
+	Last   int
+	Daily  int
+	Weekly int
+}
+
+func (r Retention) keepsAll() bool {
+	return r.Last <= 0 && r.Daily <= 0 && r.Weekly <= 0
+}
+
+// keep returns the names to retain from snapshots sorted newest first.
+func (r Retention) keep(snapshots []Snapshot) map[string]bool {
+	kept := map[string]bool{}
+	for i, s := range snapshots {
+		if i < r.Last {
+			kept[s.Name] = true
+		}
+	}
+
+	keepNewestPer := func(limit int, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for _, s := range snapshots {
+			if len(seen) >= limit {
+				return
+			}
+			key := period(s.CreatedAt)
+			if !seen[key] {
+				seen[key] = true
+				kept[s.Name] = true
+			}
+		}
+	}
+	keepNewestPer(r.Daily, func(t time.Time) string { return t.Format("2006-01-02") })
+	keepNewestPer(r.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%02d", year, week)
+	})
+	return kept
+}
+
+// Manager takes snapshots of a SQLite database into a directory and
+// rotates them according to its Retention.
+type Manager struct {
+	db        *persistence.DB
+	dir       string
+	retention Retention
+	logger    *slog.Logger
+
+	// mu serializes snapshots so concurrent triggers cannot race on names.
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewManager returns a Manager writing into dir, which is created with the
+// first snapshot.
+func NewManager(db *persistence.DB, dir string, retention Retention, logger *slog.Logger) *Manager {
+	return &Manager{
+		db:        db,
+		dir:       dir,
+		retention: retention,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Create writes a new snapshot and verifies it before making it visible.
+func (m *Manager) Create(ctx context.Context) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	created := m.now().UTC().Truncate(time.Millisecond)
+	name := filePrefix + created.Format(timeLayout) + fileSuffix
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	path := filepath.Join(m.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return Snapshot{}, fmt.Errorf("backup %s already exists", name)
+	}
+
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if err := m.db.BackupTo(ctx, tmp); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	if err := persistence.CheckIntegrity(ctx, tmp); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, fmt.Errorf("failed to finalize backup: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to stat backup: %w", err)
+	}
+	return Snapshot{Name: name, Size: info.Size(), CreatedAt: created}, nil
+}
+
+// List returns the snapshots in the backup directory, newest first.
+func (m *Manager) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(m.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		created, ok := parseName(entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat backup: %w", err)
+		}
+		snapshots = append(snapshots, Snapshot{Name: entry.Name(), Size: info.Size(), CreatedAt: created})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Open returns the snapshot file called name. Only names produced by Create
+// are accepted, so callers may pass user input.
+func (m *Manager) Open(name string) (*os.File, error) {
+	if _, ok := parseName(name); !ok {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(filepath.Join(m.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	return f, nil
+}
+
+// Prune deletes the snapshots the retention rules no longer keep and
+// returns their names.
+func (m *Manager) Prune() ([]string, error) {
+	if m.retention.keepsAll() {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshots, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	kept := m.retention.keep(snapshots)
+	var removed []string
+	for _, s := range snapshots {
+		if kept[s.Name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(m.dir, s.Name)); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", s.Name, err)
+		}
+		removed = append(removed, s.Name)
+	}
+	return removed, nil
+}
+
+// Run takes a snapshot and prunes old ones on every tick until ctx is
+// cancelled.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snapshot, err := m.Create(ctx)
+			if err != nil {
+				m.logger.Error("failed_to_create_backup", slog.Any("error", err))
+				continue
+			}
+			m.logger.Info("backup_created",
+				slog.String("name", snapshot.Name),
+				slog.Int64("size", snapshot.Size),
+			)
+
+			removed, err := m.Prune()
+			if err != nil {
+				m.logger.Warn("failed_to_prune_backups", slog.Any("error", err))
+			}
+			if len(removed) > 0 {
+				m.logger.Info("backups_pruned", slog.Any("removed", removed))
+			}
+		}
+	}
+}
+
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+	created, err := time.Parse(timeLayout, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created, true
+}
+
+// Restore replaces the SQLite database at dst with the snapshot at src. The
+// snapshot is verified first, and the current database is preserved next
+// to dst; the returned path names that copy, or is empty when dst did not
+// exist. The server must not be running against dst.
+func Restore(ctx context.Context, src, dst string) (string, error) {
+	if err := persistence.CheckIntegrity(ctx, src); err != nil {
+		return "", err
+	}
+
+	tmp := dst + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+
+	var previous string
+	if _, err := os.Stat(dst); err == nil {
+		previous = fmt.Sprintf("%s.pre-restore-%s", dst, time.Now().UTC().Format(timeLayout))
+		if err := snapshotFile(ctx, dst, previous); err != nil {
+			return "", err
+		}
+	}
+
+	// A stale write-ahead log would be replayed on top of the restored file.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dst + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return previous, fmt.Errorf("failed to remove %s: %w", dst+suffix, err)
+		}
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return previous, fmt.Errorf("failed to replace database: %w", err)
+	}
+	return previous, nil
+}
+
+// snapshotFile copies the database at path, including any committed
+// changes still in its write-ahead log, to dest.
+func snapshotFile(ctx context.Context, path, dest string) error {
+	db, err := persistence.NewDB(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.BackupTo(ctx, dest)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to sync %s: %w", dst, err)
+	}
+	return out.Close()
+}
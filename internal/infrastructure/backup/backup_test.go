@@ -0,0 +1,195 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"peso/internal/domain/user"
+	"peso/internal/infrastructure/persistence"
+)
+
+func setupDB(t *testing.T, path string) *persistence.DB {
+	t.Helper()
+
+	db, err := persistence.NewDB(path)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	if err := db.Migrate(os.DirFS("../../../migrations/sqlite")); err != nil {
+		db.Close()
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func userExists(t *testing.T, path, id string) bool {
+	t.Helper()
+
+	db, err := persistence.NewDB(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	exists, err := persistence.NewUserRepository(db).Exists(context.Background(), user.UserID(id))
+	if err != nil {
+		t.Fatalf("failed to query user: %v", err)
+	}
+	return exists
+}
+
+func TestRetention_Keep(t *testing.T) {
+	base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC) // a Monday
+	var snapshots []Snapshot
+	// Two snapshots a day for 21 days, newest first.
+	for i := range 42 {
+		created := base.Add(-time.Duration(i) * 12 * time.Hour)
+		snapshots = append(snapshots, Snapshot{Name: created.Format(timeLayout), CreatedAt: created})
+	}
+
+	tests := []struct {
+		name      string
+		retention Retention
+		want      int
+	}{
+		{name: "last only", retention: Retention{Last: 3}, want: 3},
+		{name: "daily only", retention: Retention{Daily: 5}, want: 5},
+		{name: "weekly only", retention: Retention{Weekly: 2}, want: 2},
+		{name: "overlapping rules", retention: Retention{Last: 2, Daily: 2, Weekly: 4}, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept := tt.retention.keep(snapshots)
+			if len(kept) != tt.want {
+				t.Errorf("expected %d snapshots kept, got %d", tt.want, len(kept))
+			}
+			if !kept[snapshots[0].Name] {
+				t.Error("expected the newest snapshot to be kept")
+			}
+		})
+	}
+}
+
+func TestManager_CreateListPrune(t *testing.T) {
+	dir := t.TempDir()
+	db := setupDB(t, filepath.Join(dir, "peso.db"))
+	defer db.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := NewManager(db, filepath.Join(dir, "backups"), Retention{Last: 2}, logger)
+	if snapshots, err := m.List(); err != nil || len(snapshots) != 0 {
+		t.Fatalf("expected no backups before the first snapshot, got %v (err %v)", snapshots, err)
+	}
+
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	var names []string
+	for i := range 3 {
+		m.now = func() time.Time { return start.Add(time.Duration(i) * time.Hour) }
+		snapshot, err := m.Create(context.Background())
+		if err != nil {
+			t.Fatalf("failed to create backup: %v", err)
+		}
+		if snapshot.Size == 0 {
+			t.Error("expected non-empty backup")
+		}
+		names = append(names, snapshot.Name)
+	}
+
+	if _, err := m.Create(context.Background()); err == nil {
+		t.Error("expected error for a second backup within the same millisecond")
+	}
+
+	removed, err := m.Prune()
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if !reflect.DeepEqual(removed, names[:1]) {
+		t.Errorf("expected %v removed, got %v", names[:1], removed)
+	}
+
+	snapshots, err := m.List()
+	if err != nil {
+		t.Fatalf("failed to list backups: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != names[2] {
+		t.Errorf("expected newest two backups, got %+v", snapshots)
+	}
+
+	f, err := m.Open(names[2])
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	f.Close()
+}
+
+func TestManager_OpenRejectsUnknownNames(t *testing.T) {
+	dir := t.TempDir()
+	db := setupDB(t, filepath.Join(dir, "peso.db"))
+	defer db.Close()
+
+	m := NewManager(db, filepath.Join(dir, "backups"), Retention{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, name := range []string{"../peso.db", "peso.db", "peso-20250301T080000.000Z.db"} {
+		if _, err := m.Open(name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestRestore_ReplacesDatabaseAndKeepsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "peso.db")
+	snapshotPath := filepath.Join(dir, "snapshot.db")
+
+	db := setupDB(t, dbPath)
+	if err := db.BackupTo(context.Background(), snapshotPath); err != nil {
+		t.Fatalf("failed to back up: %v", err)
+	}
+	u, err := user.NewUser("marta", "Marta", "marta@example.com")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := persistence.NewUserRepository(db).Save(context.Background(), u); err != nil {
+		t.Fatalf("failed to save user: %v", err)
+	}
+	db.Close()
+
+	previous, err := Restore(context.Background(), snapshotPath, dbPath)
+	if err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+
+	if userExists(t, dbPath, "marta") {
+		t.Error("expected user added after the snapshot to be gone")
+	}
+	if !userExists(t, previous, "marta") {
+		t.Error("expected previous database to be preserved")
+	}
+}
+
+func TestRestore_RejectsCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "peso.db")
+	db := setupDB(t, dbPath)
+	db.Close()
+
+	corrupt := filepath.Join(dir, "corrupt.db")
+	if err := os.WriteFile(corrupt, []byte("not a database"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := Restore(context.Background(), corrupt, dbPath); err == nil {
+		t.Fatal("expected error restoring a corrupt snapshot")
+	}
+	if !userExists(t, dbPath, "giada") {
+		t.Error("expected the current database to be untouched")
+	}
+}
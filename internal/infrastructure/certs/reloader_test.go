@@ -0,0 +1,162 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeyPair(t *testing.T, dir, commonName string, modTime time.Time) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, modTime, modTime); err != nil {
+			t.Fatalf("failed to set mtime: %v", err)
+		}
+	}
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("failed to get certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestReloader_PicksUpRenewedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start := time.Now().Add(-time.Hour)
+
+	certFile, keyFile := writeKeyPair(t, dir, "old", start)
+	r, err := NewReloader(certFile, keyFile, logger)
+	if err != nil {
+		t.Fatalf("failed to create reloader: %v", err)
+	}
+
+	reloaded, err := r.Reload()
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if reloaded {
+		t.Error("expected no reload when files are unchanged")
+	}
+
+	writeKeyPair(t, dir, "new", start.Add(time.Minute))
+	reloaded, err = r.Reload()
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if !reloaded {
+		t.Error("expected reload after files changed")
+	}
+	if got := commonName(t, r); got != "new" {
+		t.Errorf("expected certificate new, got %s", got)
+	}
+}
+
+func TestReloader_KeepsCertificateOnInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start := time.Now().Add(-time.Hour)
+
+	certFile, keyFile := writeKeyPair(t, dir, "good", start)
+	r, err := NewReloader(certFile, keyFile, logger)
+	if err != nil {
+		t.Fatalf("failed to create reloader: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+	if err := os.Chtimes(certFile, start.Add(time.Minute), start.Add(time.Minute)); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	if _, err := r.Reload(); err == nil {
+		t.Error("expected error for invalid certificate")
+	}
+	if got := commonName(t, r); got != "good" {
+		t.Errorf("expected previous certificate good, got %s", got)
+	}
+}
+
+func TestNewReloader_MissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, err := NewReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), logger)
+	if err == nil {
+		t.Error("expected error for missing files")
+	}
+}
+
+func TestParseMinVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    uint16
+		wantErr bool
+	}{
+		{input: "1.2", want: tls.VersionTLS12},
+		{input: "1.3", want: tls.VersionTLS13},
+		{input: "1.1", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMinVersion(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
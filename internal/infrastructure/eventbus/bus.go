@@ -0,0 +1,180 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"peso/internal/domain/event"
+)
+
+// All subscribes a handler to every event.
+const All = "*"
+
+const (
+	defaultWorkers   = 4
+	defaultQueueSize = 256
+)
+
+// Handler reacts to a published event.
+type Handler func(ctx context.Context, e event.Event) error
+
+// Typed adapts a handler for one concrete event type. Events of other types
+// are ignored.
+func Typed[E event.Event](fn func(ctx context.Context, e E) error) Handler {
+	return func(ctx context.Context, e event.Event) error {
+		typed, ok := e.(E)
+		if !ok {
+			return nil
+		}
+		return fn(ctx, typed)
+	}
+}
+
+type subscription struct {
+	name    string
+	handler Handler
+	async   bool
+}
+
+type delivery struct {
+	ctx   context.Context
+	event event.Event
+	sub   subscription
+}
+
+// Bus is an in-process EventPublisher. Synchronous subscribers run in the
+// publishing goroutine, in subscription order; asynchronous ones run on a
+// pool of workers fed by a bounded queue, and are dropped with a warning
+// when it is full. Handler errors and panics are logged and never reach
+// the publisher.
+type Bus struct {
+	logger *slog.Logger
+
+	mu            sync.RWMutex
+	subscriptions []subscription
+	closed        bool
+
+	queue   chan delivery
+	workers sync.WaitGroup
+}
+
+// New starts a bus with its async workers. Close stops them.
+func New(logger *slog.Logger) *Bus {
+	b := &Bus{
+		logger: logger,
+		queue:  make(chan delivery, defaultQueueSize),
+	}
+	for range defaultWorkers {
+		b.workers.Add(1)
+		go b.work()
+	}
+	return b
+}
+
+// Subscribe runs h synchronously for every event called name, or for all
+// events when name is All.
+func (b *Bus) Subscribe(name string, h Handler) {
+	b.subscribe(subscription{name: name, handler: h})
+}
+
+// SubscribeAsync runs h on a background worker for every event called
+// name, or for all events when name is All. h receives the publisher's
+// context without its cancellation, so it may outlive the request.
+func (b *Bus) SubscribeAsync(name string, h Handler) {
+	b.subscribe(subscription{name: name, handler: h, async: true})
+}
+
+func (b *Bus) subscribe(s subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, s)
+}
+
+// Publish dispatches events in order.
+func (b *Bus) Publish(ctx context.Context, events ...event.Event) {
+	for _, e := range events {
+		b.mu.RLock()
+		var matching []subscription
+		for _, s := range b.subscriptions {
+			if s.name == All || s.name == e.Name() {
+				matching = append(matching, s)
+			}
+		}
+		b.mu.RUnlock()
+
+		for _, s := range matching {
+			if s.async {
+				b.enqueue(delivery{ctx: context.WithoutCancel(ctx), event: e, sub: s})
+				continue
+			}
+			b.run(ctx, e, s)
+		}
+	}
+}
+
+func (b *Bus) enqueue(d delivery) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		b.logger.WarnContext(d.ctx, "event_dropped", slog.String("event", d.event.Name()), slog.String("reason", "bus closed"))
+		return
+	}
+	select {
+	case b.queue <- d:
+	default:
+		b.logger.WarnContext(d.ctx, "event_dropped", slog.String("event", d.event.Name()), slog.String("reason", "queue full"))
+	}
+}
+
+func (b *Bus) work() {
+	defer b.workers.Done()
+	for d := range b.queue {
+		b.run(d.ctx, d.event, d.sub)
+	}
+}
+
+func (b *Bus) run(ctx context.Context, e event.Event, s subscription) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			b.logger.ErrorContext(ctx, "event_handler_panic",
+				slog.String("event", e.Name()),
+				slog.Any("panic", rec),
+			)
+		}
+	}()
+
+	if err := s.handler(ctx, e); err != nil {
+		b.logger.ErrorContext(ctx, "event_handler_failed",
+			slog.String("event", e.Name()),
+			slog.Bool("async", s.async),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// Close stops accepting async deliveries and waits for the queued ones to
+// finish, or for ctx to be done.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event bus did not drain: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,100 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"peso/internal/domain/event"
+)
+
+func newTestBus(t *testing.T) *Bus {
+	t.Helper()
+	b := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { b.Close(context.Background()) })
+	return b
+}
+
+func TestBus_SyncSubscribersRunInOrder(t *testing.T) {
+	b := newTestBus(t)
+
+	var got []string
+	b.Subscribe(event.NameWeightRecorded, func(ctx context.Context, e event.Event) error {
+		got = append(got, "first:"+e.Name())
+		return errors.New("ignored")
+	})
+	b.Subscribe(All, func(ctx context.Context, e event.Event) error {
+		got = append(got, "all:"+e.Name())
+		return nil
+	})
+	b.Subscribe(event.NameGoalSet, func(ctx context.Context, e event.Event) error {
+		panic("recovered")
+	})
+
+	b.Publish(context.Background(),
+		event.WeightRecorded{Base: event.NewBase("giada"), WeightID: "w1"},
+		event.GoalSet{Base: event.NewBase("giada"), GoalID: "g1"},
+	)
+
+	want := []string{"first:weight.recorded", "all:weight.recorded", "all:goal.set"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBus_AsyncSubscribersOutliveRequest(t *testing.T) {
+	b := newTestBus(t)
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	b.SubscribeAsync(event.NameWeightDeleted, Typed(func(ctx context.Context, e event.WeightDeleted) error {
+		if err := ctx.Err(); err != nil {
+			t.Errorf("expected live context, got %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, e.WeightID)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Publish(ctx, event.WeightDeleted{Base: event.NewBase("giada"), WeightID: "w1"})
+	cancel()
+
+	closeCtx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+	if err := b.Close(closeCtx); err != nil {
+		t.Fatalf("failed to close bus: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, []string{"w1"}) {
+		t.Errorf("expected [w1] delivered, got %v", got)
+	}
+
+	// Publishing after Close drops async deliveries instead of panicking.
+	b.Publish(context.Background(), event.WeightDeleted{Base: event.NewBase("giada"), WeightID: "w2"})
+}
+
+func TestTyped_IgnoresOtherEvents(t *testing.T) {
+	called := false
+	h := Typed(func(ctx context.Context, e event.GoalAchieved) error {
+		called = true
+		return nil
+	})
+
+	if err := h(context.Background(), event.GoalSet{}); err != nil || called {
+		t.Errorf("expected GoalSet to be ignored, called=%v err=%v", called, err)
+	}
+	if err := h(context.Background(), event.GoalAchieved{}); err != nil || !called {
+		t.Errorf("expected GoalAchieved to be handled, called=%v err=%v", called, err)
+	}
+}
@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Overall readiness reported by Check.
+const (
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc reports why a dependency is unusable, or nil when it is fine.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one CheckFunc.
+type CheckResult struct {
+	OK         bool    `json:"ok"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report is the outcome of every registered check.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready reports whether traffic should be sent to this instance.
+func (r Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+type namedCheck struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker runs readiness checks and tracks whether the process is shutting
+// down.
+type Checker struct {
+	timeout time.Duration
+	started time.Time
+
+	mu       sync.Mutex
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// NewChecker returns a Checker that gives each round of checks at most
+// timeout to complete.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, started: time.Now()}
+}
+
+// Register adds a check run by every call to Check.
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name, fn})
+}
+
+// Drain marks the process as shutting down, so Check reports it as not
+// ready regardless of its dependencies.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Uptime is how long ago the Checker was created.
+func (c *Checker) Uptime() time.Duration {
+	return time.Since(c.started)
+}
+
+// Check runs every registered check concurrently.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.Unlock()
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check.fn(ctx)
+			elapsed := time.Since(start)
+			results[i] = CheckResult{OK: err == nil, DurationMS: float64(elapsed.Microseconds()) / 1000}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: make(map[string]CheckResult, len(checks))}
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+		if !results[i].OK {
+			report.Status = StatusNotReady
+		}
+	}
+	if c.draining.Load() {
+		report.Status = StatusShuttingDown
+	}
+	return report
+}
+
+// DirWritable checks that a file can be created in dir, e.g. to catch a
+// data volume mounted read-only or out of space.
+func DirWritable(dir string) CheckFunc {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".peso-ready-*")
+		if err != nil {
+			return fmt.Errorf("directory not writable: %w", err)
+		}
+		name := f.Name()
+		_, werr := f.Write([]byte("ok"))
+		cerr := f.Close()
+		os.Remove(name)
+		if werr != nil {
+			return fmt.Errorf("directory not writable: %w", werr)
+		}
+		if cerr != nil {
+			return fmt.Errorf("directory not writable: %w", cerr)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChecker_Check(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("database is locked") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		checks     map[string]CheckFunc
+		drain      bool
+		wantStatus string
+		wantFailed string
+	}{
+		{name: "all passing", checks: map[string]CheckFunc{"database": ok, "data_dir": ok}, wantStatus: StatusReady},
+		{name: "no checks", wantStatus: StatusReady},
+		{name: "one failing", checks: map[string]CheckFunc{"database": failing, "data_dir": ok}, wantStatus: StatusNotReady, wantFailed: "database"},
+		{name: "timed out", checks: map[string]CheckFunc{"database": slow}, wantStatus: StatusNotReady, wantFailed: "database"},
+		{name: "draining", checks: map[string]CheckFunc{"database": ok}, drain: true, wantStatus: StatusShuttingDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(10 * time.Millisecond)
+			for name, fn := range tt.checks {
+				c.Register(name, fn)
+			}
+			if tt.drain {
+				c.Drain()
+			}
+
+			report := c.Check(context.Background())
+			if report.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, report.Status)
+			}
+			if report.Ready() != (tt.wantStatus == StatusReady) {
+				t.Errorf("expected Ready() to match status %s", report.Status)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("expected %d check results, got %d", len(tt.checks), len(report.Checks))
+			}
+			for name, result := range report.Checks {
+				if failed := name == tt.wantFailed; result.OK == failed {
+					t.Errorf("expected check %s ok=%v, got %+v", name, !failed, result)
+				}
+				if !result.OK && result.Error == "" {
+					t.Errorf("expected error message for failed check %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestDirWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := DirWritable(dir)(context.Background()); err != nil {
+		t.Errorf("expected writable directory, got %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected probe file to be removed, found %d entries", len(entries))
+	}
+
+	if err := DirWritable(filepath.Join(dir, "missing"))(context.Background()); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
@@ -0,0 +1,212 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is a language the interface is translated into, named by its
+// two-letter ISO 639-1 code.
+type Locale string
+
+const (
+	Italian Locale = "it"
+	English Locale = "en"
+)
+
+// Default is used when neither the user nor the browser asks for a
+// supported language.
+const Default = Italian
+
+// Supported lists the locales with a catalog, in the order they are offered
+// to users.
+var Supported = []Locale{Italian, English}
+
+// format holds the conventions for writing numbers and dates in a locale.
+type format struct {
+	decimal   string
+	thousands string
+	date      string
+	shortDate string
+	clock     string
+}
+
+var formats = map[Locale]format{
+	Italian: {decimal: ",", thousands: ".", date: "02/01/2006", shortDate: "02/01", clock: "15:04"},
+	English: {decimal: ".", thousands: ",", date: "01/02/2006", shortDate: "01/02", clock: "3:04 PM"},
+}
+
+//go:embed locales/*.json
+var catalogFS embed.FS
+
+// catalogs maps each locale to its messages, keyed by message ID.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[Locale]map[string]string {
+	out := make(map[Locale]map[string]string, len(Supported))
+	for _, l := range Supported {
+		data, err := catalogFS.ReadFile("locales/" + string(l) + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %q: %v", l, err))
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %q: %v", l, err))
+		}
+		out[l] = messages
+	}
+	return out
+}
+
+// Parse returns the supported locale for a language tag such as "en" or
+// "en-GB".
+func Parse(tag string) (Locale, bool) {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	for _, l := range Supported {
+		if string(l) == lang {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Match picks the supported locale the client prefers from an
+// Accept-Language header, or fallback when it accepts none of them.
+func Match(acceptLanguage string, fallback Locale) Locale {
+	type candidate struct {
+		locale Locale
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		l, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{locale: l, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return fallback
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// T translates the message with the given ID, formatting args into it like
+// fmt.Sprintf. Messages missing from the locale's catalog fall back to the
+// default locale, then to the ID itself.
+func (l Locale) T(key string, args ...any) string {
+	msg, ok := catalogs[l][key]
+	if !ok {
+		msg, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Has reports whether the locale's catalog defines key.
+func (l Locale) Has(key string) bool {
+	_, ok := catalogs[l][key]
+	return ok
+}
+
+// Name is the locale's name in its own language, as shown in a language
+// picker.
+func (l Locale) Name() string {
+	return l.T("locale.name")
+}
+
+// Number formats v with the given number of decimals, e.g. 1.234,5 in
+// Italian and 1,234.5 in English.
+func (l Locale) Number(v float64, decimals int) string {
+	f := l.format()
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.thousands)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(f.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// Date formats the day of t, e.g. 31/12/2026 in Italian and 12/31/2026 in
+// English.
+func (l Locale) Date(t time.Time) string {
+	return t.Format(l.format().date)
+}
+
+// ShortDate formats the day of t without the year.
+func (l Locale) ShortDate(t time.Time) string {
+	return t.Format(l.format().shortDate)
+}
+
+// Time formats the time of day of t.
+func (l Locale) Time(t time.Time) string {
+	return t.Format(l.format().clock)
+}
+
+// DateTime formats the day and time of t.
+func (l Locale) DateTime(t time.Time) string {
+	return l.Date(t) + " " + l.Time(t)
+}
+
+func (l Locale) format() format {
+	if f, ok := formats[l]; ok {
+		return f
+	}
+	return formats[Default]
+}
+
+type ctxKey string
+
+const localeCtxKey ctxKey = "locale"
+
+// WithLocale returns a context carrying l.
+func WithLocale(ctx context.Context, l Locale) context.Context {
+	return context.WithValue(ctx, localeCtxKey, l)
+}
+
+// FromContext returns the locale chosen for the current request, or
+// Default.
+func FromContext(ctx context.Context) Locale {
+	if l, ok := ctx.Value(localeCtxKey).(Locale); ok {
+		return l
+	}
+	return Default
+}
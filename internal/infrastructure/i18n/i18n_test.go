@@ -0,0 +1,154 @@
+package i18n
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	assets "peso"
+)
+
+func TestCatalogs_SameKeys(t *testing.T) {
+	for _, l := range Supported {
+		for key := range catalogs[Default] {
+			if !l.Has(key) {
+				t.Errorf("%s catalog is missing %q", l, key)
+			}
+		}
+		for key := range catalogs[l] {
+			if !Default.Has(key) {
+				t.Errorf("%s catalog has %q, which %s lacks", l, key, Default)
+			}
+		}
+	}
+}
+
+// TestCatalogs_CoverUsedKeys checks that every message the templates and
+// handlers ask for is in the catalogs
+func TestCatalogs_CoverUsedKeys(t *testing.T) {
+	templateKey := regexp.MustCompile(`\bt "([a-z0-9_.]+)"`)
+	goKey := regexp.MustCompile(`"([a-z]+(?:\.[a-z0-9_]+)+)"`)
+
+	sources := map[string][]byte{}
+	templates, err := fs.Glob(assets.FS, "templates/*.html")
+	if err != nil {
+		t.Fatalf("failed to list templates: %v", err)
+	}
+	for _, name := range templates {
+		data, err := fs.ReadFile(assets.FS, name)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		sources[name] = data
+	}
+	handlers, err := filepath.Glob("../web/*.go")
+	if err != nil || len(handlers) == 0 {
+		t.Fatalf("failed to list handlers: %v", err)
+	}
+	for _, name := range handlers {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		sources[name] = data
+	}
+
+	for name, data := range sources {
+		pattern := templateKey
+		if filepath.Ext(name) == ".go" {
+			pattern = goKey
+		}
+		for _, match := range pattern.FindAllSubmatch(data, -1) {
+			key := string(match[1])
+			// Template names look like keys too
+			if strings.HasSuffix(key, ".html") {
+				continue
+			}
+			if !Default.Has(key) {
+				t.Errorf("%s uses %q, which is not in the catalogs", name, key)
+			}
+		}
+	}
+}
+
+func TestLocale_T(t *testing.T) {
+	if got := English.T("webhooks.attempts_many", 3); got != "3 attempts" {
+		t.Errorf("expected %q, got %q", "3 attempts", got)
+	}
+	if got := Italian.T("webhooks.attempts_many", 3); got != "3 tentativi" {
+		t.Errorf("expected %q, got %q", "3 tentativi", got)
+	}
+	if got := English.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("expected the key back, got %q", got)
+	}
+	if got := Locale("fr").T("goal.heading"); got != Default.T("goal.heading") {
+		t.Errorf("expected the default language, got %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Locale
+	}{
+		{header: "", want: Italian},
+		{header: "en", want: English},
+		{header: "en-GB,en;q=0.9", want: English},
+		{header: "de-DE,de;q=0.9,en;q=0.8", want: English},
+		{header: "it;q=0.5,en;q=0.8", want: English},
+		{header: "en;q=0,it", want: Italian},
+		{header: "fr,de", want: Italian},
+		{header: "*", want: Italian},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := Match(tt.header, Italian); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLocale_Number(t *testing.T) {
+	tests := []struct {
+		locale   Locale
+		value    float64
+		decimals int
+		want     string
+	}{
+		{Italian, 72.45, 1, "72,5"},
+		{English, 72.45, 1, "72.5"},
+		{Italian, 1234.5, 1, "1.234,5"},
+		{English, 1234.5, 1, "1,234.5"},
+		{Italian, -0.4, 1, "-0,4"},
+		{English, 1234567, 0, "1,234,567"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.locale.Number(tt.value, tt.decimals); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.locale, tt.want, got)
+		}
+	}
+}
+
+func TestLocale_Dates(t *testing.T) {
+	at := time.Date(2026, time.March, 7, 18, 5, 0, 0, time.UTC)
+
+	if got := Italian.Date(at); got != "07/03/2026" {
+		t.Errorf("expected 07/03/2026, got %q", got)
+	}
+	if got := English.Date(at); got != "03/07/2026" {
+		t.Errorf("expected 03/07/2026, got %q", got)
+	}
+	if got := Italian.DateTime(at); got != "07/03/2026 18:05" {
+		t.Errorf("expected 07/03/2026 18:05, got %q", got)
+	}
+	if got := English.Time(at); got != "6:05 PM" {
+		t.Errorf("expected 6:05 PM, got %q", got)
+	}
+}
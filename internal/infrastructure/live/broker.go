@@ -0,0 +1,239 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"peso/internal/domain/event"
+	"peso/internal/domain/user"
+)
+
+// Client-side event names. They match the HTMX triggers the dashboard
+// partials refresh on.
+const (
+	TopicWeight  = "weight-updated"
+	TopicGoal    = "goal-updated"
+	TopicRefresh = "refresh"
+)
+
+const (
+	// historySize is how many messages are kept per user for replay.
+	historySize = 50
+	// subscriberBuffer is how many messages a slow client may fall behind
+	// before it is disconnected. It reconnects and catches up by replay.
+	subscriberBuffer = 16
+)
+
+// Message is one server-sent event.
+type Message struct {
+	ID    uint64
+	Topic string
+	Data  []byte
+}
+
+// Topic maps a domain event to the client event it refreshes, or "" for
+// events the dashboard does not show.
+func Topic(name string) string {
+	switch {
+	case strings.HasPrefix(name, "weight."):
+		return TopicWeight
+	case strings.HasPrefix(name, "goal."):
+		return TopicGoal
+	default:
+		return ""
+	}
+}
+
+type history struct {
+	messages []Message
+	// evicted is the ID of the newest message dropped from messages, so
+	// clients that last saw an older one know they missed something.
+	evicted uint64
+}
+
+// Broker fans out domain events to the pages each user has open, keeping a
+// short per-user history so reconnecting clients can replay what they
+// missed. Message IDs start from the boot time, so IDs issued by a previous
+// process are always older than this one's history.
+type Broker struct {
+	mu          sync.Mutex
+	firstID     uint64
+	nextID      uint64
+	histories   map[user.UserID]*history
+	subscribers map[user.UserID]map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBroker creates an empty broker.
+func NewBroker() *Broker {
+	start := uint64(time.Now().UnixMicro())
+	return &Broker{
+		firstID:     start,
+		nextID:      start,
+		histories:   make(map[user.UserID]*history),
+		subscribers: make(map[user.UserID]map[*Subscription]struct{}),
+	}
+}
+
+// OnEvent records e for its user and forwards it to their open streams. It
+// never blocks: subscribers that cannot keep up are disconnected.
+func (b *Broker) OnEvent(ctx context.Context, e event.Event) error {
+	topic := Topic(e.Name())
+	if topic == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(struct {
+		Type string      `json:"type"`
+		Data event.Event `json:"data"`
+	}{Type: e.Name(), Data: e})
+	if err != nil {
+		return fmt.Errorf("failed to encode live event: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+
+	b.nextID++
+	msg := Message{ID: b.nextID, Topic: topic, Data: data}
+
+	h := b.histories[e.Subject()]
+	if h == nil {
+		h = &history{}
+		b.histories[e.Subject()] = h
+	}
+	h.messages = append(h.messages, msg)
+	if len(h.messages) > historySize {
+		h.evicted = h.messages[0].ID
+		h.messages = append(h.messages[:0:0], h.messages[1:]...)
+	}
+
+	for sub := range b.subscribers[e.Subject()] {
+		select {
+		case sub.messages <- msg:
+		default:
+			b.remove(e.Subject(), sub)
+		}
+	}
+	return nil
+}
+
+// Subscription is one open stream.
+type Subscription struct {
+	// Replay holds the messages published after the client's last event
+	// ID, to be sent before anything from Messages. When some of them are
+	// no longer available it holds a single TopicRefresh message instead.
+	Replay []Message
+	// LastID is the newest message ID when the subscription opened; new
+	// clients start from it.
+	LastID uint64
+
+	messages chan Message
+	broker   *Broker
+	userID   user.UserID
+}
+
+// Messages delivers new messages. It is closed when the client falls too
+// far behind or the broker shuts down.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s.userID, s)
+}
+
+// Subscribe opens a stream for userID. lastEventID is the client's
+// Last-Event-ID header, empty on first connection.
+func (b *Broker) Subscribe(userID user.UserID, lastEventID string) *Subscription {
+	sub := &Subscription{
+		messages: make(chan Message, subscriberBuffer),
+		broker:   b,
+		userID:   userID,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub.LastID = b.nextID
+	if lastEventID != "" {
+		replay, ok := b.since(userID, lastEventID)
+		if !ok {
+			replay = []Message{{ID: b.nextID, Topic: TopicRefresh, Data: []byte("{}")}}
+		}
+		sub.Replay = replay
+	}
+
+	if b.closed {
+		close(sub.messages)
+		return sub
+	}
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+	return sub
+}
+
+// since returns the user's messages newer than lastEventID, or false if
+// some of them are no longer available.
+func (b *Broker) since(userID user.UserID, lastEventID string) ([]Message, bool) {
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil || last < b.firstID || last > b.nextID {
+		return nil, false
+	}
+
+	h := b.histories[userID]
+	if h == nil {
+		return nil, true
+	}
+	if last < h.evicted {
+		return nil, false
+	}
+
+	var replay []Message
+	for _, msg := range h.messages {
+		if msg.ID > last {
+			replay = append(replay, msg)
+		}
+	}
+	return replay, true
+}
+
+// remove drops sub and closes its channel. b.mu must be held.
+func (b *Broker) remove(userID user.UserID, sub *Subscription) {
+	subs := b.subscribers[userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, userID)
+	}
+	close(sub.messages)
+}
+
+// Close ends every open stream and refuses new ones, so the HTTP server can
+// shut down without waiting for clients to disconnect.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for userID, subs := range b.subscribers {
+		for sub := range subs {
+			b.remove(userID, sub)
+		}
+	}
+}
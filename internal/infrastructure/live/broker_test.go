@@ -0,0 +1,145 @@
+package live
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"peso/internal/domain/event"
+	"peso/internal/domain/user"
+)
+
+func publish(t *testing.T, b *Broker, e event.Event) {
+	t.Helper()
+	if err := b.OnEvent(context.Background(), e); err != nil {
+		t.Fatalf("OnEvent failed: %v", err)
+	}
+}
+
+func topics(messages []Message) []string {
+	var got []string
+	for _, msg := range messages {
+		got = append(got, msg.Topic)
+	}
+	return got
+}
+
+func TestBroker_DeliversToSubjectOnly(t *testing.T) {
+	b := NewBroker()
+	giada := b.Subscribe("giada", "")
+	defer giada.Close()
+	marco := b.Subscribe("marco", "")
+	defer marco.Close()
+
+	publish(t, b, event.WeightRecorded{Base: event.NewBase("giada"), WeightID: "w1"})
+	publish(t, b, event.UserRegistered{Base: event.NewBase("giada")})
+	publish(t, b, event.GoalSet{Base: event.NewBase("giada"), GoalID: "g1"})
+
+	var got []string
+	for range 2 {
+		got = append(got, (<-giada.Messages()).Topic)
+	}
+	if got[0] != TopicWeight || got[1] != TopicGoal {
+		t.Errorf("expected [%s %s], got %v", TopicWeight, TopicGoal, got)
+	}
+	if n := len(giada.Messages()); n != 0 {
+		t.Errorf("expected user.registered to be skipped, got %d more messages", n)
+	}
+	if n := len(marco.Messages()); n != 0 {
+		t.Errorf("expected no messages for another user, got %d", n)
+	}
+}
+
+func TestBroker_Replay(t *testing.T) {
+	b := NewBroker()
+	first := b.Subscribe("giada", "")
+	first.Close()
+
+	publish(t, b, event.WeightRecorded{Base: event.NewBase("giada"), WeightID: "w1"})
+	publish(t, b, event.WeightRecorded{Base: event.NewBase("marco"), WeightID: "w2"})
+	publish(t, b, event.GoalDeleted{Base: event.NewBase("giada"), GoalID: "g1"})
+
+	lastSeen := strconv.FormatUint(first.LastID, 10)
+
+	tests := []struct {
+		name        string
+		userID      user.UserID
+		lastEventID string
+		want        []string
+	}{
+		{name: "first connection", userID: "giada", lastEventID: "", want: nil},
+		{name: "missed events", userID: "giada", lastEventID: lastSeen, want: []string{TopicWeight, TopicGoal}},
+		{name: "up to date", userID: "giada", lastEventID: strconv.FormatUint(first.LastID+3, 10), want: nil},
+		{name: "no events for user", userID: "luca", lastEventID: lastSeen, want: nil},
+		{name: "previous process", userID: "giada", lastEventID: "42", want: []string{TopicRefresh}},
+		{name: "unknown id", userID: "giada", lastEventID: "x", want: []string{TopicRefresh}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := b.Subscribe(tt.userID, tt.lastEventID)
+			defer sub.Close()
+
+			got := topics(sub.Replay)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected replay %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected replay %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBroker_ReplayAfterEviction(t *testing.T) {
+	b := NewBroker()
+	sub := b.Subscribe("giada", "")
+	sub.Close()
+
+	for range historySize + 1 {
+		publish(t, b, event.WeightRecorded{Base: event.NewBase("giada")})
+	}
+
+	stale := b.Subscribe("giada", strconv.FormatUint(sub.LastID, 10))
+	defer stale.Close()
+	if got := topics(stale.Replay); len(got) != 1 || got[0] != TopicRefresh {
+		t.Errorf("expected a refresh after eviction, got %v", got)
+	}
+
+	recent := b.Subscribe("giada", strconv.FormatUint(sub.LastID+1, 10))
+	defer recent.Close()
+	if n := len(recent.Replay); n != historySize {
+		t.Errorf("expected %d replayed messages, got %d", historySize, n)
+	}
+}
+
+func TestBroker_DisconnectsSlowAndClosed(t *testing.T) {
+	b := NewBroker()
+	slow := b.Subscribe("giada", "")
+	defer slow.Close()
+
+	for range subscriberBuffer + 1 {
+		publish(t, b, event.WeightDeleted{Base: event.NewBase("giada")})
+	}
+	for range subscriberBuffer {
+		<-slow.Messages()
+	}
+	if _, ok := <-slow.Messages(); ok {
+		t.Error("expected a slow subscriber to be disconnected")
+	}
+
+	open := b.Subscribe("giada", "")
+	defer open.Close()
+	b.Close()
+	if _, ok := <-open.Messages(); ok {
+		t.Error("expected Close to end open streams")
+	}
+
+	late := b.Subscribe("giada", "")
+	defer late.Close()
+	if _, ok := <-late.Messages(); ok {
+		t.Error("expected subscriptions after Close to be closed")
+	}
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+)
+
+type ctxKey string
+
+const (
+	requestIDKey ctxKey = "request_id"
+	scopeKey     ctxKey = "scope"
+)
+
+// scope holds request details that are only known deep in the handler
+// chain but should appear on every log line of the request, including the
+// ones written by middleware further out. It is filled in before the
+// handler runs and only read afterwards.
+type scope struct {
+	route  string
+	userID string
+}
+
+// WithRequestID stores request id into context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext extracts request id from context
+func RequestIDFromContext(ctx context.Context) string {
+	if v := ctx.Value(requestIDKey); v != nil {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// WithScope prepares ctx to carry the route recorded by Route and the user
+// set by SetUserID. RequestID does this for every request.
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey, &scope{})
+}
+
+func scopeFromContext(ctx context.Context) *scope {
+	s, _ := ctx.Value(scopeKey).(*scope)
+	return s
+}
+
+// RouteFromContext returns the pattern of the ServeMux route handling the
+// request, e.g. "GET /users/{userID}", or "" before one matched.
+func RouteFromContext(ctx context.Context) string {
+	if s := scopeFromContext(ctx); s != nil {
+		return s.route
+	}
+	return ""
+}
+
+// SetUserID records the authenticated user of the request for logging.
+func SetUserID(ctx context.Context, userID string) {
+	if s := scopeFromContext(ctx); s != nil {
+		s.userID = userID
+	}
+}
+
+// UserIDFromContext returns the user recorded by SetUserID, or "" for
+// anonymous requests.
+func UserIDFromContext(ctx context.Context) string {
+	if s := scopeFromContext(ctx); s != nil {
+		return s.userID
+	}
+	return ""
+}
+
+// Route records the pattern mux matches before dispatching to it, so code
+// further down and middleware further out can both see it. With nested
+// muxes the innermost match wins.
+func Route(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s := scopeFromContext(r.Context()); s != nil {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				s.route = pattern
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
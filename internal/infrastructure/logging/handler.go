@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextHandler adds the request ID, user ID, route and trace of the
+// context passed to the *Context logging methods to every record, so code
+// that logs with a request context needs no correlation fields of its own.
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps h with request correlation.
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: h}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if s := scopeFromContext(ctx); s != nil {
+		if s.userID != "" {
+			r.AddAttrs(slog.String("user_id", s.userID))
+		}
+		if s.route != "" {
+			r.AddAttrs(slog.String("route", s.route))
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -1,7 +1,6 @@
 package logging
 
 import (
-	"context"
 	"log/slog"
 	"net/http"
 	"os"
@@ -23,54 +22,29 @@ func NewLogger(level string) *slog.Logger {
 		lvl = slog.LevelInfo
 	}
 	h := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: lvl})
-	return slog.New(h)
+	return slog.New(NewContextHandler(h))
 }
 
-type ctxKey string
-
-const requestIDKey ctxKey = "request_id"
-
-// WithRequestID stores request id into context
-func WithRequestID(ctx context.Context, id string) context.Context {
-	return context.WithValue(ctx, requestIDKey, id)
-}
-
-// RequestIDFromContext extracts request id from context
-func RequestIDFromContext(ctx context.Context) string {
-	if v := ctx.Value(requestIDKey); v != nil {
-		if s, ok := v.(string); ok {
-			return s
-		}
-	}
-	return ""
-}
-
-// Middleware: attaches a request id to context and response header
-func RequestID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		id := r.Header.Get("X-Request-ID")
-		if id == "" {
-			// simple unique id: epoch millis + random suffix from time
-			id = time.Now().Format("20060102T150405.000000000")
-		}
-		w.Header().Set("X-Request-ID", id)
-		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
-	})
-}
-
-// responseWriter wrapper to capture status/bytes
-type rwCapture struct {
+// StatusRecorder wraps a ResponseWriter to remember the status code and
+// the number of body bytes sent through it.
+type StatusRecorder struct {
 	http.ResponseWriter
 	status int
 	size   int
 }
 
-func (w *rwCapture) WriteHeader(code int) {
-	w.status = code
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w}
+}
+
+func (w *StatusRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
 	w.ResponseWriter.WriteHeader(code)
 }
 
-func (w *rwCapture) Write(b []byte) (int, error) {
+func (w *StatusRecorder) Write(b []byte) (int, error) {
 	if w.status == 0 {
 		w.status = http.StatusOK
 	}
@@ -79,22 +53,41 @@ func (w *rwCapture) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (w *StatusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status code sent, http.StatusOK when the handler
+// never set one.
+func (w *StatusRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns how many body bytes were written.
+func (w *StatusRecorder) Size() int {
+	return w.size
+}
+
 // Middleware: logs requests in structured form
 func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
-			rw := &rwCapture{ResponseWriter: w}
+			rw := NewStatusRecorder(w)
 
 			next.ServeHTTP(rw, r)
 
-			logger.Info("http_request",
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "http_request",
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
-				slog.Int("status", statusOf(rw.status)),
-				slog.Int("size", rw.size),
+				slog.Int("status", rw.Status()),
+				slog.Int("size", rw.Size()),
 				slog.String("remote", r.RemoteAddr),
-				slog.String("request_id", RequestIDFromContext(r.Context())),
 				slog.Duration("duration", time.Since(start)),
 			)
 		})
@@ -107,7 +100,7 @@ func Recoverer(logger *slog.Logger) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if rec := recover(); rec != nil {
-					logger.Error("panic_recovered", slog.Any("error", rec), slog.String("request_id", RequestIDFromContext(r.Context())))
+					logger.ErrorContext(r.Context(), "panic_recovered", slog.Any("error", rec))
 					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 				}
 			}()
@@ -115,10 +108,3 @@ func Recoverer(logger *slog.Logger) func(http.Handler) http.Handler {
 		})
 	}
 }
-
-func statusOf(s int) int {
-	if s == 0 {
-		return http.StatusOK
-	}
-	return s
-}
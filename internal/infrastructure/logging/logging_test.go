@@ -0,0 +1,186 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name             string
+		requestID        string
+		traceparent      string
+		trustTraceparent bool
+		want             string
+	}{
+		{name: "client ID kept", requestID: "req-123", want: "req-123"},
+		{name: "client ID wins over traceparent", requestID: "req-123", traceparent: traceparent, trustTraceparent: true, want: "req-123"},
+		{name: "trusted traceparent", traceparent: traceparent, trustTraceparent: true, want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "untrusted traceparent", traceparent: traceparent},
+		{name: "malformed traceparent", traceparent: "00-xyz", trustTraceparent: true},
+		{name: "unsafe client ID", requestID: "evil\" injected=\"1"},
+		{name: "oversized client ID", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "no headers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(tt.trustTraceparent)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Header().Get("X-Request-ID") != seen {
+				t.Errorf("expected response header %q to match context %q", rec.Header().Get("X-Request-ID"), seen)
+			}
+			if tt.want != "" {
+				if seen != tt.want {
+					t.Errorf("expected request ID %q, got %q", tt.want, seen)
+				}
+				return
+			}
+			if !validRequestID(seen) || seen == tt.requestID || len(seen) != 36 {
+				t.Errorf("expected a generated UUID, got %q", seen)
+			}
+		})
+	}
+}
+
+func TestNewRequestID_Unique(t *testing.T) {
+	const workers, perWorker = 8, 1000
+
+	var mu sync.Mutex
+	seen := make(map[string]bool, workers*perWorker)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]string, perWorker)
+			for i := range ids {
+				ids[i] = NewRequestID()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range ids {
+				if seen[id] {
+					t.Errorf("duplicate request ID %s", id)
+				}
+				seen[id] = true
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTraceIDFromTraceparent(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{header: traceparent, want: "4bf92f3577b34da6a3ce929d0e0e4736", ok: true},
+		{header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", want: "4bf92f3577b34da6a3ce929d0e0e4736", ok: true},
+		{header: traceparent + "-extra"},
+		{header: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{header: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{header: "00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01"},
+		{header: ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := TraceIDFromTraceparent(tt.header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("TraceIDFromTraceparent(%q): expected (%q, %v), got (%q, %v)", tt.header, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("component", "test"))
+
+	inner := http.NewServeMux()
+	inner.HandleFunc("GET /users/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		SetUserID(r.Context(), r.PathValue("userID"))
+		logger.InfoContext(r.Context(), "inside")
+	})
+	root := http.NewServeMux()
+	root.Handle("/users/", Route(inner))
+	handler := RequestID(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Route(root).ServeHTTP(w, r)
+		// Middleware further out sees what the handler recorded.
+		logger.InfoContext(r.Context(), "outside")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/giada", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to parse log line %q: %v", line, err)
+		}
+		for key, want := range map[string]string{
+			"request_id": "req-123",
+			"user_id":    "giada",
+			"route":      "GET /users/{userID}",
+			"component":  "test",
+		} {
+			if entry[key] != want {
+				t.Errorf("%s: expected %s=%q, got %v", entry["msg"], key, want, entry[key])
+			}
+		}
+	}
+}
+
+func TestContextHandler_AddsTraceIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	logger.InfoContext(ctx, "traced")
+	logger.Info("untraced")
+
+	out := buf.String()
+	if !strings.Contains(out, `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7"`) {
+		t.Errorf("expected trace and span IDs in %q", out)
+	}
+	if strings.Count(out, "trace_id") != 1 {
+		t.Errorf("expected only the traced line to carry trace_id, got %q", out)
+	}
+}
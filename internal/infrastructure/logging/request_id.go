@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength bounds client-supplied request IDs.
+const maxRequestIDLength = 128
+
+// RequestID attaches a request ID and a logging scope to the context and
+// echoes the ID in the X-Request-ID response header. A well-formed
+// X-Request-ID from the client is kept. Otherwise, when trustTraceparent is
+// set, the trace ID of a W3C traceparent header is used so log lines match
+// the caller's trace; failing both a time-ordered UUIDv7 is generated.
+func RequestID(trustTraceparent bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get("X-Request-ID")
+			if !validRequestID(id) {
+				id = ""
+				if trustTraceparent {
+					id, _ = TraceIDFromTraceparent(r.Header.Get("traceparent"))
+				}
+				if id == "" {
+					id = NewRequestID()
+				}
+			}
+			w.Header().Set("X-Request-ID", id)
+			next.ServeHTTP(w, r.WithContext(WithScope(WithRequestID(r.Context(), id))))
+		})
+	}
+}
+
+// NewRequestID returns a random, time-ordered ID that stays unique across
+// concurrent requests and processes.
+func NewRequestID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// validRequestID accepts IDs that are safe to log and echo: printable
+// ASCII without spaces or quotes, up to maxRequestIDLength.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		isAlnum := c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if !isAlnum && c != '-' && c != '_' && c != '.' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceIDFromTraceparent extracts the trace ID from a W3C traceparent
+// header value ("00-<32 hex trace id>-<16 hex parent id>-<2 hex flags>").
+func TraceIDFromTraceparent(header string) (string, bool) {
+	if len(header) < 55 || (len(header) > 55 && header[55] != '-') {
+		return "", false
+	}
+	version, traceID, parentID, flags := header[0:2], header[3:35], header[36:52], header[53:55]
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return "", false
+	}
+	// Version ff is forbidden; later versions may append fields, which the
+	// length check above allows.
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(header) != 55) {
+		return "", false
+	}
+	if !isLowerHex(traceID) || !isLowerHex(parentID) || !isLowerHex(flags) {
+		return "", false
+	}
+	if traceID == "00000000000000000000000000000000" || parentID == "0000000000000000" {
+		return "", false
+	}
+	return traceID, true
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
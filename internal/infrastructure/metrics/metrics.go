@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"peso/internal/domain/event"
+	"peso/internal/infrastructure/logging"
+)
+
+// Metrics are the application instruments exposed on /metrics.
+type Metrics struct {
+	registry *Registry
+
+	requests *CounterVec
+	latency  *HistogramVec
+	inFlight *Gauge
+	queries  *HistogramVec
+	weights  *Counter
+	logins   *CounterVec
+}
+
+// Login outcomes counted by RecordLogin.
+const (
+	LoginSucceeded = "success"
+	LoginFailed    = "failure"
+)
+
+// New registers the application instruments together with the Go runtime
+// statistics.
+func New() *Metrics {
+	r := NewRegistry()
+	RegisterRuntime(r)
+	return &Metrics{
+		registry: r,
+		requests: r.NewCounterVec("peso_http_requests_total",
+			"HTTP requests served, by method, route pattern and status code.", "method", "route", "status"),
+		latency: r.NewHistogramVec("peso_http_request_duration_seconds",
+			"HTTP request latency, by method and route pattern.", DefaultBuckets, "method", "route"),
+		inFlight: r.NewGauge("peso_http_requests_in_flight",
+			"HTTP requests currently being served."),
+		queries: r.NewHistogramVec("peso_db_query_duration_seconds",
+			"Database statement latency, by repository method.", DefaultBuckets, "method"),
+		weights: r.NewCounter("peso_weights_recorded_total",
+			"Weights recorded since startup."),
+		logins: r.NewCounterVec("peso_logins_total",
+			"Login attempts, by result.", "result"),
+	}
+}
+
+// Registry exposes the underlying registry for instruments owned elsewhere.
+func (m *Metrics) Registry() *Registry {
+	return m.registry
+}
+
+// ObserveQuery records how long a statement issued by method took.
+func (m *Metrics) ObserveQuery(method string, d time.Duration) {
+	m.queries.With(method).Observe(d.Seconds())
+}
+
+// RecordLogin counts a login attempt with result LoginSucceeded or
+// LoginFailed.
+func (m *Metrics) RecordLogin(result string) {
+	m.logins.With(result).Inc()
+}
+
+// OnEvent is an event bus subscriber counting domain events of interest.
+func (m *Metrics) OnEvent(ctx context.Context, e event.Event) error {
+	if e.Name() == event.NameWeightRecorded {
+		m.weights.Inc()
+	}
+	return nil
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (m *Metrics) Handler(logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := m.registry.Write(r.Context(), w); err != nil {
+			logger.WarnContext(r.Context(), "metrics_collect_failed", slog.Any("error", err))
+		}
+	})
+}
+
+// Middleware counts and times every request under the route pattern that
+// served it, as recorded by logging.Route. Requests no mux matched are
+// labelled "unmatched", and non-standard methods "other".
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		rw := logging.NewStatusRecorder(w)
+		next.ServeHTTP(rw, r)
+
+		pattern := logging.RouteFromContext(r.Context())
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+		status := rw.Status()
+		method := methodLabel(r.Method)
+		m.requests.With(method, pattern, strconv.Itoa(status)).Inc()
+		m.latency.With(method, pattern).Observe(time.Since(start).Seconds())
+	})
+}
+
+// methodLabel keeps clients from creating a series per made-up method.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
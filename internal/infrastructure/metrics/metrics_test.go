@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"peso/internal/domain/event"
+	"peso/internal/domain/user"
+	"peso/internal/infrastructure/logging"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Requests.\nBy path.", "path")
+	requests.With("/b").Add(2)
+	requests.With(`/a"x`).Inc()
+	r.NewGauge("test_temperature", "Temperature.").Set(-1.5)
+	r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}).With().Observe(0.5)
+	r.NewGaugeFunc("test_broken", "Always fails.", func(context.Context) (float64, error) {
+		return 0, errors.New("boom")
+	})
+
+	var buf bytes.Buffer
+	err := r.Write(context.Background(), &buf)
+	if err == nil || !strings.Contains(err.Error(), "test_broken") {
+		t.Errorf("expected error naming test_broken, got %v", err)
+	}
+
+	want := `# HELP test_requests_total Requests.\nBy path.
+# TYPE test_requests_total counter
+test_requests_total{path="/a\"x"} 1
+test_requests_total{path="/b"} 2
+# HELP test_temperature Temperature.
+# TYPE test_temperature gauge
+test_temperature -1.5
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{le="0.1"} 0
+test_latency_seconds_bucket{le="1"} 1
+test_latency_seconds_bucket{le="+Inf"} 1
+test_latency_seconds_sum 0.5
+test_latency_seconds_count 1
+`
+	if buf.String() != want {
+		t.Errorf("expected output:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestRegistry_RejectsDuplicateNames(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate registration")
+		}
+	}()
+	r.NewGauge("test_total", "Test.")
+}
+
+func TestMetrics_Middleware(t *testing.T) {
+	m := New()
+
+	inner := http.NewServeMux()
+	inner.HandleFunc("GET /users/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	root := http.NewServeMux()
+	root.Handle("/users/", logging.Route(inner))
+	handler := logging.RequestID(false)(m.Middleware(logging.Route(root)))
+
+	for _, path := range []string{"/users/giada", "/users/luca"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	for _, method := range []string{"FROB", "X-RANDOM-1"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/nowhere", nil))
+	}
+
+	tests := []struct {
+		method string
+		route  string
+		status string
+		want   float64
+	}{
+		{method: http.MethodGet, route: "GET /users/{userID}", status: "418", want: 2},
+		{method: http.MethodGet, route: "unmatched", status: "404", want: 1},
+		{method: "other", route: "unmatched", status: "404", want: 2},
+		{method: "FROB", route: "unmatched", status: "404", want: 0},
+	}
+	for _, tt := range tests {
+		if got := m.requests.With(tt.method, tt.route, tt.status).Value(); got != tt.want {
+			t.Errorf("expected %v requests for %s %s %s, got %v", tt.want, tt.method, tt.route, tt.status, got)
+		}
+	}
+	if got := m.latency.With(http.MethodGet, "GET /users/{userID}").Count(); got != 2 {
+		t.Errorf("expected 2 latency observations, got %d", got)
+	}
+	if got := m.inFlight.Value(); got != 0 {
+		t.Errorf("expected no requests in flight, got %v", got)
+	}
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveQuery("weightRepository.Save", 3*time.Millisecond)
+	m.RecordLogin(LoginFailed)
+	m.OnEvent(context.Background(), event.WeightRecorded{Base: event.NewBase(user.UserID("giada"))})
+	m.OnEvent(context.Background(), event.GoalSet{Base: event.NewBase(user.UserID("giada"))})
+
+	rec := httptest.NewRecorder()
+	m.Handler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("expected Prometheus text content type, got %q", ct)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		`peso_db_query_duration_seconds_bucket{method="weightRepository.Save",le="0.005"} 1`,
+		`peso_logins_total{result="failure"} 1`,
+		`peso_weights_recorded_total 1`,
+		`go_goroutines `,
+		`go_memstats_alloc_bytes `,
+	} {
+		if !strings.Contains(body, "\n"+line) {
+			t.Errorf("expected line %q in output", line)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the latency histogram upper bounds, in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds metric families and renders them in the Prometheus text
+// exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	names    map[string]bool
+}
+
+type family interface {
+	describe() (name, help, typ string)
+	collect(ctx context.Context) ([]sample, error)
+}
+
+// sample is a single exposed line: a metric name suffix, its labels and
+// its value.
+type sample struct {
+	suffix string
+	labels []string // alternating names and values
+	value  float64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(f family) {
+	name, _, _ := f.describe()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// Write renders every family in registration order. A family whose value
+// function fails is skipped and its error returned once the rest have been
+// written.
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	var errs []error
+	for _, f := range families {
+		name, help, typ := f.describe()
+		samples, err := f.collect(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, typ)
+		for _, s := range samples {
+			bw.WriteString(name)
+			bw.WriteString(s.suffix)
+			writeLabels(bw, s.labels)
+			bw.WriteByte(' ')
+			bw.WriteString(formatValue(s.value))
+			bw.WriteByte('\n')
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to collect metrics: %v", errs)
+	}
+	return nil
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	bits atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases the counter by v, which must not be negative.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	addFloat(&c.bits, v)
+}
+
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+func (g *Gauge) Inc() {
+	addFloat(&g.bits, 1)
+}
+
+func (g *Gauge) Dec() {
+	addFloat(&g.bits, -1)
+}
+
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	upper  []float64
+	counts []atomic.Uint64
+	sum    atomic.Uint64
+	count  atomic.Uint64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{upper: buckets, counts: make([]atomic.Uint64, len(buckets))}
+}
+
+func (h *Histogram) Observe(v float64) {
+	if i := sort.SearchFloat64s(h.upper, v); i < len(h.upper) {
+		h.counts[i].Add(1)
+	}
+	addFloat(&h.sum, v)
+	h.count.Add(1)
+}
+
+// Count reports how many values have been observed.
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+func (h *Histogram) samples(labels []string) []sample {
+	out := make([]sample, 0, len(h.upper)+3)
+	var cumulative uint64
+	for i, upper := range h.upper {
+		cumulative += h.counts[i].Load()
+		out = append(out, sample{"_bucket", withLabel(labels, "le", formatValue(upper)), float64(cumulative)})
+	}
+	count := h.count.Load()
+	out = append(out,
+		sample{"_bucket", withLabel(labels, "le", "+Inf"), float64(count)},
+		sample{"_sum", labels, math.Float64frombits(h.sum.Load())},
+		sample{"_count", labels, float64(count)},
+	)
+	return out
+}
+
+// vec keeps one child per distinct combination of label values.
+type vec[T any] struct {
+	labels   []string
+	newChild func() *T
+
+	mu       sync.RWMutex
+	children map[string]*T
+	values   map[string][]string
+}
+
+func newVec[T any](labels []string, newChild func() *T) *vec[T] {
+	return &vec[T]{labels: labels, newChild: newChild, children: make(map[string]*T), values: make(map[string][]string)}
+}
+
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: expected %d label values, got %d", len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	child, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return child
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if child, ok := v.children[key]; ok {
+		return child
+	}
+	child = v.newChild()
+	v.children[key] = child
+	v.values[key] = slices.Clone(values)
+	return child
+}
+
+// each visits the children sorted by label values so output is stable.
+func (v *vec[T]) each(fn func(labels []string, child *T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	v.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v.mu.RLock()
+		child, values := v.children[key], v.values[key]
+		v.mu.RUnlock()
+
+		labels := make([]string, 0, 2*len(values))
+		for i, value := range values {
+			labels = append(labels, v.labels[i], value)
+		}
+		fn(labels, child)
+	}
+}
+
+type meta struct {
+	name, help, typ string
+}
+
+func (m meta) describe() (string, string, string) {
+	return m.name, m.help, m.typ
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	meta
+	*vec[Counter]
+}
+
+// NewCounterVec registers a counter with the given label names.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{meta{name, help, "counter"}, newVec(labels, func() *Counter { return new(Counter) })}
+	r.register(c)
+	return c
+}
+
+// NewCounter registers a counter without labels.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.with(values)
+}
+
+func (c *CounterVec) collect(context.Context) ([]sample, error) {
+	var out []sample
+	c.each(func(labels []string, child *Counter) {
+		out = append(out, sample{labels: labels, value: child.Value()})
+	})
+	return out, nil
+}
+
+// GaugeVec is a gauge partitioned by label values.
+type GaugeVec struct {
+	meta
+	*vec[Gauge]
+}
+
+// NewGaugeVec registers a gauge with the given label names.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{meta{name, help, "gauge"}, newVec(labels, func() *Gauge { return new(Gauge) })}
+	r.register(g)
+	return g
+}
+
+// NewGauge registers a gauge without labels.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).With()
+}
+
+func (g *GaugeVec) With(values ...string) *Gauge {
+	return g.with(values)
+}
+
+func (g *GaugeVec) collect(context.Context) ([]sample, error) {
+	var out []sample
+	g.each(func(labels []string, child *Gauge) {
+		out = append(out, sample{labels: labels, value: child.Value()})
+	})
+	return out, nil
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	meta
+	*vec[Histogram]
+}
+
+// NewHistogramVec registers a histogram with the given bucket upper bounds,
+// which must be sorted, and label names.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !slices.IsSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %s buckets are not sorted", name))
+	}
+	h := &HistogramVec{meta{name, help, "histogram"}, newVec(labels, func() *Histogram { return newHistogram(buckets) })}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.with(values)
+}
+
+func (h *HistogramVec) collect(context.Context) ([]sample, error) {
+	var out []sample
+	h.each(func(labels []string, child *Histogram) {
+		out = append(out, child.samples(labels)...)
+	})
+	return out, nil
+}
+
+// funcFamily reads its value when the registry is written, for values that
+// are owned elsewhere.
+type funcFamily struct {
+	meta
+	fn func(ctx context.Context) (float64, error)
+}
+
+// NewGaugeFunc registers a gauge whose value is computed by fn on every
+// scrape.
+func (r *Registry) NewGaugeFunc(name, help string, fn func(ctx context.Context) (float64, error)) {
+	r.register(&funcFamily{meta{name, help, "gauge"}, fn})
+}
+
+// NewCounterFunc registers a counter whose value is computed by fn on every
+// scrape. fn must never return a smaller value than before.
+func (r *Registry) NewCounterFunc(name, help string, fn func(ctx context.Context) (float64, error)) {
+	r.register(&funcFamily{meta{name, help, "counter"}, fn})
+}
+
+func (f *funcFamily) collect(ctx context.Context) ([]sample, error) {
+	v, err := f.fn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []sample{{value: v}}, nil
+}
+
+func addFloat(bits *atomic.Uint64, delta float64) {
+	for {
+		old := bits.Load()
+		if bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func withLabel(labels []string, name, value string) []string {
+	out := make([]string, 0, len(labels)+2)
+	return append(append(out, labels...), name, value)
+}
+
+func writeLabels(w *bufio.Writer, labels []string) {
+	if len(labels) == 0 {
+		return
+	}
+	w.WriteByte('{')
+	for i := 0; i < len(labels); i += 2 {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(labels[i])
+		w.WriteString(`="`)
+		w.WriteString(labelEscaper.Replace(labels[i+1]))
+		w.WriteByte('"')
+	}
+	w.WriteByte('}')
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// memStatsMaxAge lets the runtime gauges of one scrape share a single
+// ReadMemStats call, which briefly stops the world.
+const memStatsMaxAge = time.Second
+
+type memStatsCache struct {
+	mu     sync.Mutex
+	stats  runtime.MemStats
+	readAt time.Time
+}
+
+func (c *memStatsCache) get() *runtime.MemStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.readAt) > memStatsMaxAge {
+		runtime.ReadMemStats(&c.stats)
+		c.readAt = time.Now()
+	}
+	return &c.stats
+}
+
+// RegisterRuntime adds Go runtime and process statistics to r.
+func RegisterRuntime(r *Registry) {
+	start := float64(time.Now().UnixNano()) / 1e9
+	cache := &memStatsCache{}
+	mem := func(fn func(m *runtime.MemStats) float64) func(context.Context) (float64, error) {
+		return func(context.Context) (float64, error) {
+			return fn(cache.get()), nil
+		}
+	}
+
+	r.NewGaugeVec("go_info", "Information about the Go environment.", "version").With(runtime.Version()).Set(1)
+	r.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func(context.Context) (float64, error) {
+		return float64(runtime.NumGoroutine()), nil
+	})
+	r.NewGaugeFunc("go_gomaxprocs", "Value of GOMAXPROCS.", func(context.Context) (float64, error) {
+		return float64(runtime.GOMAXPROCS(0)), nil
+	})
+	r.NewGaugeFunc("go_memstats_alloc_bytes", "Bytes of allocated heap objects.", mem(func(m *runtime.MemStats) float64 {
+		return float64(m.HeapAlloc)
+	}))
+	r.NewCounterFunc("go_memstats_alloc_bytes_total", "Cumulative bytes allocated for heap objects.", mem(func(m *runtime.MemStats) float64 {
+		return float64(m.TotalAlloc)
+	}))
+	r.NewGaugeFunc("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.", mem(func(m *runtime.MemStats) float64 {
+		return float64(m.Sys)
+	}))
+	r.NewGaugeFunc("go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.", mem(func(m *runtime.MemStats) float64 {
+		return float64(m.HeapInuse)
+	}))
+	r.NewGaugeFunc("go_memstats_heap_objects", "Number of allocated heap objects.", mem(func(m *runtime.MemStats) float64 {
+		return float64(m.HeapObjects)
+	}))
+	r.NewCounterFunc("go_gc_cycles_total", "Number of completed GC cycles.", mem(func(m *runtime.MemStats) float64 {
+		return float64(m.NumGC)
+	}))
+	r.NewCounterFunc("go_gc_pause_seconds_total", "Cumulative time the world was stopped for GC.", mem(func(m *runtime.MemStats) float64 {
+		return float64(m.PauseTotalNs) / 1e9
+	}))
+	r.NewGaugeFunc("process_start_time_seconds", "Start time of the process since the Unix epoch, in seconds.", func(context.Context) (float64, error) {
+		return start, nil
+	})
+}
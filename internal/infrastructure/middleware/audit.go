@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"peso/internal/domain/audit"
+	"peso/internal/infrastructure/logging"
+)
+
+// AuditMetadata attributes the audit events recorded while serving a
+// request to the signed-in user and the request ID. It must run inside
+// SessionMiddleware.
+func AuditMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := audit.Metadata{RequestID: logging.RequestIDFromContext(r.Context())}
+		if u := UserFromContext(r.Context()); u != nil {
+			meta.ActorID = u.ID()
+		}
+		next.ServeHTTP(w, r.WithContext(audit.WithMetadata(r.Context(), meta)))
+	})
+}
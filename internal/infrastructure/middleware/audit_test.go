@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"peso/internal/domain/audit"
+	"peso/internal/domain/user"
+	"peso/internal/infrastructure/logging"
+)
+
+func TestAuditMetadata(t *testing.T) {
+	u, err := user.NewUser("giada", "Giada", "giada@example.com")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		user *user.User
+		want audit.Metadata
+	}{
+		{name: "signed in", user: u, want: audit.Metadata{ActorID: "giada", RequestID: "req-1"}},
+		{name: "anonymous", want: audit.Metadata{RequestID: "req-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got audit.Metadata
+			handler := AuditMetadata(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = audit.MetadataFromContext(r.Context())
+			}))
+
+			ctx := logging.WithRequestID(context.Background(), "req-1")
+			if tt.user != nil {
+				ctx = context.WithValue(ctx, userCtxKey, tt.user)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,10 +2,13 @@ package middleware
 
 import (
 	"context"
+	"net"
 	"net/http"
 
 	"peso/internal/application"
+	"peso/internal/domain/session"
 	"peso/internal/domain/user"
+	"peso/internal/infrastructure/logging"
 )
 
 type ctxKey string
@@ -13,6 +16,7 @@ type ctxKey string
 const (
 	userCtxKey    ctxKey = "user"
 	sessionCtxKey ctxKey = "session_token"
+	currentCtxKey ctxKey = "session"
 	CookieName    string = "peso_session"
 )
 
@@ -25,15 +29,17 @@ func SessionMiddleware(authService *application.AuthService) func(http.Handler)
 				return
 			}
 
-			u, err := authService.ValidateSession(cookie.Value)
+			u, sess, err := authService.ValidateSession(r.Context(), cookie.Value, ClientInfo(r))
 			if err != nil {
-				ClearSessionCookie(w)
+				ClearSessionCookie(w, r)
 				next.ServeHTTP(w, r)
 				return
 			}
 
+			logging.SetUserID(r.Context(), u.ID().String())
 			ctx := context.WithValue(r.Context(), userCtxKey, u)
 			ctx = context.WithValue(ctx, sessionCtxKey, cookie.Value)
+			ctx = context.WithValue(ctx, currentCtxKey, sess)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -65,24 +71,48 @@ func SessionTokenFromContext(ctx context.Context) string {
 	return token
 }
 
-func SetSessionCookie(w http.ResponseWriter, token string) {
+// SessionFromContext returns the session the current request was
+// authenticated with, or nil for anonymous requests.
+func SessionFromContext(ctx context.Context) *session.Session {
+	sess, ok := ctx.Value(currentCtxKey).(*session.Session)
+	if !ok {
+		return nil
+	}
+	return sess
+}
+
+// ClientInfo describes the device issuing the request.
+func ClientInfo(r *http.Request) session.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return session.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: ip,
+	}
+}
+
+func SetSessionCookie(w http.ResponseWriter, r *http.Request, token string) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     CookieName,
 		Value:    token,
 		Path:     "/",
-		MaxAge:   30 * 24 * 60 * 60, // 30 days
+		MaxAge:   int(session.MaxLifetime.Seconds()), // expiry is enforced server-side
 		HttpOnly: true,
+		Secure:   IsSecureRequest(r),
 		SameSite: http.SameSiteLaxMode,
 	})
 }
 
-func ClearSessionCookie(w http.ResponseWriter) {
+func ClearSessionCookie(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     CookieName,
 		Value:    "",
 		Path:     "/",
 		MaxAge:   -1,
 		HttpOnly: true,
+		Secure:   IsSecureRequest(r),
 		SameSite: http.SameSiteLaxMode,
 	})
 }
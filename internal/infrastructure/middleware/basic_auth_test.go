@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		supplied   []string
+		wantStatus int
+	}{
+		{name: "valid credentials", username: "prom", password: "s3cret", supplied: []string{"prom", "s3cret"}, wantStatus: http.StatusOK},
+		{name: "wrong password", username: "prom", password: "s3cret", supplied: []string{"prom", "nope"}, wantStatus: http.StatusUnauthorized},
+		{name: "wrong username", username: "prom", password: "s3cret", supplied: []string{"admin", "s3cret"}, wantStatus: http.StatusUnauthorized},
+		{name: "missing credentials", username: "prom", password: "s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "disabled", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := BasicAuth("peso metrics", tt.username, tt.password)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.supplied != nil {
+				req.SetBasicAuth(tt.supplied[0], tt.supplied[1])
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate challenge")
+			}
+		})
+	}
+}
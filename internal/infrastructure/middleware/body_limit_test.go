@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	form := "weight=72.5&notes=" + strings.Repeat("x", 100)
+
+	tests := []struct {
+		name        string
+		limit       int64
+		contentType string
+		body        string
+		chunked     bool
+		wantStatus  int
+		wantWeight  string
+	}{
+		{name: "small form", limit: 1024, contentType: "application/x-www-form-urlencoded", body: form, wantStatus: http.StatusOK, wantWeight: "72.5"},
+		{name: "oversized form", limit: 64, contentType: "application/x-www-form-urlencoded", body: form, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "oversized chunked form", limit: 64, contentType: "application/x-www-form-urlencoded", body: form, chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "malformed form", limit: 1024, contentType: "application/x-www-form-urlencoded", body: "weight=%zz", wantStatus: http.StatusBadRequest},
+		{name: "oversized JSON fails on read", limit: 64, contentType: "application/json", body: `{"notes":"` + strings.Repeat("x", 100) + `"}`, chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "disabled", limit: 0, contentType: "application/x-www-form-urlencoded", body: form, wantStatus: http.StatusOK, wantWeight: "72.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var weight string
+			handler := MaxBodySize(tt.limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") == "application/json" {
+					if _, err := io.ReadAll(r.Body); err != nil {
+						http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+					}
+					return
+				}
+				weight = r.FormValue("weight")
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/weights", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if weight != tt.wantWeight {
+				t.Errorf("expected weight %q, got %q", tt.wantWeight, weight)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"peso/internal/domain/session"
+)
+
+const (
+	csrfCtxKey ctxKey = "csrf_token"
+
+	// CSRFCookieName holds the per-browser CSRF secret.
+	CSRFCookieName = "peso_csrf"
+	// CSRFHeaderName is sent by HTMX and fetch requests.
+	CSRFHeaderName = "X-CSRF-Token"
+	// CSRFFormField is sent by plain HTML forms.
+	CSRFFormField = "csrf_token"
+
+	csrfTokenLength = 32
+)
+
+// CSRF protects state-changing requests with a double-submit token: every
+// browser gets a random token in a cookie, pages embed the same token, and
+// unsafe requests must echo it back through CSRFHeaderName or CSRFFormField.
+// Cross-site requests can send the cookie but cannot read it, so they cannot
+// supply a matching token.
+func CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		if cookie, err := r.Cookie(CSRFCookieName); err == nil {
+			token = cookie.Value
+		}
+
+		if !isSafeMethod(r.Method) {
+			if token == "" || !validCSRFToken(token, submittedCSRFToken(r)) {
+				writeError(w, r, http.StatusForbidden, "error.csrf_invalid")
+				return
+			}
+		}
+
+		if token == "" {
+			var err error
+			token, err = generateCSRFToken()
+			if err != nil {
+				writeError(w, r, http.StatusInternalServerError, "error.internal")
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     CSRFCookieName,
+				Value:    token,
+				Path:     "/",
+				MaxAge:   int(session.MaxLifetime.Seconds()),
+				HttpOnly: true,
+				Secure:   IsSecureRequest(r),
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		ctx := context.WithValue(r.Context(), csrfCtxKey, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// CSRFTokenFromContext returns the token pages must embed in forms and
+// HTMX headers.
+func CSRFTokenFromContext(ctx context.Context) string {
+	token, ok := ctx.Value(csrfCtxKey).(string)
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func submittedCSRFToken(r *http.Request) string {
+	if token := r.Header.Get(CSRFHeaderName); token != "" {
+		return token
+	}
+	return r.PostFormValue(CSRFFormField)
+}
+
+func validCSRFToken(expected, submitted string) bool {
+	if submitted == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(submitted)) == 1
+}
+
+func generateCSRFToken() (string, error) {
+	bytes := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"peso/internal/infrastructure/i18n"
+)
+
+func csrfTestHandler() http.Handler {
+	return CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFTokenFromContext(r.Context())))
+	}))
+}
+
+func TestCSRF_SafeRequestIssuesToken(t *testing.T) {
+	rec := httptest.NewRecorder()
+	csrfTestHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == CSRFCookieName {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value == "" {
+		t.Fatal("expected CSRF cookie to be set")
+	}
+	if !cookie.HttpOnly {
+		t.Error("expected CSRF cookie to be HttpOnly")
+	}
+	if body := rec.Body.String(); body != cookie.Value {
+		t.Errorf("expected context token %q to match cookie %q", body, cookie.Value)
+	}
+}
+
+func TestCSRF_SafeRequestReusesExistingToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "existing"})
+	rec := httptest.NewRecorder()
+
+	csrfTestHandler().ServeHTTP(rec, req)
+
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("expected no new cookie when one already exists")
+	}
+	if rec.Body.String() != "existing" {
+		t.Errorf("expected existing token in context, got %q", rec.Body.String())
+	}
+}
+
+func TestCSRF_RejectsUnsafeRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		cookie string
+		header string
+		form   string
+	}{
+		{name: "post without cookie or token", method: http.MethodPost},
+		{name: "post with cookie but no token", method: http.MethodPost, cookie: "secret"},
+		{name: "post with token but no cookie", method: http.MethodPost, header: "secret"},
+		{name: "post with mismatched header", method: http.MethodPost, cookie: "secret", header: "other"},
+		{name: "post with mismatched form field", method: http.MethodPost, cookie: "secret", form: "other"},
+		{name: "delete without token", method: http.MethodDelete, cookie: "secret"},
+		{name: "put with mismatched header", method: http.MethodPut, cookie: "secret", header: "secre"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.form != "" {
+				form.Set(CSRFFormField, tt.form)
+			}
+			req := httptest.NewRequest(tt.method, "/api/weights", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeaderName, tt.header)
+			}
+
+			called := false
+			handler := CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("expected status 403, got %d", rec.Code)
+			}
+			if called {
+				t.Error("expected handler not to be called")
+			}
+		})
+	}
+}
+
+func TestCSRF_AcceptsMatchingToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		form   string
+	}{
+		{name: "htmx header", header: "secret"},
+		{name: "form field", form: "secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"weight": {"70.5"}}
+			if tt.form != "" {
+				form.Set(CSRFFormField, tt.form)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/weights", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "secret"})
+			if tt.header != "" {
+				req.Header.Set(CSRFHeaderName, tt.header)
+			}
+
+			var weight string
+			handler := CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				weight = r.FormValue("weight")
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected status 200, got %d", rec.Code)
+			}
+			if weight != "70.5" {
+				t.Errorf("expected form to remain readable by handler, got %q", weight)
+			}
+		})
+	}
+}
+
+func TestCSRF_RejectionIsTranslated(t *testing.T) {
+	want := i18n.Italian.T("error.csrf_invalid")
+
+	send := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "secret"})
+		rec := httptest.NewRecorder()
+		csrfTestHandler().ServeHTTP(rec, req.WithContext(i18n.WithLocale(req.Context(), i18n.Italian)))
+		return rec
+	}
+
+	rec := send("/api/weights")
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a JSON 403 for an API path, got %d %q", rec.Code, rec.Body)
+	}
+	if body.Code != "csrf_invalid" || body.Message != want {
+		t.Errorf("expected code csrf_invalid with %q, got %+v", want, body)
+	}
+
+	rec = send("/account/language")
+	if rec.Code != http.StatusForbidden || strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("expected a plain 403 with %q for a page, got %d %q", want, rec.Code, rec.Body)
+	}
+}
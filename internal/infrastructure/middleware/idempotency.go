@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"peso/internal/application"
+	"peso/internal/domain/idempotency"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/logging"
+)
+
+const (
+	resourceCtxKey ctxKey = "idempotent_resource"
+
+	// maxIdempotentBodyBytes bounds the request bodies read to fingerprint
+	// them.
+	maxIdempotentBodyBytes = 1 << 20
+	// maxStoredResponseBytes bounds the responses kept for replay; larger
+	// ones are sent but not remembered.
+	maxStoredResponseBytes = 256 << 10
+)
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed.
+var replayedHeaders = []string{"Content-Type", "Location", "HX-Trigger", "HX-Redirect"}
+
+// Idempotent lets clients retry create requests safely. A request carrying
+// an idempotency.HeaderName header, or an idempotency.FormField field in a
+// form, is served once per signed-in user and key: retries with the same
+// body get the stored response back, and a key reused with a different body
+// is rejected with 409. Only successful responses are stored, so a failed
+// request can be retried with the same key. Requests without a key or a
+// session pass through. It must run inside SessionMiddleware.
+func Idempotent(store *application.Idempotency, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			currentUser := UserFromContext(r.Context())
+			if currentUser == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, payload, err := idempotentRequest(w, r)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeError(w, r, http.StatusRequestEntityTooLarge, "error.body_too_large")
+					return
+				}
+				writeError(w, r, http.StatusBadRequest, "error.invalid_body")
+				return
+			}
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := idempotency.ValidateKey(key); err != nil {
+				writeError(w, r, http.StatusBadRequest, "error.domain.idempotency_key")
+				return
+			}
+
+			unlock, err := store.Lock(r.Context(), currentUser.ID(), key)
+			if err != nil {
+				writeError(w, r, http.StatusServiceUnavailable, "error.timeout")
+				return
+			}
+			defer unlock()
+
+			requestHash := idempotency.Fingerprint(r.Method, r.URL.Path, payload)
+			rec, err := store.Lookup(r.Context(), currentUser.ID(), key, requestHash)
+			switch {
+			case errors.Is(err, application.ErrIdempotencyKeyReused):
+				writeError(w, r, http.StatusConflict, "error.idempotency_key_reused")
+				return
+			case err != nil:
+				logger.ErrorContext(r.Context(), "idempotency_lookup_failed", slog.Any("error", err), slog.String("path", r.URL.Path))
+				writeError(w, r, http.StatusInternalServerError, "error.internal")
+				return
+			case rec != nil:
+				for name, value := range rec.Headers() {
+					w.Header().Set(name, value)
+				}
+				w.Header().Set(idempotency.ReplayedHeader, "true")
+				w.WriteHeader(rec.StatusCode())
+				w.Write(rec.Response())
+				return
+			}
+
+			var resourceID string
+			cw := &responseCapture{ResponseWriter: w}
+			next.ServeHTTP(cw, r.WithContext(context.WithValue(r.Context(), resourceCtxKey, &resourceID)))
+
+			if cw.status < 200 || cw.status >= 400 || cw.overflow {
+				return
+			}
+			headers := make(map[string]string)
+			for _, name := range replayedHeaders {
+				if value := w.Header().Get(name); value != "" {
+					headers[name] = value
+				}
+			}
+			// The response matters most when the client never received it,
+			// so store it even if the client has gone away.
+			ctx := context.WithoutCancel(r.Context())
+			if err := store.Remember(ctx, currentUser.ID(), key, requestHash, resourceID, cw.status, headers, cw.body.Bytes()); err != nil {
+				logger.ErrorContext(r.Context(), "failed_to_remember_idempotency_key", slog.Any("error", err), slog.String("path", r.URL.Path))
+			}
+		})
+	}
+}
+
+// writeError answers like the web handlers do: a JSON body with the code
+// and the translated message for /api paths, the message as plain text
+// otherwise.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	text := i18n.FromContext(r.Context()).T(message)
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		http.Error(w, text, status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":    false,
+		"error":      http.StatusText(status),
+		"code":       strings.TrimPrefix(message, "error."),
+		"message":    text,
+		"request_id": logging.RequestIDFromContext(r.Context()),
+	})
+}
+
+// SetCreatedResource records the ID of the resource the current request
+// created, to be stored with its idempotency key. It does nothing outside
+// Idempotent.
+func SetCreatedResource(ctx context.Context, id string) {
+	if resourceID, ok := ctx.Value(resourceCtxKey).(*string); ok {
+		*resourceID = id
+	}
+}
+
+// idempotentRequest returns the request's idempotency key and the payload
+// that identifies it. Forms are compared field by field, without the CSRF
+// token and the key itself; other bodies byte for byte.
+func idempotentRequest(w http.ResponseWriter, r *http.Request) (string, string, error) {
+	key := r.Header.Get(idempotency.HeaderName)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes)
+		if err := r.ParseForm(); err != nil {
+			return "", "", err
+		}
+		if key == "" {
+			key = r.PostForm.Get(idempotency.FormField)
+		}
+		fields := make(url.Values, len(r.PostForm))
+		for name, values := range r.PostForm {
+			if name != CSRFFormField && name != idempotency.FormField {
+				fields[name] = values
+			}
+		}
+		return key, fields.Encode(), nil
+	}
+
+	if key == "" {
+		return "", "", nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+	if err != nil {
+		return "", "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return key, string(body), nil
+}
+
+// responseCapture passes a response through while keeping a copy of its
+// status and body.
+type responseCapture struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *responseCapture) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseCapture) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body.Len()+len(b) > maxStoredResponseBytes {
+		w.overflow = true
+	} else if !w.overflow {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseCapture) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
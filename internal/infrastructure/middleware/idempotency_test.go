@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"peso/internal/application"
+	"peso/internal/domain/idempotency"
+	"peso/internal/domain/user"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/interfaces"
+)
+
+type memoryKeys struct {
+	records map[string]*idempotency.Record
+}
+
+func (m *memoryKeys) Find(ctx context.Context, userID user.UserID, key string) (*idempotency.Record, error) {
+	return m.records[userID.String()+"/"+key], nil
+}
+
+func (m *memoryKeys) Save(ctx context.Context, rec *idempotency.Record) error {
+	id := rec.UserID().String() + "/" + rec.Key()
+	if _, ok := m.records[id]; ok {
+		return interfaces.ErrConflict
+	}
+	m.records[id] = rec
+	return nil
+}
+
+func (m *memoryKeys) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotent(t *testing.T) {
+	u, err := user.NewUser("giada", "Giada", "giada@example.com")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	keys := &memoryKeys{records: make(map[string]*idempotency.Record)}
+	created := 0
+	handler := Idempotent(application.NewIdempotency(keys), slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("weight") == "" {
+			http.Error(w, "missing weight", http.StatusBadRequest)
+			return
+		}
+		created++
+		SetCreatedResource(r.Context(), "w1")
+		w.Header().Set("HX-Trigger", "weight-updated")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "created")
+	}))
+
+	tests := []struct {
+		name         string
+		signedIn     bool
+		key          string
+		form         string
+		wantStatus   int
+		wantReplayed bool
+		wantCreated  int
+		wantCode     string
+	}{
+		{name: "first request", signedIn: true, key: "k1", form: "weight=72.4", wantStatus: http.StatusCreated, wantCreated: 1},
+		{name: "retry is replayed", signedIn: true, key: "k1", form: "weight=72.4&csrf_token=other", wantStatus: http.StatusCreated, wantReplayed: true, wantCreated: 1},
+		{name: "key in form field", signedIn: true, form: "weight=72.4&idempotency_key=k1", wantStatus: http.StatusCreated, wantReplayed: true, wantCreated: 1},
+		{name: "different payload", signedIn: true, key: "k1", form: "weight=80", wantStatus: http.StatusConflict, wantCreated: 1, wantCode: "idempotency_key_reused"},
+		{name: "invalid key", signedIn: true, key: "two words", form: "weight=72.4", wantStatus: http.StatusBadRequest, wantCreated: 1, wantCode: "domain.idempotency_key"},
+		{name: "failure is not stored", signedIn: true, key: "k2", form: "", wantStatus: http.StatusBadRequest, wantCreated: 1},
+		{name: "retry after failure", signedIn: true, key: "k2", form: "weight=72.4", wantStatus: http.StatusCreated, wantCreated: 2},
+		{name: "no key", signedIn: true, form: "weight=72.4", wantStatus: http.StatusCreated, wantCreated: 3},
+		{name: "anonymous", key: "k1", form: "weight=72.4", wantStatus: http.StatusCreated, wantCreated: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/weights", strings.NewReader(tt.form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.key != "" {
+				req.Header.Set(idempotency.HeaderName, tt.key)
+			}
+			if tt.signedIn {
+				req = req.WithContext(context.WithValue(req.Context(), userCtxKey, u))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if replayed := rec.Header().Get(idempotency.ReplayedHeader) == "true"; replayed != tt.wantReplayed {
+				t.Errorf("expected replayed=%v, got %v", tt.wantReplayed, replayed)
+			}
+			if tt.wantReplayed && (rec.Body.String() != "created" || rec.Header().Get("HX-Trigger") != "weight-updated") {
+				t.Errorf("expected the original response, got %q with HX-Trigger %q", rec.Body.String(), rec.Header().Get("HX-Trigger"))
+			}
+			if tt.wantCode != "" {
+				var body struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Code != tt.wantCode || body.Message == "" {
+					t.Errorf("expected a JSON error with code %q, got %q", tt.wantCode, rec.Body.String())
+				}
+			}
+			if created != tt.wantCreated {
+				t.Errorf("expected %d records created, got %d", tt.wantCreated, created)
+			}
+		})
+	}
+
+	if rec := keys.records["giada/k1"]; rec == nil || rec.ResourceID() != "w1" {
+		t.Errorf("expected k1 to be stored with its resource, got %v", rec)
+	}
+}
+
+func TestIdempotent_JSONBody(t *testing.T) {
+	u, err := user.NewUser("giada", "Giada", "giada@example.com")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	keys := &memoryKeys{records: make(map[string]*idempotency.Record)}
+	var bodies []string
+	handler := Idempotent(application.NewIdempotency(keys), slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"results":[]}`)
+	}))
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/weights/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotency.HeaderName, "batch-1")
+		ctx := i18n.WithLocale(context.WithValue(req.Context(), userCtxKey, u), i18n.Italian)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(ctx))
+		return rec
+	}
+
+	send(`{"entries":[1]}`)
+	if len(bodies) != 1 || bodies[0] != `{"entries":[1]}` {
+		t.Fatalf("expected the handler to read the original body, got %q", bodies)
+	}
+	if rec := send(`{"entries":[1]}`); rec.Header().Get("Content-Type") != "application/json" || len(bodies) != 1 {
+		t.Errorf("expected a replay with its content type, got %q after %d calls", rec.Header().Get("Content-Type"), len(bodies))
+	}
+	rec := send(`{"entries":[2]}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, rec.Code)
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Message != i18n.Italian.T("error.idempotency_key_reused") {
+		t.Errorf("expected the error in Italian, got %q", rec.Body.String())
+	}
+}
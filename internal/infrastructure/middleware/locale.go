@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"peso/internal/infrastructure/i18n"
+)
+
+// Locale chooses the language each request is answered in: the signed-in
+// user's preference, then the browser's Accept-Language, then fallback.
+// Handlers read it with i18n.FromContext. It must run inside
+// SessionMiddleware to see the user's preference.
+func Locale(fallback i18n.Locale) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			loc, ok := i18n.Locale(""), false
+			if u := UserFromContext(r.Context()); u != nil {
+				loc, ok = i18n.Parse(u.Language())
+			}
+			if !ok {
+				loc = i18n.Match(r.Header.Get("Accept-Language"), fallback)
+			}
+
+			w.Header().Add("Vary", "Accept-Language")
+			w.Header().Set("Content-Language", string(loc))
+			next.ServeHTTP(w, r.WithContext(i18n.WithLocale(r.Context(), loc)))
+		})
+	}
+}
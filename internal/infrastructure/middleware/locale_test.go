@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"peso/internal/domain/user"
+	"peso/internal/infrastructure/i18n"
+)
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		name           string
+		preference     string
+		signedIn       bool
+		acceptLanguage string
+		want           i18n.Locale
+	}{
+		{name: "fallback", want: i18n.Italian},
+		{name: "browser", acceptLanguage: "en-US,en;q=0.9", want: i18n.English},
+		{name: "unsupported browser language", acceptLanguage: "de-DE", want: i18n.Italian},
+		{name: "user preference wins", signedIn: true, preference: "it", acceptLanguage: "en", want: i18n.Italian},
+		{name: "automatic preference follows the browser", signedIn: true, acceptLanguage: "en", want: i18n.English},
+		{name: "unsupported preference follows the browser", signedIn: true, preference: "de", acceptLanguage: "en", want: i18n.English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got i18n.Locale
+			handler := Locale(i18n.Italian)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = i18n.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if tt.signedIn {
+				u, err := user.NewUser("giada", "Giada", "giada@example.com")
+				if err != nil {
+					t.Fatalf("failed to create user: %v", err)
+				}
+				if err := u.SetLanguage(tt.preference); err != nil {
+					t.Fatalf("failed to set language: %v", err)
+				}
+				req = req.WithContext(context.WithValue(req.Context(), userCtxKey, u))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got != tt.want {
+				t.Errorf("expected locale %q, got %q", tt.want, got)
+			}
+			if rec.Header().Get("Content-Language") != string(tt.want) {
+				t.Errorf("expected Content-Language %q, got %q", tt.want, rec.Header().Get("Content-Language"))
+			}
+			if rec.Header().Get("Vary") != "Accept-Language" {
+				t.Errorf("expected Vary: Accept-Language, got %q", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const secureCtxKey ctxKey = "secure"
+
+// ProxyHeaders applies the client address and scheme reported by a reverse
+// proxy. Forwarding headers are only honoured when trust is true; otherwise
+// any client could spoof its address or claim to be on HTTPS.
+func ProxyHeaders(trust bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !trust {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := forwardedFor(r); ip != "" {
+				r.RemoteAddr = ip
+			}
+
+			if strings.EqualFold(strings.TrimSpace(r.Header.Get("X-Forwarded-Proto")), "https") {
+				r = r.WithContext(context.WithValue(r.Context(), secureCtxKey, true))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsSecureRequest reports whether the client reached us over HTTPS, either
+// directly or through a trusted proxy.
+func IsSecureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	secure, _ := r.Context().Value(secureCtxKey).(bool)
+	return secure
+}
+
+// forwardedFor returns the client address as seen by the nearest proxy: the
+// right-most X-Forwarded-For entry, falling back to X-Real-IP. Entries further
+// left are supplied by the client and cannot be trusted.
+func forwardedFor(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+			return ip
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-Real-IP"))
+}
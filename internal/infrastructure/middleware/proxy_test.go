@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		trust      bool
+		headers    map[string]string
+		wantIP     string
+		wantSecure bool
+	}{
+		{
+			name:    "untrusted proxy headers are ignored",
+			trust:   false,
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Forwarded-Proto": "https"},
+			wantIP:  "192.0.2.1",
+		},
+		{
+			name:       "trusted proxy sets client ip and scheme",
+			trust:      true,
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Forwarded-Proto": "https"},
+			wantIP:     "203.0.113.7",
+			wantSecure: true,
+		},
+		{
+			name:    "spoofed left-most entries are skipped",
+			trust:   true,
+			headers: map[string]string{"X-Forwarded-For": "10.0.0.1, 203.0.113.7"},
+			wantIP:  "203.0.113.7",
+		},
+		{
+			name:    "falls back to x-real-ip",
+			trust:   true,
+			headers: map[string]string{"X-Real-IP": "203.0.113.9"},
+			wantIP:  "203.0.113.9",
+		},
+		{
+			name:    "no headers keeps remote address",
+			trust:   true,
+			headers: map[string]string{"X-Forwarded-Proto": "http"},
+			wantIP:  "192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIP string
+			var gotSecure bool
+			handler := ProxyHeaders(tt.trust)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotIP = ClientInfo(r).IPAddress
+				gotSecure = IsSecureRequest(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:51234"
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotIP != tt.wantIP {
+				t.Errorf("expected client IP %s, got %s", tt.wantIP, gotIP)
+			}
+			if gotSecure != tt.wantSecure {
+				t.Errorf("expected secure=%v, got %v", tt.wantSecure, gotSecure)
+			}
+		})
+	}
+}
+
+func TestSetSessionCookie_SecureBehindTrustedProxy(t *testing.T) {
+	var cookie *http.Cookie
+	handler := ProxyHeaders(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetSessionCookie(w, r, "token")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == CookieName {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("expected session cookie")
+	}
+	if !cookie.Secure {
+		t.Error("expected Secure cookie for HTTPS request")
+	}
+}
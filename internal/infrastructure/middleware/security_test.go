@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecurityHeaders_SetsNonceBasedPolicy(t *testing.T) {
+	var nonce string
+	handler := SecurityHeaders(DefaultSecurityHeadersConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = CSPNonceFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if nonce == "" {
+		t.Fatal("expected nonce in request context")
+	}
+
+	csp := rec.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "script-src 'self' 'nonce-"+nonce+"'") {
+		t.Errorf("expected script-src to allow nonce %q, got %q", nonce, csp)
+	}
+	if strings.Contains(csp, "script-src 'self' 'unsafe-inline'") {
+		t.Error("script-src must not allow unsafe-inline")
+	}
+	if !strings.Contains(csp, "frame-ancestors 'none'") {
+		t.Errorf("expected frame-ancestors 'none' for DENY, got %q", csp)
+	}
+
+	expected := map[string]string{
+		"X-Frame-Options":        "DENY",
+		"X-Content-Type-Options": "nosniff",
+		"Referrer-Policy":        "strict-origin-when-cross-origin",
+	}
+	for header, want := range expected {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("expected %s %q, got %q", header, want, got)
+		}
+	}
+
+	if hsts := rec.Header().Get("Strict-Transport-Security"); hsts != "" {
+		t.Errorf("expected no HSTS over plain HTTP, got %q", hsts)
+	}
+}
+
+func TestSecurityHeaders_NonceChangesPerRequest(t *testing.T) {
+	handler := SecurityHeaders(DefaultSecurityHeadersConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/", nil))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if first.Header().Get("Content-Security-Policy") == second.Header().Get("Content-Security-Policy") {
+		t.Error("expected a fresh nonce for every request")
+	}
+}
+
+func TestSecurityHeaders_HSTS(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxAge     time.Duration
+		tls        bool
+		forwarded  bool
+		trustProxy bool
+		want       string
+	}{
+		{name: "direct tls", maxAge: time.Hour, tls: true, want: "max-age=3600; includeSubDomains"},
+		{name: "trusted proxy over https", maxAge: time.Hour, forwarded: true, trustProxy: true, want: "max-age=3600; includeSubDomains"},
+		{name: "untrusted forwarded proto", maxAge: time.Hour, forwarded: true, trustProxy: false, want: ""},
+		{name: "disabled", maxAge: 0, tls: true, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultSecurityHeadersConfig()
+			cfg.HSTSMaxAge = tt.maxAge
+			handler := ProxyHeaders(tt.trustProxy)(SecurityHeaders(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tt.forwarded {
+				req.Header.Set("X-Forwarded-Proto", "https")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Strict-Transport-Security"); got != tt.want {
+				t.Errorf("expected HSTS %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders_ReportOnly(t *testing.T) {
+	cfg := DefaultSecurityHeadersConfig()
+	cfg.CSPReportOnly = true
+	handler := SecurityHeaders(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Header().Get("Content-Security-Policy") != "" {
+		t.Error("expected enforcing policy header to be absent")
+	}
+	if rec.Header().Get("Content-Security-Policy-Report-Only") == "" {
+		t.Error("expected report-only policy header")
+	}
+}
@@ -0,0 +1,142 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"peso/internal/domain/audit"
+	"peso/internal/domain/user"
+	"peso/internal/interfaces"
+)
+
+type auditRepository struct {
+	db dbtx
+}
+
+func NewAuditRepository(db *DB) interfaces.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+const auditColumns = `id, user_id, actor_id, action, target_id, before_data, after_data, request_id, occurred_at`
+
+// Append stores an event. The table rejects updates and deletes, so this
+// is the only way it is written.
+func (r *auditRepository) Append(ctx context.Context, e *audit.Event) error {
+	query := `
+		INSERT INTO audit_events (user_id, actor_id, action, target_type, target_id, before_data, after_data, request_id, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		e.UserID().String(),
+		optional(e.ActorID().String()),
+		string(e.Action()),
+		e.TargetType(),
+		e.TargetID(),
+		optional(string(e.Before())),
+		optional(string(e.After())),
+		optional(e.RequestID()),
+		e.OccurredAt().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append audit event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *auditRepository) Find(ctx context.Context, q interfaces.AuditQuery) ([]*audit.Event, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if q.UserID != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, q.UserID.String())
+	}
+	if q.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, string(q.Action))
+	}
+	if !q.Since.IsZero() {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		conditions = append(conditions, "occurred_at < ?")
+		args = append(args, q.Until.UTC())
+	}
+	if q.BeforeID > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, q.BeforeID)
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM audit_events`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, q.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*audit.Event
+	for rows.Next() {
+		e, err := r.scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event row: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over audit event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *auditRepository) scanEvent(row rowScanner) (*audit.Event, error) {
+	var (
+		id                                int64
+		userID, action, targetID          string
+		actorID, before, after, requestID sql.NullString
+		occurredAt                        time.Time
+	)
+
+	if err := row.Scan(&id, &userID, &actorID, &action, &targetID, &before, &after, &requestID, &occurredAt); err != nil {
+		return nil, err
+	}
+
+	return audit.ReconstructEvent(
+		id,
+		user.UserID(userID),
+		user.UserID(actorID.String),
+		audit.Action(action),
+		targetID,
+		rawJSON(before),
+		rawJSON(after),
+		requestID.String,
+		occurredAt,
+	), nil
+}
+
+// optional maps an empty string to NULL
+func optional(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
@@ -0,0 +1,60 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrBackupUnsupported is returned by BackupTo on databases that must be
+// backed up with their own tooling, such as pg_dump for Postgres.
+var ErrBackupUnsupported = errors.New("online backup is only supported for sqlite")
+
+// BackupTo writes a consistent, compacted copy of the live database to path
+// with VACUUM INTO. It only holds a read transaction, so requests keep being
+// served while it runs. path must not exist.
+func (db *DB) BackupTo(ctx context.Context, path string) error {
+	if db.dialect != DialectSQLite {
+		return ErrBackupUnsupported
+	}
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// CheckIntegrity runs PRAGMA integrity_check on the SQLite file at path
+// without modifying it.
+func CheckIntegrity(ctx context.Context, path string) error {
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("failed to check integrity of %s: %w", path, err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return fmt.Errorf("failed to read integrity check: %w", err)
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check integrity of %s: %w", path, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check of %s failed: %s", path, strings.Join(problems, "; "))
+	}
+	return nil
+}
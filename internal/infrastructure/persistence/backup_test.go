@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupTo_WritesConsistentCopy(t *testing.T) {
+	db := setupMigratedDB(t)
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := db.BackupTo(context.Background(), path); err != nil {
+		t.Fatalf("failed to back up: %v", err)
+	}
+	if err := CheckIntegrity(context.Background(), path); err != nil {
+		t.Errorf("expected snapshot to pass integrity check, got %v", err)
+	}
+
+	snapshot, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	defer snapshot.Close()
+
+	exists, err := NewUserRepository(snapshot).Exists(context.Background(), "giada")
+	if err != nil {
+		t.Fatalf("failed to query snapshot: %v", err)
+	}
+	if !exists {
+		t.Error("expected snapshot to contain the default users")
+	}
+
+	if err := db.BackupTo(context.Background(), path); err == nil {
+		t.Error("expected error when the destination already exists")
+	}
+}
+
+func TestCheckIntegrity_RejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corrupt.db")
+	if err := os.WriteFile(path, []byte("definitely not a database"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if err := CheckIntegrity(context.Background(), path); err == nil {
+		t.Error("expected integrity check to fail")
+	}
+}
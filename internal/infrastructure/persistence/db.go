@@ -1,24 +1,81 @@
 package persistence
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
-	"io/fs"
-	"path/filepath"
-	"sort"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
-	_ "modernc.org/sqlite"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"modernc.org/sqlite"
+
+	"peso/internal/domain/session"
+)
+
+func init() {
+	// peso_sha256 lets SQL migrations hash legacy session tokens in place.
+	sqlite.MustRegisterDeterministicScalarFunction("peso_sha256", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch v := args[0].(type) {
+		case string:
+			return session.HashToken(v), nil
+		case []byte:
+			return session.HashToken(string(v)), nil
+		default:
+			return nil, fmt.Errorf("peso_sha256: unsupported argument type %T", v)
+		}
+	})
+}
+
+// Dialect identifies the SQL flavour spoken by the underlying database.
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
 )
 
 // DB wraps sql.DB with additional functionality
 type DB struct {
 	*sql.DB
+
+	dialect       Dialect
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	queryObserver func(method string, d time.Duration)
+}
+
+// Open connects to the database for driver ("sqlite" or "postgres"). For
+// SQLite source is a file path, for Postgres a connection string.
+func Open(driver, source string) (*DB, error) {
+	switch Dialect(driver) {
+	case DialectSQLite:
+		return NewDB(source)
+	case DialectPostgres:
+		if source == "" {
+			return nil, errors.New("postgres connection string is required")
+		}
+		return NewPostgresDB(source)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
 }
 
 // NewDB creates a new database connection
 func NewDB(dbPath string) (*DB, error) {
-	db, err := sql.Open("sqlite", dbPath+"?_foreign_keys=on&_journal_mode=WAL")
+	// Transactions take the write lock up front so read-then-write units of
+	// work serialize instead of failing with SQLITE_BUSY on upgrade.
+	db, err := sql.Open("sqlite", dbPath+"?_txlock=immediate&_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -28,116 +85,163 @@ func NewDB(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{DB: db}, nil
+	return &DB{DB: db, dialect: DialectSQLite}, nil
 }
 
-// Migrate runs database migrations from the given filesystem
-func (db *DB) Migrate(migrationsFS fs.FS) error {
-	// Create migrations table if it doesn't exist
-	if err := db.createMigrationsTable(); err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
+// NewPostgresDB creates a new PostgreSQL connection pool from a libpq-style
+// connection string or URL.
+func NewPostgresDB(dsn string) (*DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Get list of migration files
-	files, err := fs.Glob(migrationsFS, "*.sql")
-	if err != nil {
-		return fmt.Errorf("failed to list migration files: %w", err)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Sort files to ensure proper execution order
-	sort.Strings(files)
+	return &DB{DB: db, dialect: DialectPostgres}, nil
+}
 
-	for _, file := range files {
-		migrationName := strings.TrimSuffix(filepath.Base(file), ".sql")
+// Dialect reports which SQL flavour the database speaks.
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
 
-		// Check if migration has already been applied
-		applied, err := db.isMigrationApplied(migrationName)
-		if err != nil {
-			return fmt.Errorf("failed to check migration status: %w", err)
-		}
+// rebind rewrites ? placeholders into $N for Postgres. Queries in this
+// package never contain literal question marks.
+func (db *DB) rebind(query string) string {
+	if db.dialect != DialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
 
-		if applied {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
 			continue
 		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
 
-		// Read and execute migration
-		content, err := fs.ReadFile(migrationsFS, file)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", file, err)
-		}
-
-		if err := db.executeMigration(string(content)); err != nil {
-			return fmt.Errorf("failed to execute migration %s: %w", file, err)
-		}
-
-		// Mark migration as applied
-		if err := db.markMigrationApplied(migrationName); err != nil {
-			return fmt.Errorf("failed to mark migration as applied: %w", err)
-		}
+// LogSlowQueries logs every statement that takes longer than threshold
+// with the context of the request that ran it. Zero disables it.
+func (db *DB) LogSlowQueries(logger *slog.Logger, threshold time.Duration) {
+	db.logger = logger
+	db.slowThreshold = threshold
+}
 
-		fmt.Printf("Applied migration: %s\n", migrationName)
-	}
+// ObserveQueries reports the duration of every statement to fn, together
+// with the repository method that issued it (e.g. "weightRepository.Save")
+// or "other" for statements run outside a repository.
+func (db *DB) ObserveQueries(fn func(method string, d time.Duration)) {
+	db.queryObserver = fn
+}
 
-	return nil
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, done := db.observe(ctx, query)
+	result, err := db.DB.ExecContext(ctx, db.rebind(query), args...)
+	done(err)
+	return result, err
 }
 
-func (db *DB) createMigrationsTable() error {
-	query := `
-		CREATE TABLE IF NOT EXISTS migrations (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL UNIQUE,
-			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-		)
-	`
-	_, err := db.Exec(query)
-	return err
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, done := db.observe(ctx, query)
+	rows, err := db.DB.QueryContext(ctx, db.rebind(query), args...)
+	done(err)
+	return rows, err
 }
 
-func (db *DB) isMigrationApplied(name string) (bool, error) {
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM migrations WHERE name = ?", name).Scan(&count)
-	if err != nil {
-		return false, err
-	}
-	return count > 0, nil
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, done := db.observe(ctx, query)
+	row := db.DB.QueryRowContext(ctx, db.rebind(query), args...)
+	done(row.Err())
+	return row
 }
 
-func (db *DB) executeMigration(content string) error {
-	// Execute the entire content as one statement for SQLite
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+var tracer = otel.Tracer("peso/internal/infrastructure/persistence")
 
-	// Clean up the content - remove comments and excessive whitespace
-	lines := strings.Split(content, "\n")
-	var cleanedLines []string
+// observe times a statement for the query observer, slow query logging and,
+// when the request is traced, a span named after the repository method.
+// The statement wrappers must call it directly: repositoryMethod counts
+// stack frames from here.
+func (db *DB) observe(ctx context.Context, query string) (context.Context, func(error)) {
+	start := time.Now()
+	traced := trace.SpanFromContext(ctx).IsRecording()
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "--") {
-			continue
-		}
-		cleanedLines = append(cleanedLines, line)
+	var method string
+	if traced || db.queryObserver != nil {
+		method = repositoryMethod()
 	}
 
-	if len(cleanedLines) == 0 {
-		return nil // No actual SQL to execute
+	var span trace.Span
+	if traced {
+		system := semconv.DBSystemNameSQLite
+		if db.dialect == DialectPostgres {
+			system = semconv.DBSystemNamePostgreSQL
+		}
+		ctx, span = tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(system, semconv.DBQueryText(compactQuery(query))),
+		)
 	}
 
-	cleanedContent := strings.Join(cleanedLines, " ")
-
-	if _, err := tx.Exec(cleanedContent); err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
+	return ctx, func(err error) {
+		elapsed := time.Since(start)
+		if span != nil {
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+		if db.queryObserver != nil {
+			db.queryObserver(method, elapsed)
+		}
+		if db.logger == nil || db.slowThreshold <= 0 || elapsed < db.slowThreshold {
+			return
+		}
+		db.logger.WarnContext(ctx, "slow_query",
+			slog.Duration("duration", elapsed),
+			slog.String("query", compactQuery(query)),
+		)
 	}
+}
 
-	return tx.Commit()
+// compactQuery folds a statement's whitespace onto a single line.
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
 }
 
-func (db *DB) markMigrationApplied(name string) error {
-	_, err := db.Exec("INSERT INTO migrations (name) VALUES (?)", name)
-	return err
+var packagePath = reflect.TypeOf(DB{}).PkgPath()
+
+// repositoryMethod names the repository method on the call stack, skipping
+// observe and the statement wrapper that called it.
+func repositoryMethod() string {
+	var pcs [8]uintptr
+	n := runtime.Callers(4, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		// Methods are named like "peso/.../persistence.(*weightRepository).Save",
+		// with a ".funcN" suffix inside closures.
+		if rest, ok := strings.CutPrefix(frame.Function, packagePath+".(*"); ok {
+			if typ, method, ok := strings.Cut(rest, ")."); ok && strings.HasSuffix(typ, "Repository") {
+				method, _, _ = strings.Cut(method, ".")
+				return typ + "." + method
+			}
+		}
+		if !more {
+			return "other"
+		}
+	}
 }
 
 // Close closes the database connection
@@ -0,0 +1,136 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"peso/internal/domain/user"
+	"peso/internal/infrastructure/logging"
+	"peso/internal/interfaces"
+)
+
+func TestDB_LogsSlowQueriesWithRequestID(t *testing.T) {
+	db := setupMigratedDB(t)
+	defer db.Close()
+
+	var buf bytes.Buffer
+	db.LogSlowQueries(slog.New(logging.NewContextHandler(slog.NewJSONHandler(&buf, nil))), time.Nanosecond)
+
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+	if _, err := NewUserRepository(db).FindByID(ctx, user.UserID("giada")); err != nil {
+		t.Fatalf("failed to find user: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"msg":"slow_query"`) {
+		t.Fatalf("expected slow_query log, got %q", out)
+	}
+	if !strings.Contains(out, `"request_id":"req-123"`) {
+		t.Errorf("expected request ID in log, got %q", out)
+	}
+}
+
+func TestDB_ObservesQueriesByRepositoryMethod(t *testing.T) {
+	db := setupMigratedDB(t)
+	defer db.Close()
+
+	var methods []string
+	db.ObserveQueries(func(method string, d time.Duration) {
+		methods = append(methods, method)
+	})
+
+	ctx := context.Background()
+	if _, err := NewUserRepository(db).FindByID(ctx, user.UserID("giada")); err != nil {
+		t.Fatalf("failed to find user: %v", err)
+	}
+	err := db.Do(ctx, func(repos interfaces.Repositories) error {
+		_, err := repos.Sessions.CountActive(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to count sessions: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatalf("failed to run statement: %v", err)
+	}
+
+	want := []string{"userRepository.FindByID", "sessionRepository.CountActive", "other"}
+	if strings.Join(methods, ",") != strings.Join(want, ",") {
+		t.Errorf("expected methods %v, got %v", want, methods)
+	}
+}
+
+func TestDB_TracesQueriesWithinRequests(t *testing.T) {
+	db := setupMigratedDB(t)
+	defer db.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	// Statements outside a traced request do not start traces of their own.
+	if _, err := NewUserRepository(db).FindByID(context.Background(), user.UserID("giada")); err != nil {
+		t.Fatalf("failed to find user: %v", err)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Fatalf("expected no spans without a parent, got %d", len(spans))
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "GET /users/{userID}")
+	err := db.Do(ctx, func(repos interfaces.Repositories) error {
+		_, err := repos.Users.FindByID(ctx, user.UserID("giada"))
+		return err
+	})
+	parent.End()
+	if err != nil {
+		t.Fatalf("failed to find user: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	want := []string{"userRepository.FindByID", "UnitOfWork.Do", "GET /users/{userID}"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected spans %v, got %v", want, names)
+	}
+	for _, s := range spans[:2] {
+		if s.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("expected %s to be a child of the request span", s.Name)
+		}
+	}
+
+	var statement string
+	for _, kv := range spans[0].Attributes {
+		if kv.Key == "db.query.text" {
+			statement = kv.Value.AsString()
+		}
+	}
+	if !strings.HasPrefix(statement, "SELECT ") || strings.Contains(statement, "\n") {
+		t.Errorf("expected compacted statement, got %q", statement)
+	}
+}
+
+func TestDB_HonoursCancelledContext(t *testing.T) {
+	db := setupMigratedDB(t)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewUserRepository(db).FindActive(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
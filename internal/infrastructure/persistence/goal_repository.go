@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -12,7 +13,7 @@ import (
 )
 
 type goalRepository struct {
-	db *DB
+	db dbtx
 }
 
 // NewGoalRepository creates a new goal repository
@@ -20,13 +21,13 @@ func NewGoalRepository(db *DB) interfaces.GoalRepository {
 	return &goalRepository{db: db}
 }
 
-func (r *goalRepository) Save(g *goal.Goal) error {
+func (r *goalRepository) Create(ctx context.Context, g *goal.Goal) error {
 	query := `
-		INSERT OR REPLACE INTO goals (id, user_id, target_weight, unit, target_date, description, active, created_at, updated_at)
+		INSERT INTO goals (id, user_id, target_weight, unit, target_date, description, active, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		g.ID().String(),
 		g.UserID().String(),
 		g.TargetWeight().Float64(),
@@ -39,17 +40,57 @@ func (r *goalRepository) Save(g *goal.Goal) error {
 	)
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to create goal: %w", interfaces.ErrConflict)
+		}
+		return fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	return nil
+}
+
+func (r *goalRepository) Save(ctx context.Context, g *goal.Goal) error {
+	// An upsert rather than INSERT OR REPLACE: REPLACE would silently delete
+	// another active goal instead of tripping the one-active-goal index.
+	query := `
+		INSERT INTO goals (id, user_id, target_weight, unit, target_date, description, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			target_weight = excluded.target_weight,
+			unit = excluded.unit,
+			target_date = excluded.target_date,
+			description = excluded.description,
+			active = excluded.active,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		g.ID().String(),
+		g.UserID().String(),
+		g.TargetWeight().Float64(),
+		g.Unit().String(),
+		g.TargetDate().ToTime(),
+		g.Description(),
+		g.IsActive(),
+		g.CreatedAt(),
+		g.UpdatedAt(),
+	)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to save goal: %w", interfaces.ErrConflict)
+		}
 		return fmt.Errorf("failed to save goal: %w", err)
 	}
 
 	return nil
 }
 
-func (r *goalRepository) FindByID(id goal.GoalID) (*goal.Goal, error) {
+func (r *goalRepository) FindByID(ctx context.Context, id goal.GoalID) (*goal.Goal, error) {
 	query := `
 		SELECT id, user_id, target_weight, unit, target_date, description, active, created_at, updated_at
 		FROM goals 
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	var (
@@ -64,7 +105,7 @@ func (r *goalRepository) FindByID(id goal.GoalID) (*goal.Goal, error) {
 		updatedAt    time.Time
 	)
 
-	err := r.db.QueryRow(query, id.String()).Scan(
+	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
 		&goalID, &userID, &targetWeight, &unit, &targetDate, &description, &active, &createdAt, &updatedAt,
 	)
 
@@ -78,11 +119,11 @@ func (r *goalRepository) FindByID(id goal.GoalID) (*goal.Goal, error) {
 	return r.scanGoal(goalID, userID, targetWeight, unit, targetDate, description, active, createdAt, updatedAt)
 }
 
-func (r *goalRepository) FindActiveByUserID(userID user.UserID) (*goal.Goal, error) {
+func (r *goalRepository) FindActiveByUserID(ctx context.Context, userID user.UserID) (*goal.Goal, error) {
 	query := `
 		SELECT id, user_id, target_weight, unit, target_date, description, active, created_at, updated_at
 		FROM goals 
-		WHERE user_id = ? AND active = TRUE
+		WHERE user_id = ? AND active = TRUE AND deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
@@ -99,7 +140,7 @@ func (r *goalRepository) FindActiveByUserID(userID user.UserID) (*goal.Goal, err
 		updatedAt    time.Time
 	)
 
-	err := r.db.QueryRow(query, userID.String()).Scan(
+	err := r.db.QueryRowContext(ctx, query, userID.String()).Scan(
 		&goalID, &uid, &targetWeight, &unit, &targetDate, &description, &active, &createdAt, &updatedAt,
 	)
 
@@ -113,15 +154,15 @@ func (r *goalRepository) FindActiveByUserID(userID user.UserID) (*goal.Goal, err
 	return r.scanGoal(goalID, uid, targetWeight, unit, targetDate, description, active, createdAt, updatedAt)
 }
 
-func (r *goalRepository) FindByUserID(userID user.UserID) ([]*goal.Goal, error) {
+func (r *goalRepository) FindByUserID(ctx context.Context, userID user.UserID) ([]*goal.Goal, error) {
 	query := `
 		SELECT id, user_id, target_weight, unit, target_date, description, active, created_at, updated_at
 		FROM goals 
-		WHERE user_id = ?
+		WHERE user_id = ? AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID.String())
+	rows, err := r.db.QueryContext(ctx, query, userID.String())
 	if err != nil {
 		return nil, fmt.Errorf("failed to query goals by user ID: %w", err)
 	}
@@ -130,14 +171,14 @@ func (r *goalRepository) FindByUserID(userID user.UserID) ([]*goal.Goal, error)
 	return r.scanGoals(rows)
 }
 
-func (r *goalRepository) DeactivateByUserID(userID user.UserID) error {
+func (r *goalRepository) DeactivateByUserID(ctx context.Context, userID user.UserID) error {
 	query := `
 		UPDATE goals 
 		SET active = FALSE, updated_at = CURRENT_TIMESTAMP
-		WHERE user_id = ? AND active = TRUE
+		WHERE user_id = ? AND active = TRUE AND deleted_at IS NULL
 	`
 
-	_, err := r.db.Exec(query, userID.String())
+	_, err := r.db.ExecContext(ctx, query, userID.String())
 	if err != nil {
 		return fmt.Errorf("failed to deactivate goals for user: %w", err)
 	}
@@ -145,10 +186,12 @@ func (r *goalRepository) DeactivateByUserID(userID user.UserID) error {
 	return nil
 }
 
-func (r *goalRepository) Delete(id goal.GoalID) error {
-	query := `DELETE FROM goals WHERE id = ?`
+// Delete moves a goal to the trash; Restore brings it back until
+// PurgeDeleted removes it for good.
+func (r *goalRepository) Delete(ctx context.Context, id goal.GoalID) error {
+	query := `UPDATE goals SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
 
-	result, err := r.db.Exec(query, id.String())
+	result, err := r.db.ExecContext(ctx, query, time.Now().UTC(), id.String())
 	if err != nil {
 		return fmt.Errorf("failed to delete goal: %w", err)
 	}
@@ -165,6 +208,47 @@ func (r *goalRepository) Delete(id goal.GoalID) error {
 	return nil
 }
 
+// Restore fails with interfaces.ErrConflict when the goal is active and the
+// user has set another active goal since deleting it.
+func (r *goalRepository) Restore(ctx context.Context, userID user.UserID, id goal.GoalID) error {
+	query := `UPDATE goals SET deleted_at = NULL WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id.String(), userID.String())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to restore goal: %w", interfaces.ErrConflict)
+		}
+		return fmt.Errorf("failed to restore goal: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted goal not found: %s", id.String())
+	}
+
+	return nil
+}
+
+func (r *goalRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM goals WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+
+	result, err := r.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted goals: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
 func (r *goalRepository) scanGoals(rows *sql.Rows) ([]*goal.Goal, error) {
 	var goals []*goal.Goal
 
@@ -224,14 +308,14 @@ func (r *goalRepository) scanGoal(id, userIDStr string, targetWeight float64, un
 		targetDateValue = goal.TargetDate{} // This will need to be adjusted
 	}
 
-	g, err := goal.NewGoal(id, userID, weightValue, unit, targetDateValue, description)
+	goalID, err := goal.NewGoalID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create goal from database row: %w", err)
+		return nil, fmt.Errorf("invalid goal ID from database: %w", err)
 	}
 
-	if !active {
-		g.Deactivate()
+	if targetDateValue.IsZero() {
+		return nil, fmt.Errorf("failed to create goal from database row: %w", goal.ErrZeroTargetDate)
 	}
 
-	return g, nil
+	return goal.ReconstructGoal(goalID, userID, weightValue, unit, targetDateValue, description, active, createdAt, updatedAt), nil
 }
@@ -0,0 +1,93 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"peso/internal/domain/idempotency"
+	"peso/internal/domain/user"
+	"peso/internal/interfaces"
+)
+
+type idempotencyRepository struct {
+	db dbtx
+}
+
+func NewIdempotencyRepository(db *DB) interfaces.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Find(ctx context.Context, userID user.UserID, key string) (*idempotency.Record, error) {
+	query := `
+		SELECT request_hash, resource_id, status_code, response_headers, response, created_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND idempotency_key = ?
+	`
+
+	var (
+		requestHash, resourceID, headersJSON, response string
+		statusCode                                     int
+		createdAt                                      time.Time
+	)
+	err := r.db.QueryRowContext(ctx, query, userID.String(), key).Scan(&requestHash, &resourceID, &statusCode, &headersJSON, &response, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+
+	var headers map[string]string
+	if headersJSON != "" {
+		if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
+			return nil, fmt.Errorf("failed to decode idempotency response headers: %w", err)
+		}
+	}
+
+	return idempotency.ReconstructRecord(userID, key, requestHash, resourceID, statusCode, headers, []byte(response), createdAt), nil
+}
+
+func (r *idempotencyRepository) Save(ctx context.Context, rec *idempotency.Record) error {
+	query := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, resource_id, status_code, response_headers, response, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	headersJSON := ""
+	if len(rec.Headers()) > 0 {
+		encoded, err := json.Marshal(rec.Headers())
+		if err != nil {
+			return fmt.Errorf("failed to encode idempotency response headers: %w", err)
+		}
+		headersJSON = string(encoded)
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		rec.UserID().String(),
+		rec.Key(),
+		rec.RequestHash(),
+		rec.ResourceID(),
+		rec.StatusCode(),
+		headersJSON,
+		string(rec.Response()),
+		rec.CreatedAt().UTC(),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to save idempotency key: %w", interfaces.ErrConflict)
+		}
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
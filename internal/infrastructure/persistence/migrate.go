@@ -0,0 +1,399 @@
+package persistence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+// ErrMigrationModified is returned when an applied migration no longer
+// matches the checksum recorded when it ran.
+var ErrMigrationModified = errors.New("applied migration was modified")
+
+// Migration is a schema change loaded from NNN_name.up.sql and its optional
+// NNN_name.down.sql counterpart.
+type Migration struct {
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes a migration against the current database.
+type MigrationStatus struct {
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Modified is set when the up file changed after it was applied.
+	Modified bool
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// LoadMigrations reads all migrations from fsys sorted by name.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
+	}
+
+	byName := map[string]*Migration{}
+	for _, file := range files {
+		var name string
+		var down bool
+		switch {
+		case strings.HasSuffix(file, upSuffix):
+			name = strings.TrimSuffix(file, upSuffix)
+		case strings.HasSuffix(file, downSuffix):
+			name, down = strings.TrimSuffix(file, downSuffix), true
+		default:
+			return nil, fmt.Errorf("migration %s must end in %s or %s", file, upSuffix, downSuffix)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", file, err)
+		}
+
+		m, ok := byName[name]
+		if !ok {
+			m = &Migration{Name: name}
+			byName[name] = m
+		}
+		if down {
+			m.Down = string(content)
+		} else {
+			m.Up = string(content)
+			m.Checksum = checksum(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byName))
+	for name, m := range byName {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %s has no %s file", name, upSuffix)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Name < migrations[j].Name
+	})
+	return migrations, nil
+}
+
+// Migrate applies every pending migration from fsys. Each migration and its
+// bookkeeping row are committed together, so a failure leaves no partial
+// state behind.
+func (db *DB) Migrate(migrationsFS fs.FS) error {
+	migrations, applied, err := db.loadMigrationState(migrationsFS)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.Name]; ok {
+			continue
+		}
+
+		if err := db.runMigration(m.Up, "INSERT INTO migrations (name, checksum) VALUES (?, ?)", m.Name, m.Checksum); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.Name, err)
+		}
+		fmt.Printf("Applied migration: %s\n", m.Name)
+	}
+
+	return nil
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first.
+func (db *DB) MigrateDown(migrationsFS fs.FS, steps int) error {
+	migrations, _, err := db.loadMigrationState(migrationsFS)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]Migration, len(migrations))
+	for _, m := range migrations {
+		byName[m.Name] = m
+	}
+
+	rows, err := db.QueryContext(context.Background(), "SELECT name FROM migrations ORDER BY id DESC LIMIT ?", steps)
+	if err != nil {
+		return fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan migration: %w", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+
+	for _, name := range names {
+		m, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("applied migration %s not found", name)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			return fmt.Errorf("migration %s has no %s file", name, downSuffix)
+		}
+
+		if err := db.runMigration(m.Down, "DELETE FROM migrations WHERE name = ?", m.Name); err != nil {
+			return fmt.Errorf("failed to revert migration %s: %w", m.Name, err)
+		}
+		fmt.Printf("Reverted migration: %s\n", m.Name)
+	}
+
+	return nil
+}
+
+// MigrationStatus reports every migration in fsys and whether it has run.
+func (db *DB) MigrationStatus(migrationsFS fs.FS) ([]MigrationStatus, error) {
+	if err := db.createMigrationsTable(); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	migrations, err := LoadMigrations(migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Name: m.Name}
+		if a, ok := applied[m.Name]; ok {
+			status.Applied = true
+			status.AppliedAt = a.appliedAt
+			status.Modified = a.checksum != "" && a.checksum != m.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// PendingMigrations lists the migrations in fsys that have not been applied,
+// and fails if an applied one was modified afterwards. Unlike Migrate and
+// MigrationStatus it never writes, so it is safe to call while serving.
+func (db *DB) PendingMigrations(ctx context.Context, migrationsFS fs.FS) ([]string, error) {
+	migrations, err := LoadMigrations(migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, m := range migrations {
+		a, ok := applied[m.Name]
+		if !ok {
+			pending = append(pending, m.Name)
+			continue
+		}
+		if a.checksum != "" && a.checksum != m.Checksum {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationModified, m.Name)
+		}
+	}
+	return pending, nil
+}
+
+// loadMigrationState reads the migration files and the applied set, and
+// refuses to continue if an applied migration was edited afterwards.
+// Rows recorded before checksums existed are backfilled.
+func (db *DB) loadMigrationState(migrationsFS fs.FS) ([]Migration, map[string]appliedMigration, error) {
+	if err := db.createMigrationsTable(); err != nil {
+		return nil, nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	migrations, err := LoadMigrations(migrationsFS)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, err := db.appliedMigrations(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, m := range migrations {
+		a, ok := applied[m.Name]
+		if !ok {
+			continue
+		}
+		if a.checksum == "" {
+			if _, err := db.ExecContext(context.Background(), "UPDATE migrations SET checksum = ? WHERE name = ?", m.Checksum, m.Name); err != nil {
+				return nil, nil, fmt.Errorf("failed to record checksum for %s: %w", m.Name, err)
+			}
+			continue
+		}
+		if a.checksum != m.Checksum {
+			return nil, nil, fmt.Errorf("%w: %s", ErrMigrationModified, m.Name)
+		}
+	}
+
+	return migrations, applied, nil
+}
+
+func (db *DB) createMigrationsTable() error {
+	ctx := context.Background()
+
+	if db.dialect == DialectPostgres {
+		_, err := db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS migrations (
+				id SERIAL PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE,
+				checksum TEXT NOT NULL DEFAULT '',
+				applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)
+		`)
+		return err
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS migrations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			checksum TEXT NOT NULL DEFAULT '',
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	// Tables created before checksums were tracked lack the column.
+	var count int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_table_info('migrations') WHERE name = 'checksum'").Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		_, err = db.ExecContext(ctx, "ALTER TABLE migrations ADD COLUMN checksum TEXT NOT NULL DEFAULT ''")
+	}
+	return err
+}
+
+func (db *DB) appliedMigrations(ctx context.Context) (map[string]appliedMigration, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, checksum, applied_at FROM migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[string]appliedMigration{}
+	for rows.Next() {
+		var name string
+		var a appliedMigration
+		if err := rows.Scan(&name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[name] = a
+	}
+	return applied, rows.Err()
+}
+
+// runMigration executes script and the bookkeeping statement in a single
+// transaction.
+func (db *DB) runMigration(script, bookkeeping string, args ...any) error {
+	ctx := context.Background()
+	sqlTx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	// Scripts run verbatim; only the bookkeeping statement needs rebinding.
+	if strings.TrimSpace(script) != "" {
+		if _, err := sqlTx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	tx := observedTx{Tx: sqlTx, db: db}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return fmt.Errorf("failed to update migrations table: %w", err)
+	}
+
+	return sqlTx.Commit()
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	migrationPrefix = regexp.MustCompile(`^(\d+)_`)
+	nonWord         = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// CreateMigration writes an empty up/down pair in dir, numbered after the
+// highest existing migration.
+func CreateMigration(dir, name string) (string, string, error) {
+	slug := strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "", "", errors.New("migration name is required")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	next := 1
+	for _, entry := range entries {
+		match := migrationPrefix.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(match[1]); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+
+	base := fmt.Sprintf("%03d_%s", next, slug)
+	upPath := filepath.Join(dir, base+upSuffix)
+	downPath := filepath.Join(dir, base+downSuffix)
+
+	for _, path := range []string{upPath, downPath} {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create migration file: %w", err)
+		}
+		_, werr := fmt.Fprintf(f, "-- %s\n", base)
+		if cerr := f.Close(); werr == nil {
+			werr = cerr
+		}
+		if werr != nil {
+			return "", "", fmt.Errorf("failed to write migration file: %w", werr)
+		}
+	}
+
+	return upPath, downPath, nil
+}
@@ -0,0 +1,247 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func setupEmptyDB(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := NewDB(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *DB, name string) bool {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	if err != nil {
+		t.Fatalf("failed to query schema: %v", err)
+	}
+	return count > 0
+}
+
+func TestMigrate_RepositoryMigrationsRoundTrip(t *testing.T) {
+	db := setupEmptyDB(t)
+	migrations := os.DirFS("../../../migrations/sqlite")
+
+	if err := db.Migrate(migrations); err != nil {
+		t.Fatalf("failed to migrate up: %v", err)
+	}
+
+	all, err := LoadMigrations(migrations)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	for _, m := range all {
+		if m.Down == "" {
+			t.Errorf("expected down migration for %s", m.Name)
+		}
+	}
+
+	if err := db.MigrateDown(migrations, len(all)); err != nil {
+		t.Fatalf("failed to migrate down: %v", err)
+	}
+	for _, table := range []string{"users", "weights", "goals", "sessions"} {
+		if tableExists(t, db, table) {
+			t.Errorf("expected table %s to be dropped", table)
+		}
+	}
+
+	if err := db.Migrate(migrations); err != nil {
+		t.Fatalf("failed to migrate up again: %v", err)
+	}
+	if !tableExists(t, db, "sessions") {
+		t.Error("expected sessions table after re-applying migrations")
+	}
+}
+
+func TestMigrate_DetectsModifiedMigration(t *testing.T) {
+	db := setupEmptyDB(t)
+	migrations := fstest.MapFS{
+		"001_things.up.sql":   {Data: []byte("CREATE TABLE things (id TEXT)")},
+		"001_things.down.sql": {Data: []byte("DROP TABLE things")},
+	}
+
+	if err := db.Migrate(migrations); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	migrations["001_things.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE things (id TEXT, name TEXT)")}
+
+	if err := db.Migrate(migrations); !errors.Is(err, ErrMigrationModified) {
+		t.Errorf("expected ErrMigrationModified, got %v", err)
+	}
+
+	statuses, err := db.MigrationStatus(migrations)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if len(statuses) != 1 || !statuses[0].Modified {
+		t.Errorf("expected modified status, got %+v", statuses)
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	db := setupEmptyDB(t)
+	ctx := context.Background()
+	migrations := fstest.MapFS{
+		"001_things.up.sql": {Data: []byte("CREATE TABLE things (id TEXT)")},
+	}
+
+	if err := db.Migrate(migrations); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	pending, err := db.PendingMigrations(ctx, migrations)
+	if err != nil {
+		t.Fatalf("failed to list pending migrations: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected no pending migrations, got %v", pending)
+	}
+
+	migrations["002_more.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE more (id TEXT)")}
+	pending, err = db.PendingMigrations(ctx, migrations)
+	if err != nil {
+		t.Fatalf("failed to list pending migrations: %v", err)
+	}
+	if len(pending) != 1 || pending[0] != "002_more" {
+		t.Errorf("expected [002_more] pending, got %v", pending)
+	}
+
+	migrations["001_things.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE things (id TEXT, name TEXT)")}
+	if _, err := db.PendingMigrations(ctx, migrations); !errors.Is(err, ErrMigrationModified) {
+		t.Errorf("expected ErrMigrationModified, got %v", err)
+	}
+}
+
+func TestMigrate_FailedMigrationIsNotRecorded(t *testing.T) {
+	db := setupEmptyDB(t)
+	migrations := fstest.MapFS{
+		"001_ok.up.sql":     {Data: []byte("CREATE TABLE ok (id TEXT)")},
+		"002_broken.up.sql": {Data: []byte("CREATE TABLE half (id TEXT);\nNOT VALID SQL")},
+	}
+
+	if err := db.Migrate(migrations); err == nil {
+		t.Fatal("expected error for broken migration")
+	}
+
+	if tableExists(t, db, "half") {
+		t.Error("expected partial migration to be rolled back")
+	}
+
+	statuses, err := db.MigrationStatus(migrations)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("expected only 001 applied, got %+v", statuses)
+	}
+}
+
+func TestMigrate_BackfillsLegacyMigrationsTable(t *testing.T) {
+	db := setupEmptyDB(t)
+	_, err := db.Exec(`
+		CREATE TABLE migrations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE things (id TEXT);
+		INSERT INTO migrations (name) VALUES ('001_things');
+	`)
+	if err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	migrations := fstest.MapFS{
+		"001_things.up.sql": {Data: []byte("CREATE TABLE things (id TEXT)")},
+	}
+	if err := db.Migrate(migrations); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	var stored string
+	if err := db.QueryRow("SELECT checksum FROM migrations WHERE name = '001_things'").Scan(&stored); err != nil {
+		t.Fatalf("failed to read checksum: %v", err)
+	}
+	if stored != checksum([]byte("CREATE TABLE things (id TEXT)")) {
+		t.Errorf("expected checksum to be backfilled, got %q", stored)
+	}
+}
+
+func TestMigrateDown_RequiresDownFile(t *testing.T) {
+	db := setupEmptyDB(t)
+	migrations := fstest.MapFS{
+		"001_things.up.sql": {Data: []byte("CREATE TABLE things (id TEXT)")},
+	}
+
+	if err := db.Migrate(migrations); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.MigrateDown(migrations, 1); err == nil {
+		t.Error("expected error when down migration is missing")
+	}
+	if !tableExists(t, db, "things") {
+		t.Error("expected table to remain")
+	}
+}
+
+func TestLoadMigrations_RejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name:  "unversioned suffix",
+			files: fstest.MapFS{"001_things.sql": {Data: []byte("SELECT 1")}},
+		},
+		{
+			name:  "down without up",
+			files: fstest.MapFS{"001_things.down.sql": {Data: []byte("SELECT 1")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadMigrations(tt.files); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "007_existing.up.sql"), nil, 0o644); err != nil {
+		t.Fatalf("failed to seed directory: %v", err)
+	}
+
+	up, down, err := CreateMigration(dir, "Add Weight Tags")
+	if err != nil {
+		t.Fatalf("failed to create migration: %v", err)
+	}
+
+	if filepath.Base(up) != "008_add_weight_tags.up.sql" {
+		t.Errorf("expected 008_add_weight_tags.up.sql, got %s", filepath.Base(up))
+	}
+	if filepath.Base(down) != "008_add_weight_tags.down.sql" {
+		t.Errorf("expected 008_add_weight_tags.down.sql, got %s", filepath.Base(down))
+	}
+
+	// A comment-only scaffold must apply cleanly.
+	db := setupEmptyDB(t)
+	if err := db.Migrate(os.DirFS(dir)); err != nil {
+		t.Errorf("failed to apply scaffolded migration: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -11,25 +12,38 @@ import (
 )
 
 type sessionRepository struct {
-	db *DB
+	db dbtx
 }
 
 func NewSessionRepository(db *DB) interfaces.SessionRepository {
 	return &sessionRepository{db: db}
 }
 
-func (r *sessionRepository) Save(s *session.Session) error {
+const sessionColumns = `id, user_id, token_hash, user_agent, ip_address, device_name, expires_at, created_at, last_seen_at`
+
+func (r *sessionRepository) Save(ctx context.Context, s *session.Session) error {
 	query := `
-		INSERT OR REPLACE INTO sessions (id, user_id, token, expires_at, created_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO sessions (` + sessionColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			user_agent = excluded.user_agent,
+			ip_address = excluded.ip_address,
+			device_name = excluded.device_name,
+			expires_at = excluded.expires_at,
+			last_seen_at = excluded.last_seen_at
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		s.ID().String(),
 		s.UserID().String(),
-		s.Token(),
+		s.TokenHash(),
+		s.UserAgent(),
+		s.IPAddress(),
+		s.DeviceName(),
 		s.ExpiresAt(),
 		s.CreatedAt(),
+		s.LastSeenAt(),
 	)
 
 	if err != nil {
@@ -39,22 +53,24 @@ func (r *sessionRepository) Save(s *session.Session) error {
 	return nil
 }
 
-func (r *sessionRepository) FindByToken(token string) (*session.Session, error) {
-	query := `
-		SELECT id, user_id, token, expires_at, created_at
-		FROM sessions
-		WHERE token = ?
-	`
+func (r *sessionRepository) FindByID(ctx context.Context, id session.SessionID) (*session.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = ?`
 
-	var (
-		id        string
-		userID    string
-		tkn       string
-		expiresAt time.Time
-		createdAt time.Time
-	)
+	s, err := r.scanSession(r.db.QueryRowContext(ctx, query, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+
+	return s, nil
+}
 
-	err := r.db.QueryRow(query, token).Scan(&id, &userID, &tkn, &expiresAt, &createdAt)
+func (r *sessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*session.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE token_hash = ?`
+
+	s, err := r.scanSession(r.db.QueryRowContext(ctx, query, tokenHash))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("session not found")
@@ -62,23 +78,54 @@ func (r *sessionRepository) FindByToken(token string) (*session.Session, error)
 		return nil, fmt.Errorf("failed to find session: %w", err)
 	}
 
-	sessionID, err := session.ParseSessionID(id)
+	return s, nil
+}
+
+func (r *sessionRepository) FindActiveByUserID(ctx context.Context, userID user.UserID) ([]*session.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = ? AND expires_at >= ?
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID.String(), time.Now())
 	if err != nil {
-		return nil, fmt.Errorf("invalid session ID: %w", err)
+		return nil, fmt.Errorf("failed to query user sessions: %w", err)
 	}
+	defer rows.Close()
 
-	uid, err := user.NewUserID(userID)
+	var sessions []*session.Session
+	for rows.Next() {
+		s, err := r.scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session row: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over session rows: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) DeleteByID(ctx context.Context, id session.SessionID) error {
+	query := `DELETE FROM sessions WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, id.String())
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID: %w", err)
+		return fmt.Errorf("failed to delete session: %w", err)
 	}
 
-	return session.ReconstructSession(sessionID, uid, tkn, expiresAt, createdAt), nil
+	return nil
 }
 
-func (r *sessionRepository) DeleteByToken(token string) error {
-	query := `DELETE FROM sessions WHERE token = ?`
+func (r *sessionRepository) DeleteByTokenHash(ctx context.Context, tokenHash string) error {
+	query := `DELETE FROM sessions WHERE token_hash = ?`
 
-	_, err := r.db.Exec(query, token)
+	_, err := r.db.ExecContext(ctx, query, tokenHash)
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
@@ -86,10 +133,10 @@ func (r *sessionRepository) DeleteByToken(token string) error {
 	return nil
 }
 
-func (r *sessionRepository) DeleteByUserID(userID user.UserID) error {
+func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID user.UserID) error {
 	query := `DELETE FROM sessions WHERE user_id = ?`
 
-	_, err := r.db.Exec(query, userID.String())
+	_, err := r.db.ExecContext(ctx, query, userID.String())
 	if err != nil {
 		return fmt.Errorf("failed to delete user sessions: %w", err)
 	}
@@ -97,13 +144,79 @@ func (r *sessionRepository) DeleteByUserID(userID user.UserID) error {
 	return nil
 }
 
-func (r *sessionRepository) DeleteExpired() error {
-	query := `DELETE FROM sessions WHERE expires_at < ?`
+func (r *sessionRepository) DeleteByUserIDExcept(ctx context.Context, userID user.UserID, keep session.SessionID) error {
+	query := `DELETE FROM sessions WHERE user_id = ? AND id <> ?`
 
-	_, err := r.db.Exec(query, time.Now())
+	_, err := r.db.ExecContext(ctx, query, userID.String(), keep.String())
 	if err != nil {
-		return fmt.Errorf("failed to delete expired sessions: %w", err)
+		return fmt.Errorf("failed to delete other user sessions: %w", err)
 	}
 
 	return nil
 }
+
+func (r *sessionRepository) CountActive(ctx context.Context) (int64, error) {
+	query := `SELECT COUNT(*) FROM sessions WHERE expires_at >= ?`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query, time.Now()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count active sessions: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *sessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM sessions WHERE expires_at < ?`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (r *sessionRepository) scanSession(row rowScanner) (*session.Session, error) {
+	var (
+		id         string
+		userID     string
+		tokenHash  string
+		userAgent  string
+		ipAddress  string
+		deviceName string
+		expiresAt  time.Time
+		createdAt  time.Time
+		lastSeenAt sql.NullTime
+	)
+
+	if err := row.Scan(&id, &userID, &tokenHash, &userAgent, &ipAddress, &deviceName, &expiresAt, &createdAt, &lastSeenAt); err != nil {
+		return nil, err
+	}
+
+	sessionID, err := session.ParseSessionID(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	uid, err := user.NewUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	seen := createdAt
+	if lastSeenAt.Valid {
+		seen = lastSeenAt.Time
+	}
+
+	return session.ReconstructSession(sessionID, uid, tokenHash, userAgent, ipAddress, deviceName, expiresAt, createdAt, seen), nil
+}
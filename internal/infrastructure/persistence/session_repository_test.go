@@ -0,0 +1,153 @@
+package persistence
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"peso/internal/domain/session"
+	"peso/internal/domain/user"
+)
+
+// migrationsUpTo returns the repository migrations whose name sorts before
+// or equal to last, so tests can stop the schema at a given version.
+func migrationsUpTo(t *testing.T, last string) fs.FS {
+	t.Helper()
+
+	all := os.DirFS("../../../migrations/sqlite")
+	files, err := fs.Glob(all, "*.sql")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+
+	subset := fstest.MapFS{}
+	for _, f := range files {
+		name := strings.TrimSuffix(strings.TrimSuffix(f, upSuffix), downSuffix)
+		if name > last {
+			continue
+		}
+		content, err := fs.ReadFile(all, f)
+		if err != nil {
+			t.Fatalf("failed to read migration %s: %v", f, err)
+		}
+		subset[f] = &fstest.MapFile{Data: content}
+	}
+	return subset
+}
+
+func setupMigratedDB(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := NewDB(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	if err := db.Migrate(os.DirFS("../../../migrations/sqlite")); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestSessionRepository_StoresOnlyTokenHash(t *testing.T) {
+	db := setupMigratedDB(t)
+	defer db.Close()
+
+	repo := NewSessionRepository(db)
+
+	sess, err := session.NewSession(user.UserID("giada"), session.ClientInfo{UserAgent: "curl/8.7.1"}, session.DefaultLifetime)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if err := repo.Save(context.Background(), sess); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	var stored string
+	if err := db.QueryRow("SELECT token_hash FROM sessions WHERE id = ?", sess.ID().String()).Scan(&stored); err != nil {
+		t.Fatalf("failed to read stored token: %v", err)
+	}
+	if stored == sess.Token() {
+		t.Fatal("plaintext token stored in database")
+	}
+
+	found, err := repo.FindByTokenHash(context.Background(), session.HashToken(sess.Token()))
+	if err != nil {
+		t.Fatalf("unexpected error finding session: %v", err)
+	}
+	if found.ID() != sess.ID() {
+		t.Errorf("expected session %s, got %s", sess.ID(), found.ID())
+	}
+	if !found.MatchesToken(sess.Token()) {
+		t.Error("expected stored session to match original token")
+	}
+}
+
+func TestSessionRepository_MigratesLegacyTokens(t *testing.T) {
+	db, err := NewDB(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Migrate(migrationsUpTo(t, "007_session_devices")); err != nil {
+		t.Fatalf("failed to apply legacy migrations: %v", err)
+	}
+
+	legacyToken := "legacy-plaintext-token"
+	_, err = db.Exec(
+		`INSERT INTO sessions (id, user_id, token, expires_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		session.NewSessionID().String(), "giada", legacyToken, time.Now().Add(time.Hour), time.Now(),
+	)
+	if err != nil {
+		t.Fatalf("failed to insert legacy session: %v", err)
+	}
+
+	if err := db.Migrate(os.DirFS("../../../migrations/sqlite")); err != nil {
+		t.Fatalf("failed to apply remaining migrations: %v", err)
+	}
+
+	repo := NewSessionRepository(db)
+	found, err := repo.FindByTokenHash(context.Background(), session.HashToken(legacyToken))
+	if err != nil {
+		t.Fatalf("legacy session not found by hash: %v", err)
+	}
+	if !found.MatchesToken(legacyToken) {
+		t.Error("expected migrated session to match legacy token")
+	}
+}
+
+func TestSessionRepository_DeleteExpired(t *testing.T) {
+	db := setupMigratedDB(t)
+	defer db.Close()
+
+	repo := NewSessionRepository(db)
+	uid := user.UserID("giada")
+
+	live := session.ReconstructSession(session.NewSessionID(), uid, session.HashToken("live"), "", "", "", time.Now().Add(time.Hour), time.Now(), time.Now())
+	expired := session.ReconstructSession(session.NewSessionID(), uid, session.HashToken("expired"), "", "", "", time.Now().Add(-time.Hour), time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour))
+
+	for _, s := range []*session.Session{live, expired} {
+		if err := repo.Save(context.Background(), s); err != nil {
+			t.Fatalf("failed to save session: %v", err)
+		}
+	}
+
+	deleted, err := repo.DeleteExpired(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 expired session deleted, got %d", deleted)
+	}
+
+	if _, err := repo.FindByID(context.Background(), live.ID()); err != nil {
+		t.Errorf("expected live session to remain: %v", err)
+	}
+	if _, err := repo.FindByID(context.Background(), expired.ID()); err == nil {
+		t.Error("expected expired session to be deleted")
+	}
+}
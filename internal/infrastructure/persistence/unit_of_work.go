@@ -0,0 +1,140 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"peso/internal/interfaces"
+)
+
+// dbtx is satisfied by both *DB and observedTx, so repositories work the
+// same inside and outside a unit of work.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	Dialect() Dialect
+}
+
+// observedTx applies the DB's query observation to statements run inside a
+// transaction.
+type observedTx struct {
+	*sql.Tx
+	db *DB
+}
+
+func (tx observedTx) Dialect() Dialect {
+	return tx.db.dialect
+}
+
+func (tx observedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, done := tx.db.observe(ctx, query)
+	result, err := tx.Tx.ExecContext(ctx, tx.db.rebind(query), args...)
+	done(err)
+	return result, err
+}
+
+func (tx observedTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, done := tx.db.observe(ctx, query)
+	rows, err := tx.Tx.QueryContext(ctx, tx.db.rebind(query), args...)
+	done(err)
+	return rows, err
+}
+
+func (tx observedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, done := tx.db.observe(ctx, query)
+	row := tx.Tx.QueryRowContext(ctx, tx.db.rebind(query), args...)
+	done(row.Err())
+	return row
+}
+
+// maxTxAttempts bounds how often a unit of work is retried after Postgres
+// aborts it with a serialization failure.
+const maxTxAttempts = 3
+
+// Postgres SQLSTATE codes inspected by this package.
+const (
+	pgUniqueViolation      = "23505"
+	pgSerializationFailure = "40001"
+)
+
+// Do implements interfaces.UnitOfWork. SQLite serializes transactions with
+// an immediate write lock; Postgres runs them SERIALIZABLE and fn is retried
+// when a concurrent unit of work wins, so fn must not have side effects
+// outside repos.
+func (db *DB) Do(ctx context.Context, fn func(repos interfaces.Repositories) error) error {
+	// The span covers the whole transaction including commit and retries.
+	// Repositories run with the caller's context, so their statements show
+	// up beside it rather than beneath it.
+	if trace.SpanFromContext(ctx).IsRecording() {
+		var span trace.Span
+		ctx, span = tracer.Start(ctx, "UnitOfWork.Do")
+		defer span.End()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := db.do(ctx, fn)
+		if err == nil || attempt == maxTxAttempts || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+func (db *DB) do(ctx context.Context, fn func(repos interfaces.Repositories) error) error {
+	var opts *sql.TxOptions
+	if db.dialect == DialectPostgres {
+		opts = &sql.TxOptions{Isolation: sql.LevelSerializable}
+	}
+
+	sqlTx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	tx := observedTx{Tx: sqlTx, db: db}
+	repos := interfaces.Repositories{
+		Users:             &userRepository{db: tx},
+		Weights:           &weightRepository{db: tx},
+		Goals:             &goalRepository{db: tx},
+		Sessions:          &sessionRepository{db: tx},
+		Audit:             &auditRepository{db: tx},
+		Webhooks:          &webhookRepository{db: tx},
+		WebhookDeliveries: &webhookDeliveryRepository{db: tx},
+		Idempotency:       &idempotencyRepository{db: tx},
+	}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err was caused by a UNIQUE or PRIMARY
+// KEY constraint.
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code()
+		return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+// isSerializationFailure reports whether Postgres aborted a transaction
+// because it conflicted with a concurrent one.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgSerializationFailure
+}
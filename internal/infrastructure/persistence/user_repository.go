@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -10,7 +11,7 @@ import (
 )
 
 type userRepository struct {
-	db *DB
+	db dbtx
 }
 
 // NewUserRepository creates a new user repository
@@ -18,17 +19,25 @@ func NewUserRepository(db *DB) interfaces.UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Save(u *user.User) error {
+func (r *userRepository) Save(ctx context.Context, u *user.User) error {
 	query := `
-		INSERT OR REPLACE INTO users (id, name, email, password_hash, active, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO users (id, name, email, password_hash, language, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			email = excluded.email,
+			password_hash = excluded.password_hash,
+			language = excluded.language,
+			active = excluded.active,
+			updated_at = excluded.updated_at
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		u.ID().String(),
 		u.Name(),
 		u.Email(),
 		u.PasswordHash(),
+		u.Language(),
 		u.IsActive(),
 		u.CreatedAt(),
 		u.UpdatedAt(),
@@ -41,9 +50,9 @@ func (r *userRepository) Save(u *user.User) error {
 	return nil
 }
 
-func (r *userRepository) FindByID(id user.UserID) (*user.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id user.UserID) (*user.User, error) {
 	query := `
-		SELECT id, name, email, password_hash, active, created_at, updated_at
+		SELECT id, name, email, password_hash, language, active, created_at, updated_at
 		FROM users
 		WHERE id = ?
 	`
@@ -53,13 +62,14 @@ func (r *userRepository) FindByID(id user.UserID) (*user.User, error) {
 		name         string
 		email        string
 		passwordHash string
+		language     string
 		active       bool
 		createdAt    time.Time
 		updatedAt    time.Time
 	)
 
-	err := r.db.QueryRow(query, id.String()).Scan(
-		&userID, &name, &email, &passwordHash, &active, &createdAt, &updatedAt,
+	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
+		&userID, &name, &email, &passwordHash, &language, &active, &createdAt, &updatedAt,
 	)
 
 	if err != nil {
@@ -69,12 +79,12 @@ func (r *userRepository) FindByID(id user.UserID) (*user.User, error) {
 		return nil, fmt.Errorf("failed to find user by ID: %w", err)
 	}
 
-	return r.scanUser(userID, name, email, passwordHash, active, createdAt, updatedAt)
+	return r.scanUser(userID, name, email, passwordHash, language, active, createdAt, updatedAt)
 }
 
-func (r *userRepository) FindByName(name string) (*user.User, error) {
+func (r *userRepository) FindByName(ctx context.Context, name string) (*user.User, error) {
 	query := `
-		SELECT id, name, email, password_hash, active, created_at, updated_at
+		SELECT id, name, email, password_hash, language, active, created_at, updated_at
 		FROM users
 		WHERE name = ?
 	`
@@ -84,13 +94,14 @@ func (r *userRepository) FindByName(name string) (*user.User, error) {
 		userName     string
 		email        string
 		passwordHash string
+		language     string
 		active       bool
 		createdAt    time.Time
 		updatedAt    time.Time
 	)
 
-	err := r.db.QueryRow(query, name).Scan(
-		&userID, &userName, &email, &passwordHash, &active, &createdAt, &updatedAt,
+	err := r.db.QueryRowContext(ctx, query, name).Scan(
+		&userID, &userName, &email, &passwordHash, &language, &active, &createdAt, &updatedAt,
 	)
 
 	if err != nil {
@@ -100,12 +111,12 @@ func (r *userRepository) FindByName(name string) (*user.User, error) {
 		return nil, fmt.Errorf("failed to find user by name: %w", err)
 	}
 
-	return r.scanUser(userID, userName, email, passwordHash, active, createdAt, updatedAt)
+	return r.scanUser(userID, userName, email, passwordHash, language, active, createdAt, updatedAt)
 }
 
-func (r *userRepository) FindByEmail(email string) (*user.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
 	query := `
-		SELECT id, name, email, password_hash, active, created_at, updated_at
+		SELECT id, name, email, password_hash, language, active, created_at, updated_at
 		FROM users
 		WHERE email = ?
 	`
@@ -115,13 +126,14 @@ func (r *userRepository) FindByEmail(email string) (*user.User, error) {
 		userName     string
 		userEmail    string
 		passwordHash string
+		language     string
 		active       bool
 		createdAt    time.Time
 		updatedAt    time.Time
 	)
 
-	err := r.db.QueryRow(query, email).Scan(
-		&userID, &userName, &userEmail, &passwordHash, &active, &createdAt, &updatedAt,
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
+		&userID, &userName, &userEmail, &passwordHash, &language, &active, &createdAt, &updatedAt,
 	)
 
 	if err != nil {
@@ -131,18 +143,18 @@ func (r *userRepository) FindByEmail(email string) (*user.User, error) {
 		return nil, fmt.Errorf("failed to find user by email: %w", err)
 	}
 
-	return r.scanUser(userID, userName, userEmail, passwordHash, active, createdAt, updatedAt)
+	return r.scanUser(userID, userName, userEmail, passwordHash, language, active, createdAt, updatedAt)
 }
 
-func (r *userRepository) FindActive() ([]*user.User, error) {
+func (r *userRepository) FindActive(ctx context.Context) ([]*user.User, error) {
 	query := `
-		SELECT id, name, email, password_hash, active, created_at, updated_at
+		SELECT id, name, email, password_hash, language, active, created_at, updated_at
 		FROM users
 		WHERE active = TRUE
 		ORDER BY name
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query active users: %w", err)
 	}
@@ -156,17 +168,18 @@ func (r *userRepository) FindActive() ([]*user.User, error) {
 			name         string
 			email        string
 			passwordHash string
+			language     string
 			active       bool
 			createdAt    time.Time
 			updatedAt    time.Time
 		)
 
-		err := rows.Scan(&userID, &name, &email, &passwordHash, &active, &createdAt, &updatedAt)
+		err := rows.Scan(&userID, &name, &email, &passwordHash, &language, &active, &createdAt, &updatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user row: %w", err)
 		}
 
-		u, err := r.scanUser(userID, name, email, passwordHash, active, createdAt, updatedAt)
+		u, err := r.scanUser(userID, name, email, passwordHash, language, active, createdAt, updatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -181,11 +194,11 @@ func (r *userRepository) FindActive() ([]*user.User, error) {
 	return users, nil
 }
 
-func (r *userRepository) Exists(id user.UserID) (bool, error) {
+func (r *userRepository) Exists(ctx context.Context, id user.UserID) (bool, error) {
 	query := `SELECT COUNT(*) FROM users WHERE id = ?`
 
 	var count int
-	err := r.db.QueryRow(query, id.String()).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if user exists: %w", err)
 	}
@@ -193,11 +206,11 @@ func (r *userRepository) Exists(id user.UserID) (bool, error) {
 	return count > 0, nil
 }
 
-func (r *userRepository) EmailExists(email string) (bool, error) {
+func (r *userRepository) EmailExists(ctx context.Context, email string) (bool, error) {
 	query := `SELECT COUNT(*) FROM users WHERE email = ?`
 
 	var count int
-	err := r.db.QueryRow(query, email).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, email).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if email exists: %w", err)
 	}
@@ -205,13 +218,16 @@ func (r *userRepository) EmailExists(email string) (bool, error) {
 	return count > 0, nil
 }
 
-func (r *userRepository) scanUser(id, name, email, passwordHash string, active bool, createdAt, updatedAt time.Time) (*user.User, error) {
+func (r *userRepository) scanUser(id, name, email, passwordHash, language string, active bool, createdAt, updatedAt time.Time) (*user.User, error) {
 	u, err := user.NewUser(id, name, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user from database row: %w", err)
 	}
 
 	u.SetPasswordHash(passwordHash)
+	if err := u.SetLanguage(language); err != nil {
+		return nil, fmt.Errorf("failed to create user from database row: %w", err)
+	}
 
 	if !active {
 		u.Deactivate()
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"testing"
 
 	"peso/internal/domain/user"
@@ -22,6 +23,7 @@ func setupTestDB(t *testing.T) *DB {
 			name TEXT NOT NULL,
 			email TEXT DEFAULT '',
 			password_hash TEXT DEFAULT '',
+			language TEXT NOT NULL DEFAULT '',
 			active BOOLEAN NOT NULL DEFAULT TRUE,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -46,14 +48,14 @@ func TestUserRepository_Save(t *testing.T) {
 	}
 
 	// Test save
-	err = repo.Save(testUser)
+	err = repo.Save(context.Background(), testUser)
 	if err != nil {
 		t.Errorf("unexpected error saving user: %v", err)
 	}
 
 	// Test save again (update)
 	testUser.UpdateEmail("giada.updated@example.com")
-	err = repo.Save(testUser)
+	err = repo.Save(context.Background(), testUser)
 	if err != nil {
 		t.Errorf("unexpected error updating user: %v", err)
 	}
@@ -71,14 +73,14 @@ func TestUserRepository_FindByID(t *testing.T) {
 		t.Fatalf("failed to create test user: %v", err)
 	}
 
-	err = repo.Save(originalUser)
+	err = repo.Save(context.Background(), originalUser)
 	if err != nil {
 		t.Fatalf("failed to save test user: %v", err)
 	}
 
 	// Find by ID
 	userID, _ := user.NewUserID("giada")
-	foundUser, err := repo.FindByID(userID)
+	foundUser, err := repo.FindByID(context.Background(), userID)
 
 	if err != nil {
 		t.Errorf("unexpected error finding user: %v", err)
@@ -108,7 +110,7 @@ func TestUserRepository_FindByID_NotFound(t *testing.T) {
 	repo := NewUserRepository(db)
 
 	userID, _ := user.NewUserID("nonexistent")
-	foundUser, err := repo.FindByID(userID)
+	foundUser, err := repo.FindByID(context.Background(), userID)
 
 	if err == nil {
 		t.Error("expected error but got nil")
@@ -130,13 +132,13 @@ func TestUserRepository_FindByName(t *testing.T) {
 		t.Fatalf("failed to create test user: %v", err)
 	}
 
-	err = repo.Save(originalUser)
+	err = repo.Save(context.Background(), originalUser)
 	if err != nil {
 		t.Fatalf("failed to save test user: %v", err)
 	}
 
 	// Find by name
-	foundUser, err := repo.FindByName("Giada")
+	foundUser, err := repo.FindByName(context.Background(), "Giada")
 
 	if err != nil {
 		t.Errorf("unexpected error finding user: %v", err)
@@ -164,7 +166,7 @@ func TestUserRepository_FindActive(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create active user: %v", err)
 	}
-	err = repo.Save(activeUser)
+	err = repo.Save(context.Background(), activeUser)
 	if err != nil {
 		t.Fatalf("failed to save active user: %v", err)
 	}
@@ -175,13 +177,13 @@ func TestUserRepository_FindActive(t *testing.T) {
 		t.Fatalf("failed to create inactive user: %v", err)
 	}
 	inactiveUser.Deactivate()
-	err = repo.Save(inactiveUser)
+	err = repo.Save(context.Background(), inactiveUser)
 	if err != nil {
 		t.Fatalf("failed to save inactive user: %v", err)
 	}
 
 	// Find active users
-	activeUsers, err := repo.FindActive()
+	activeUsers, err := repo.FindActive(context.Background())
 
 	if err != nil {
 		t.Errorf("unexpected error finding active users: %v", err)
@@ -204,13 +206,13 @@ func TestUserRepository_Exists(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create test user: %v", err)
 	}
-	err = repo.Save(testUser)
+	err = repo.Save(context.Background(), testUser)
 	if err != nil {
 		t.Fatalf("failed to save test user: %v", err)
 	}
 
 	// Test exists
-	exists, err := repo.Exists(testUser.ID())
+	exists, err := repo.Exists(context.Background(), testUser.ID())
 	if err != nil {
 		t.Errorf("unexpected error checking existence: %v", err)
 	}
@@ -220,7 +222,7 @@ func TestUserRepository_Exists(t *testing.T) {
 
 	// Test doesn't exist
 	nonExistentID, _ := user.NewUserID("nonexistent")
-	exists, err = repo.Exists(nonExistentID)
+	exists, err = repo.Exists(context.Background(), nonExistentID)
 	if err != nil {
 		t.Errorf("unexpected error checking existence: %v", err)
 	}
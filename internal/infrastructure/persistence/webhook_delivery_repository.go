@@ -0,0 +1,177 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"peso/internal/domain/user"
+	"peso/internal/domain/webhook"
+	"peso/internal/interfaces"
+)
+
+type webhookDeliveryRepository struct {
+	db dbtx
+}
+
+func NewWebhookDeliveryRepository(db *DB) interfaces.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db}
+}
+
+const deliveryColumns = `id, webhook_id, user_id, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at`
+
+func (r *webhookDeliveryRepository) Enqueue(ctx context.Context, d *webhook.Delivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		d.ID(),
+		d.WebhookID().String(),
+		d.UserID().String(),
+		d.Event(),
+		string(d.Payload()),
+		string(d.Status()),
+		d.Attempts(),
+		d.NextAttemptAt().UTC(),
+		d.LastStatusCode(),
+		d.LastError(),
+		d.CreatedAt().UTC(),
+		d.UpdatedAt().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookDeliveryRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*webhook.Delivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at
+		LIMIT ?
+	`
+
+	due, err := r.find(ctx, query, string(webhook.StatusPending), now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The attempts count only changes when an attempt is recorded, so it
+	// tells whether another dispatcher got to the row first.
+	claim := `
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id = ? AND status = ? AND attempts = ? AND next_attempt_at <= ?
+	`
+
+	var claimed []*webhook.Delivery
+	for _, d := range due {
+		result, err := r.db.ExecContext(ctx, claim, leaseUntil.UTC(), d.ID(), string(webhook.StatusPending), d.Attempts(), now.UTC())
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 1 {
+			claimed = append(claimed, d)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (r *webhookDeliveryRepository) RecordAttempt(ctx context.Context, d *webhook.Delivery, a webhook.Attempt) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_attempts (delivery_id, attempt, attempted_at, status_code, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, a.DeliveryID, a.Number, a.AttemptedAt.UTC(), a.StatusCode, a.Error, a.Duration.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET
+			status = ?,
+			attempts = ?,
+			next_attempt_at = ?,
+			last_status_code = ?,
+			last_error = ?,
+			updated_at = ?
+		WHERE id = ?
+	`, string(d.Status()), d.Attempts(), d.NextAttemptAt().UTC(), d.LastStatusCode(), d.LastError(), d.UpdatedAt().UTC(), d.ID())
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookDeliveryRepository) FindByWebhookID(ctx context.Context, id webhook.WebhookID, limit int) ([]*webhook.Delivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+
+	return r.find(ctx, query, id.String(), limit)
+}
+
+func (r *webhookDeliveryRepository) find(ctx context.Context, query string, args ...any) ([]*webhook.Delivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*webhook.Delivery
+	for rows.Next() {
+		d, err := r.scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery row: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over webhook delivery rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepository) scanDelivery(row rowScanner) (*webhook.Delivery, error) {
+	var (
+		id, webhookID, userID, event, payload, status, lastError string
+		attempts, lastStatusCode                                 int
+		nextAttemptAt, createdAt, updatedAt                      time.Time
+	)
+
+	err := row.Scan(&id, &webhookID, &userID, &event, &payload, &status, &attempts, &nextAttemptAt, &lastStatusCode, &lastError, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedWebhookID, err := webhook.ParseWebhookID(webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook ID from database: %w", err)
+	}
+
+	return webhook.ReconstructDelivery(
+		id,
+		parsedWebhookID,
+		user.UserID(userID),
+		event,
+		[]byte(payload),
+		webhook.Status(status),
+		attempts,
+		nextAttemptAt,
+		lastStatusCode,
+		lastError,
+		createdAt,
+		updatedAt,
+	), nil
+}
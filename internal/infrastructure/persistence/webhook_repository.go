@@ -0,0 +1,112 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"peso/internal/domain/user"
+	"peso/internal/domain/webhook"
+	"peso/internal/interfaces"
+)
+
+type webhookRepository struct {
+	db dbtx
+}
+
+func NewWebhookRepository(db *DB) interfaces.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const webhookColumns = `id, user_id, url, secret, events, created_at`
+
+func (r *webhookRepository) Save(ctx context.Context, w *webhook.Webhook) error {
+	query := `
+		INSERT INTO webhooks (` + webhookColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			url = excluded.url,
+			secret = excluded.secret,
+			events = excluded.events
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		w.ID().String(),
+		w.UserID().String(),
+		w.URL(),
+		w.Secret(),
+		strings.Join(w.Events(), ","),
+		w.CreatedAt().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) FindByID(ctx context.Context, id webhook.WebhookID) (*webhook.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ?`
+
+	w, err := r.scanWebhook(r.db.QueryRowContext(ctx, query, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found: %s", id.String())
+		}
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+func (r *webhookRepository) FindByUserID(ctx context.Context, userID user.UserID) ([]*webhook.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = ? ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*webhook.Webhook
+	for rows.Next() {
+		w, err := r.scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook row: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over webhook rows: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id webhook.WebhookID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) scanWebhook(row rowScanner) (*webhook.Webhook, error) {
+	var (
+		id, userID, url, secret, events string
+		createdAt                       time.Time
+	)
+
+	if err := row.Scan(&id, &userID, &url, &secret, &events, &createdAt); err != nil {
+		return nil, err
+	}
+
+	webhookID, err := webhook.ParseWebhookID(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook ID from database: %w", err)
+	}
+
+	return webhook.ReconstructWebhook(webhookID, user.UserID(userID), url, secret, strings.Split(events, ","), createdAt), nil
+}
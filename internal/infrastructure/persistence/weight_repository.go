@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -11,7 +12,7 @@ import (
 )
 
 type weightRepository struct {
-	db *DB
+	db dbtx
 }
 
 // NewWeightRepository creates a new weight repository
@@ -19,13 +20,13 @@ func NewWeightRepository(db *DB) interfaces.WeightRepository {
 	return &weightRepository{db: db}
 }
 
-func (r *weightRepository) Save(w *weight.Weight) error {
+func (r *weightRepository) Save(ctx context.Context, w *weight.Weight) error {
 	query := `
-		INSERT OR REPLACE INTO weights (id, user_id, value, unit, measured_at, notes, created_at)
+		INSERT INTO weights (id, user_id, value, unit, measured_at, notes, created_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		w.ID().String(),
 		w.UserID().String(),
 		w.Value().Float64(),
@@ -42,11 +43,11 @@ func (r *weightRepository) Save(w *weight.Weight) error {
 	return nil
 }
 
-func (r *weightRepository) FindByID(id weight.WeightID) (*weight.Weight, error) {
+func (r *weightRepository) FindByID(ctx context.Context, id weight.WeightID) (*weight.Weight, error) {
 	query := `
 		SELECT id, user_id, value, unit, measured_at, notes, created_at 
 		FROM weights 
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	var (
@@ -59,7 +60,7 @@ func (r *weightRepository) FindByID(id weight.WeightID) (*weight.Weight, error)
 		createdAt  time.Time
 	)
 
-	err := r.db.QueryRow(query, id.String()).Scan(
+	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
 		&weightID, &userID, &value, &unit, &measuredAt, &notes, &createdAt,
 	)
 
@@ -73,16 +74,16 @@ func (r *weightRepository) FindByID(id weight.WeightID) (*weight.Weight, error)
 	return r.scanWeight(weightID, userID, value, unit, measuredAt, notes, createdAt)
 }
 
-func (r *weightRepository) FindByUserID(userID user.UserID, limit int) ([]*weight.Weight, error) {
+func (r *weightRepository) FindByUserID(ctx context.Context, userID user.UserID, limit int) ([]*weight.Weight, error) {
 	query := `
 		SELECT id, user_id, value, unit, measured_at, notes, created_at 
 		FROM weights 
-		WHERE user_id = ?
+		WHERE user_id = ? AND deleted_at IS NULL
 		ORDER BY measured_at DESC
 		LIMIT ?
 	`
 
-	rows, err := r.db.Query(query, userID.String(), limit)
+	rows, err := r.db.QueryContext(ctx, query, userID.String(), limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query weights by user ID: %w", err)
 	}
@@ -91,15 +92,15 @@ func (r *weightRepository) FindByUserID(userID user.UserID, limit int) ([]*weigh
 	return r.scanWeights(rows)
 }
 
-func (r *weightRepository) FindByUserIDAndPeriod(userID user.UserID, from, to time.Time) ([]*weight.Weight, error) {
+func (r *weightRepository) FindByUserIDAndPeriod(ctx context.Context, userID user.UserID, from, to time.Time) ([]*weight.Weight, error) {
 	query := `
 		SELECT id, user_id, value, unit, measured_at, notes, created_at 
 		FROM weights 
-		WHERE user_id = ? AND measured_at >= ? AND measured_at <= ?
+		WHERE user_id = ? AND measured_at >= ? AND measured_at <= ? AND deleted_at IS NULL
 		ORDER BY measured_at ASC
 	`
 
-	rows, err := r.db.Query(query, userID.String(), from, to)
+	rows, err := r.db.QueryContext(ctx, query, userID.String(), from, to)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query weights by user ID and period: %w", err)
 	}
@@ -108,11 +109,11 @@ func (r *weightRepository) FindByUserIDAndPeriod(userID user.UserID, from, to ti
 	return r.scanWeights(rows)
 }
 
-func (r *weightRepository) FindLatestByUserID(userID user.UserID) (*weight.Weight, error) {
+func (r *weightRepository) FindLatestByUserID(ctx context.Context, userID user.UserID) (*weight.Weight, error) {
 	query := `
 		SELECT id, user_id, value, unit, measured_at, notes, created_at 
 		FROM weights 
-		WHERE user_id = ?
+		WHERE user_id = ? AND deleted_at IS NULL
 		ORDER BY measured_at DESC
 		LIMIT 1
 	`
@@ -127,7 +128,7 @@ func (r *weightRepository) FindLatestByUserID(userID user.UserID) (*weight.Weigh
 		createdAt  time.Time
 	)
 
-	err := r.db.QueryRow(query, userID.String()).Scan(
+	err := r.db.QueryRowContext(ctx, query, userID.String()).Scan(
 		&weightID, &uid, &value, &unit, &measuredAt, &notes, &createdAt,
 	)
 
@@ -141,15 +142,31 @@ func (r *weightRepository) FindLatestByUserID(userID user.UserID) (*weight.Weigh
 	return r.scanWeight(weightID, uid, value, unit, measuredAt, notes, createdAt)
 }
 
-func (r *weightRepository) CountByUserIDAndDate(userID user.UserID, date time.Time) (int, error) {
+func (r *weightRepository) CountByUserIDAndDate(ctx context.Context, userID user.UserID, date time.Time) (int, error) {
+	// On SQLite measured_at is stored as Go's time.Time text ("2006-01-02
+	// 15:04:05 -0700 MST"), which DATE() cannot parse; its first ten
+	// characters are the calendar day in the measurement's own time zone.
 	query := `
 		SELECT COUNT(*) 
 		FROM weights 
-		WHERE user_id = ? AND DATE(measured_at) = DATE(?)
+		WHERE user_id = ? AND substr(measured_at, 1, 10) = ? AND deleted_at IS NULL
 	`
+	args := []any{userID.String(), date.Format("2006-01-02")}
+
+	// Postgres keeps a real timestamptz, so count the day as a range in the
+	// caller's time zone.
+	if r.db.Dialect() == DialectPostgres {
+		query = `
+			SELECT COUNT(*)
+			FROM weights
+			WHERE user_id = ? AND measured_at >= ? AND measured_at < ? AND deleted_at IS NULL
+		`
+		start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		args = []any{userID.String(), start, start.AddDate(0, 0, 1)}
+	}
 
 	var count int
-	err := r.db.QueryRow(query, userID.String(), date).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count weights by user and date: %w", err)
 	}
@@ -157,10 +174,12 @@ func (r *weightRepository) CountByUserIDAndDate(userID user.UserID, date time.Ti
 	return count, nil
 }
 
-func (r *weightRepository) Delete(id weight.WeightID) error {
-	query := `DELETE FROM weights WHERE id = ?`
+// Delete moves a weight to the trash; Restore brings it back until
+// PurgeDeleted removes it for good.
+func (r *weightRepository) Delete(ctx context.Context, id weight.WeightID) error {
+	query := `UPDATE weights SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
 
-	result, err := r.db.Exec(query, id.String())
+	result, err := r.db.ExecContext(ctx, query, time.Now().UTC(), id.String())
 	if err != nil {
 		return fmt.Errorf("failed to delete weight: %w", err)
 	}
@@ -177,6 +196,42 @@ func (r *weightRepository) Delete(id weight.WeightID) error {
 	return nil
 }
 
+func (r *weightRepository) Restore(ctx context.Context, userID user.UserID, id weight.WeightID) error {
+	query := `UPDATE weights SET deleted_at = NULL WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id.String(), userID.String())
+	if err != nil {
+		return fmt.Errorf("failed to restore weight: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted weight not found: %s", id.String())
+	}
+
+	return nil
+}
+
+func (r *weightRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM weights WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+
+	result, err := r.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted weights: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
 func (r *weightRepository) scanWeights(rows *sql.Rows) ([]*weight.Weight, error) {
 	var weights []*weight.Weight
 
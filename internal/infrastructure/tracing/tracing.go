@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"peso/internal/buildinfo"
+	"peso/internal/infrastructure/logging"
+)
+
+// ServiceName is reported unless OTEL_SERVICE_NAME overrides it.
+const ServiceName = "peso"
+
+const instrumentation = "peso/internal/infrastructure/tracing"
+
+// Setup installs a global tracer provider that exports spans over
+// OTLP/HTTP and accepts W3C trace context from callers. The exporter is
+// configured through the standard OTEL_EXPORTER_OTLP_* variables and
+// sampling through OTEL_TRACES_SAMPLER. The returned function flushes
+// pending spans and must be called on shutdown.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	provider, err := NewProvider(ctx, sdktrace.WithBatcher(exporter))
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// NewProvider builds a tracer provider describing this service. Tests pass
+// sdktrace.WithSyncer with an in-memory exporter.
+func NewProvider(ctx context.Context, opts ...sdktrace.TracerProviderOption) (*sdktrace.TracerProvider, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(ServiceName),
+			semconv.ServiceVersion(buildinfo.Get().Version),
+		),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES win over the above.
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe tracing resource: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)...), nil
+}
+
+// Middleware starts a server span for each request, continuing the trace
+// of an incoming traceparent header. The span is named after the route
+// pattern recorded by logging.Route and tagged with the request ID, so it
+// must run inside logging.RequestID.
+func Middleware(next http.Handler) http.Handler {
+	tracer := otel.Tracer(instrumentation)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				attribute.String("peso.request_id", logging.RequestIDFromContext(ctx)),
+			),
+		)
+		defer span.End()
+
+		rw := logging.NewStatusRecorder(w)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		if route := logging.RouteFromContext(ctx); route != "" {
+			span.SetName(spanName(r.Method, route))
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		status := rw.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// spanName prefixes route patterns registered without a method.
+func spanName(method, route string) string {
+	if strings.Contains(route, " ") {
+		return route
+	}
+	return method + " " + route
+}
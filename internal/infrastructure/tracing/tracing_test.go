@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"peso/internal/infrastructure/logging"
+)
+
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := NewProvider(context.Background(), sdktrace.WithSyncer(exporter))
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return exporter
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := setupExporter(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := otel.Tracer("test").Start(r.Context(), "WeightTracker.GetRecentWeights")
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler := logging.RequestID(false)(Middleware(logging.Route(mux)))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/giada", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+
+	if server.Name != "GET /users/{userID}" {
+		t.Errorf("expected span named after the route, got %q", server.Name)
+	}
+	if got := server.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected trace from traceparent, got %s", got)
+	}
+	if got := server.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("expected remote parent 00f067aa0ba902b7, got %s", got)
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("expected handler span to be a child of the server span")
+	}
+	if server.Status.Code != codes.Error {
+		t.Errorf("expected error status for a 500, got %v", server.Status.Code)
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range server.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	for key, want := range map[string]string{
+		"peso.request_id":           "req-123",
+		"http.route":                "GET /users/{userID}",
+		"http.response.status_code": "500",
+	} {
+		if attrs[key] != want {
+			t.Errorf("expected %s=%q, got %q", key, want, attrs[key])
+		}
+	}
+}
+
+func TestSpanName(t *testing.T) {
+	tests := []struct {
+		route string
+		want  string
+	}{
+		{route: "GET /users/{userID}", want: "GET /users/{userID}"},
+		{route: "/admin/", want: "POST /admin/"},
+	}
+
+	for _, tt := range tests {
+		if got := spanName(http.MethodPost, tt.route); got != tt.want {
+			t.Errorf("expected %q for %q, got %q", tt.want, tt.route, got)
+		}
+	}
+}
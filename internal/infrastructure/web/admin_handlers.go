@@ -0,0 +1,189 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"peso/internal/application"
+	"peso/internal/domain/audit"
+	"peso/internal/domain/user"
+	"peso/internal/infrastructure/backup"
+	"peso/internal/interfaces"
+)
+
+// AdminHandlers serves the operator endpoints under /admin.
+type AdminHandlers struct {
+	backups  *backup.Manager
+	auditLog *application.AuditLog
+	logger   *slog.Logger
+}
+
+// NewAdminHandlers creates admin handlers. backups is nil when the
+// database driver does not support online backups.
+func NewAdminHandlers(backups *backup.Manager, auditLog *application.AuditLog, logger *slog.Logger) *AdminHandlers {
+	return &AdminHandlers{backups: backups, auditLog: auditLog, logger: logger}
+}
+
+// CreateBackupHandler takes a snapshot immediately and describes it
+func (h *AdminHandlers) CreateBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.backupsAvailable(w, r) {
+		return
+	}
+
+	snapshot, err := h.backups.Create(r.Context())
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.create_backup_failed", err)
+		return
+	}
+	h.logger.InfoContext(r.Context(), "backup_created",
+		slog.String("name", snapshot.Name),
+		slog.Int64("size", snapshot.Size),
+		slog.String("trigger", "admin"),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/admin/backups/"+snapshot.Name)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// ListBackupsHandler lists the available snapshots, newest first
+func (h *AdminHandlers) ListBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.backupsAvailable(w, r) {
+		return
+	}
+
+	snapshots, err := h.backups.List()
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.list_backups_failed", err)
+		return
+	}
+	if snapshots == nil {
+		snapshots = []backup.Snapshot{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"backups": snapshots})
+}
+
+// DownloadBackupHandler streams a snapshot as a file download
+func (h *AdminHandlers) DownloadBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.backupsAvailable(w, r) {
+		return
+	}
+
+	name := r.PathValue("name")
+	f, err := h.backups.Open(name)
+	if errors.Is(err, backup.ErrNotFound) {
+		writeError(h.logger, w, r, http.StatusNotFound, "error.backup_not_found", nil)
+		return
+	}
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.open_backup_failed", err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.open_backup_failed", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+func (h *AdminHandlers) backupsAvailable(w http.ResponseWriter, r *http.Request) bool {
+	if h.backups == nil {
+		writeError(h.logger, w, r, http.StatusNotImplemented, "error.backups_sqlite_only", nil)
+		return false
+	}
+	return true
+}
+
+// auditEventJSON is the admin representation of an audit event
+type auditEventJSON struct {
+	ID         int64           `json:"id"`
+	UserID     string          `json:"user_id"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// ListAuditEventsHandler queries the audit log. It accepts user_id, action,
+// since and until (RFC 3339), limit, and before, the next_before value of a
+// previous page.
+func (h *AdminHandlers) ListAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := interfaces.AuditQuery{
+		UserID: user.UserID(params.Get("user_id")),
+		Action: audit.Action(params.Get("action")),
+	}
+
+	var err error
+	if v := params.Get("since"); v != "" {
+		if q.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_since", nil)
+			return
+		}
+	}
+	if v := params.Get("until"); v != "" {
+		if q.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_until", nil)
+			return
+		}
+	}
+	if v := params.Get("before"); v != "" {
+		if q.BeforeID, err = strconv.ParseInt(v, 10, 64); err != nil || q.BeforeID <= 0 {
+			writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_before", nil)
+			return
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit <= 0 {
+			writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_limit", nil)
+			return
+		}
+	}
+
+	events, err := h.auditLog.Query(r.Context(), q)
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.audit_query_failed", err)
+		return
+	}
+
+	out := make([]auditEventJSON, 0, len(events))
+	for _, e := range events {
+		out = append(out, auditEventJSON{
+			ID:         e.ID(),
+			UserID:     e.UserID().String(),
+			ActorID:    e.ActorID().String(),
+			Action:     string(e.Action()),
+			TargetType: e.TargetType(),
+			TargetID:   e.TargetID(),
+			Before:     e.Before(),
+			After:      e.After(),
+			RequestID:  e.RequestID(),
+			OccurredAt: e.OccurredAt().UTC(),
+		})
+	}
+
+	resp := map[string]any{"events": out}
+	if len(events) > 0 {
+		resp["next_before"] = events[len(events)-1].ID()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,142 @@
+package web
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"peso/internal/application"
+	"peso/internal/domain/audit"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/middleware"
+	"peso/internal/interfaces"
+)
+
+const activityPageSize = 50
+
+// AuditHandlers serves the activity history of the signed-in user
+type AuditHandlers struct {
+	auditLog  *application.AuditLog
+	templates templateSet
+	logger    *slog.Logger
+}
+
+// NewAuditHandlers creates activity handlers
+func NewAuditHandlers(auditLog *application.AuditLog, logger *slog.Logger) *AuditHandlers {
+	return &AuditHandlers{
+		auditLog:  auditLog,
+		templates: loadTemplates(),
+		logger:    logger,
+	}
+}
+
+// ActivityPageHandler lists the recent changes to the current user's data
+func (h *AuditHandlers) ActivityPageHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	before, _ := strconv.ParseInt(r.URL.Query().Get("before"), 10, 64)
+	events, err := h.auditLog.Query(r.Context(), interfaces.AuditQuery{
+		UserID:   currentUser.ID(),
+		BeforeID: before,
+		Limit:    activityPageSize,
+	})
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.activity_failed", err)
+		return
+	}
+
+	type Row struct {
+		When   string
+		Label  string
+		Detail string
+		Failed bool
+	}
+	loc := i18n.FromContext(r.Context())
+	var rows []Row
+	for _, e := range events {
+		label := string(e.Action())
+		if key := "audit.action." + label; loc.Has(key) {
+			label = loc.T(key)
+		}
+		rows = append(rows, Row{
+			When:   loc.DateTime(e.OccurredAt().Local()),
+			Label:  label,
+			Detail: activityDetail(loc, e),
+			Failed: e.Action() == audit.ActionLoginFailed,
+		})
+	}
+
+	var nextBefore int64
+	if len(events) == activityPageSize {
+		nextBefore = events[len(events)-1].ID()
+	}
+
+	data := struct {
+		Title      string
+		UserID     string
+		UserName   string
+		Rows       []Row
+		NextBefore int64
+	}{
+		Title:      loc.T("activity.title"),
+		UserID:     currentUser.ID().String(),
+		UserName:   currentUser.Name(),
+		Rows:       rows,
+		NextBefore: nextBefore,
+	}
+
+	if err := render(h.templates, w, r, "activity.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
+	}
+}
+
+// activityDetail summarises an event's snapshot in one line
+func activityDetail(loc i18n.Locale, e *audit.Event) string {
+	payload := e.After()
+	if payload == nil {
+		payload = e.Before()
+	}
+	var snapshot map[string]any
+	if payload == nil || json.Unmarshal(payload, &snapshot) != nil {
+		return ""
+	}
+
+	switch e.TargetType() {
+	case "weight":
+		value, _ := snapshot["value"].(float64)
+		unit, _ := snapshot["unit"].(string)
+		detail := loc.Number(value, 1) + " " + unit
+		if s, ok := snapshot["measured_at"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, s); err == nil {
+				detail += " · " + loc.DateTime(t.Local())
+			}
+		}
+		return detail
+	case "goal":
+		target, _ := snapshot["target_weight"].(float64)
+		unit, _ := snapshot["unit"].(string)
+		date, _ := snapshot["target_date"].(string)
+		// Goal snapshots carry the date as written by goal.TargetDate
+		if t, err := time.Parse("02/01/2006", date); err == nil {
+			date = loc.Date(t)
+		}
+		return loc.T("activity.goal_detail", loc.Number(target, 1), unit, date)
+	case "user":
+		device, _ := snapshot["device"].(string)
+		ip, _ := snapshot["ip_address"].(string)
+		if device != "" && ip != "" {
+			return device + " · " + ip
+		}
+		return device + ip
+	case "webhook":
+		url, _ := snapshot["url"].(string)
+		return url
+	}
+	return ""
+}
@@ -7,21 +7,25 @@ import (
 	"log/slog"
 	"net/http"
 
-	assets "peso"
 	"peso/internal/application"
+	"peso/internal/domain/session"
 	"peso/internal/domain/user"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/metrics"
 	"peso/internal/infrastructure/middleware"
 )
 
 type AuthHandlers struct {
 	authService *application.AuthService
-	templates   *template.Template
+	metrics     *metrics.Metrics
+	templates   templateSet
 	logger      *slog.Logger
 }
 
-func NewAuthHandlers(authService *application.AuthService, logger *slog.Logger) *AuthHandlers {
+func NewAuthHandlers(authService *application.AuthService, m *metrics.Metrics, logger *slog.Logger) *AuthHandlers {
 	return &AuthHandlers{
 		authService: authService,
+		metrics:     m,
 		templates:   loadAuthTemplates(),
 		logger:      logger,
 	}
@@ -38,51 +42,47 @@ func (h *AuthHandlers) LoginPageHandler(w http.ResponseWriter, r *http.Request)
 		Title string
 		Error string
 	}{
-		Title: "Login - Peso",
+		Title: i18n.FromContext(r.Context()).T("auth.login_title"),
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "login.html", data); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "login.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 	}
 }
 
 func (h *AuthHandlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "error.method_not_allowed", nil)
 		return
 	}
 
 	email := r.FormValue("email")
 	password := r.FormValue("password")
 
-	u, sess, err := h.authService.Login(email, password)
+	u, sess, err := h.authService.Login(r.Context(), email, password, middleware.ClientInfo(r))
 	if err != nil {
 		if err == application.ErrNoPassword {
-			http.SetCookie(w, &http.Cookie{
-				Name:     "pending_email",
-				Value:    email,
-				Path:     "/",
-				MaxAge:   300, // 5 minutes
-				HttpOnly: true,
-				SameSite: http.SameSiteLaxMode,
-			})
+			setPasswordClaimCookie(w, r, h.authService.PasswordClaim(u.ID()))
 			http.Redirect(w, r, "/set-password", http.StatusSeeOther)
 			return
 		}
 
+		h.metrics.RecordLogin(metrics.LoginFailed)
+		loc := i18n.FromContext(r.Context())
 		data := struct {
 			Title string
 			Error string
 		}{
-			Title: "Login - Peso",
-			Error: "Email o password non validi",
+			Title: loc.T("auth.login_title"),
+			Error: loc.T("auth.error.invalid_credentials"),
 		}
 		w.WriteHeader(http.StatusUnauthorized)
-		h.templates.ExecuteTemplate(w, "login.html", data)
+		render(h.templates, w, r, "login.html", data)
 		return
 	}
 
-	middleware.SetSessionCookie(w, sess.Token())
+	h.metrics.RecordLogin(metrics.LoginSucceeded)
+	middleware.SetSessionCookie(w, r, sess.Token())
 	http.Redirect(w, r, "/users/"+u.ID().String(), http.StatusSeeOther)
 }
 
@@ -97,17 +97,17 @@ func (h *AuthHandlers) RegisterPageHandler(w http.ResponseWriter, r *http.Reques
 		Title string
 		Error string
 	}{
-		Title: "Registrati - Peso",
+		Title: i18n.FromContext(r.Context()).T("auth.register_title"),
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "register.html", data); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "register.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 	}
 }
 
 func (h *AuthHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "error.method_not_allowed", nil)
 		return
 	}
 
@@ -115,6 +115,7 @@ func (h *AuthHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	email := r.FormValue("email")
 	password := r.FormValue("password")
 	confirmPassword := r.FormValue("confirm_password")
+	loc := i18n.FromContext(r.Context())
 
 	if password != confirmPassword {
 		data := struct {
@@ -123,26 +124,24 @@ func (h *AuthHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 			Name  string
 			Email string
 		}{
-			Title: "Registrati - Peso",
-			Error: "Le password non coincidono",
+			Title: loc.T("auth.register_title"),
+			Error: loc.T("auth.error.password_mismatch"),
 			Name:  name,
 			Email: email,
 		}
 		w.WriteHeader(http.StatusBadRequest)
-		h.templates.ExecuteTemplate(w, "register.html", data)
+		render(h.templates, w, r, "register.html", data)
 		return
 	}
 
-	u, sess, err := h.authService.Register(name, email, password)
+	u, sess, err := h.authService.Register(r.Context(), name, email, password, middleware.ClientInfo(r))
 	if err != nil {
-		errMsg := "Errore durante la registrazione"
+		errMsg := loc.T("auth.error.register_failed")
 		switch {
 		case err == application.ErrEmailAlreadyExists:
-			errMsg = "Email già registrata"
-		case err == application.ErrInvalidEmail:
-			errMsg = "Email non valida"
-		case errors.Is(err, user.ErrPasswordTooShort):
-			errMsg = "La password deve essere di almeno 8 caratteri"
+			errMsg = loc.T("auth.error.email_taken")
+		case err == application.ErrInvalidEmail, errors.Is(err, user.ErrPasswordTooShort):
+			errMsg = errorMessage(loc, err)
 		}
 
 		data := struct {
@@ -151,24 +150,62 @@ func (h *AuthHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 			Name  string
 			Email string
 		}{
-			Title: "Registrati - Peso",
+			Title: loc.T("auth.register_title"),
 			Error: errMsg,
 			Name:  name,
 			Email: email,
 		}
 		w.WriteHeader(http.StatusBadRequest)
-		h.templates.ExecuteTemplate(w, "register.html", data)
+		render(h.templates, w, r, "register.html", data)
 		return
 	}
 
-	middleware.SetSessionCookie(w, sess.Token())
+	middleware.SetSessionCookie(w, r, sess.Token())
 	http.Redirect(w, r, "/users/"+u.ID().String(), http.StatusSeeOther)
 }
 
-func (h *AuthHandlers) SetPasswordPageHandler(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("pending_email")
+// passwordClaimCookie carries the claim issued when a user created without
+// a password signs in, until they have chosen one.
+const passwordClaimCookie = "password_claim"
+
+func setPasswordClaimCookie(w http.ResponseWriter, r *http.Request, claim string) {
+	maxAge := int(session.ClaimTTL.Seconds())
+	if claim == "" {
+		maxAge = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     passwordClaimCookie,
+		Value:    claim,
+		Path:     "/set-password",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   middleware.IsSecureRequest(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// claimingUser returns the user the request's password claim is for. When
+// there is none, or it is no longer valid, the claim cookie is dropped and
+// the client sent back to the login page.
+func (h *AuthHandlers) claimingUser(w http.ResponseWriter, r *http.Request) (*user.User, string, bool) {
+	cookie, err := r.Cookie(passwordClaimCookie)
 	if err != nil || cookie.Value == "" {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil, "", false
+	}
+
+	u, err := h.authService.ClaimingUser(r.Context(), cookie.Value)
+	if err != nil {
+		setPasswordClaimCookie(w, r, "")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil, "", false
+	}
+	return u, cookie.Value, true
+}
+
+func (h *AuthHandlers) SetPasswordPageHandler(w http.ResponseWriter, r *http.Request) {
+	u, _, ok := h.claimingUser(w, r)
+	if !ok {
 		return
 	}
 
@@ -177,30 +214,29 @@ func (h *AuthHandlers) SetPasswordPageHandler(w http.ResponseWriter, r *http.Req
 		Email string
 		Error string
 	}{
-		Title: "Imposta Password - Peso",
-		Email: cookie.Value,
+		Title: i18n.FromContext(r.Context()).T("auth.set_password_title"),
+		Email: u.Email(),
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "set_password.html", data); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "set_password.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 	}
 }
 
 func (h *AuthHandlers) SetPasswordHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "error.method_not_allowed", nil)
 		return
 	}
 
-	cookie, err := r.Cookie("pending_email")
-	if err != nil || cookie.Value == "" {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+	claimant, claim, ok := h.claimingUser(w, r)
+	if !ok {
 		return
 	}
-	email := cookie.Value
 
 	password := r.FormValue("password")
 	confirmPassword := r.FormValue("confirm_password")
+	loc := i18n.FromContext(r.Context())
 
 	if password != confirmPassword {
 		data := struct {
@@ -208,58 +244,205 @@ func (h *AuthHandlers) SetPasswordHandler(w http.ResponseWriter, r *http.Request
 			Email string
 			Error string
 		}{
-			Title: "Imposta Password - Peso",
-			Email: email,
-			Error: "Le password non coincidono",
+			Title: loc.T("auth.set_password_title"),
+			Email: claimant.Email(),
+			Error: loc.T("auth.error.password_mismatch"),
 		}
 		w.WriteHeader(http.StatusBadRequest)
-		h.templates.ExecuteTemplate(w, "set_password.html", data)
+		render(h.templates, w, r, "set_password.html", data)
 		return
 	}
 
-	u, sess, err := h.authService.SetPassword(email, password)
+	u, sess, err := h.authService.SetPassword(r.Context(), claim, password, middleware.ClientInfo(r))
 	if err != nil {
-		errMsg := "Errore durante l'impostazione della password"
-		if errors.Is(err, user.ErrPasswordTooShort) {
-			errMsg = "La password deve essere di almeno 8 caratteri"
+		errMsg := loc.T("auth.error.set_password_failed")
+		switch {
+		case errors.Is(err, user.ErrPasswordTooShort):
+			errMsg = errorMessage(loc, err)
+		case err == application.ErrPasswordAlreadySet:
+			setPasswordClaimCookie(w, r, "")
+			errMsg = loc.T("auth.error.password_already_set")
 		}
 		data := struct {
 			Title string
 			Email string
 			Error string
 		}{
-			Title: "Imposta Password - Peso",
-			Email: email,
+			Title: loc.T("auth.set_password_title"),
+			Email: claimant.Email(),
 			Error: errMsg,
 		}
 		w.WriteHeader(http.StatusBadRequest)
-		h.templates.ExecuteTemplate(w, "set_password.html", data)
+		render(h.templates, w, r, "set_password.html", data)
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:   "pending_email",
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
-	})
-
-	middleware.SetSessionCookie(w, sess.Token())
+	setPasswordClaimCookie(w, r, "")
+	middleware.SetSessionCookie(w, r, sess.Token())
 	http.Redirect(w, r, "/users/"+u.ID().String(), http.StatusSeeOther)
 }
 
 func (h *AuthHandlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	token := middleware.SessionTokenFromContext(r.Context())
 	if token != "" {
-		h.authService.Logout(token)
+		h.authService.Logout(r.Context(), token)
 	}
 
-	middleware.ClearSessionCookie(w)
+	middleware.ClearSessionCookie(w, r)
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-func loadAuthTemplates() *template.Template {
-	tmpl := template.New("").Funcs(template.FuncMap{
+// AccountPageHandler lists the devices signed in to the current account
+func (h *AuthHandlers) AccountPageHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	current := middleware.SessionFromContext(r.Context())
+
+	sessions, err := h.authService.ListSessions(r.Context(), currentUser.ID())
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.sessions_failed", err)
+		return
+	}
+
+	type Row struct {
+		ID         string
+		DeviceName string
+		IPAddress  string
+		LastSeen   string
+		CreatedAt  string
+		Current    bool
+	}
+	loc := i18n.FromContext(r.Context())
+	var rows []Row
+	for _, s := range sessions {
+		rows = append(rows, Row{
+			ID:         s.ID().String(),
+			DeviceName: s.DeviceName(),
+			IPAddress:  s.IPAddress(),
+			LastSeen:   loc.DateTime(s.LastSeenAt()),
+			CreatedAt:  loc.Date(s.CreatedAt()),
+			Current:    current != nil && s.ID() == current.ID(),
+		})
+	}
+
+	type LanguageOption struct {
+		Code     string
+		Name     string
+		Selected bool
+	}
+	var languages []LanguageOption
+	for _, l := range i18n.Supported {
+		languages = append(languages, LanguageOption{
+			Code:     string(l),
+			Name:     l.Name(),
+			Selected: string(l) == currentUser.Language(),
+		})
+	}
+
+	data := struct {
+		Title     string
+		UserID    string
+		UserName  string
+		Email     string
+		Sessions  []Row
+		Language  string
+		Languages []LanguageOption
+	}{
+		Title:     loc.T("account.title"),
+		UserID:    currentUser.ID().String(),
+		UserName:  currentUser.Name(),
+		Email:     currentUser.Email(),
+		Sessions:  rows,
+		Language:  currentUser.Language(),
+		Languages: languages,
+	}
+
+	if err := render(h.templates, w, r, "account.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
+	}
+}
+
+// RevokeSessionHandler signs out a single device of the current user
+func (h *AuthHandlers) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	sessionID, err := session.ParseSessionID(r.PathValue("sessionID"))
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_session_id", err)
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), currentUser.ID(), sessionID); err != nil {
+		if errors.Is(err, application.ErrSessionNotFound) {
+			writeError(h.logger, w, r, http.StatusNotFound, "error.session_not_found", err)
+			return
+		}
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.revoke_session_failed", err)
+		return
+	}
+
+	if current := middleware.SessionFromContext(r.Context()); current != nil && current.ID() == sessionID {
+		middleware.ClearSessionCookie(w, r)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+// RevokeOtherSessionsHandler signs out every device except the current one
+func (h *AuthHandlers) RevokeOtherSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	current := middleware.SessionFromContext(r.Context())
+	if currentUser == nil || current == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.authService.RevokeOtherSessions(r.Context(), currentUser.ID(), current.ID()); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.revoke_sessions_failed", err)
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+// SetLanguageHandler saves the interface language chosen on the account
+// page; an empty choice follows the browser again
+func (h *AuthHandlers) SetLanguageHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	language := r.FormValue("language")
+	if language != "" {
+		l, ok := i18n.Parse(language)
+		if !ok {
+			writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_language", nil)
+			return
+		}
+		language = string(l)
+	}
+
+	if err := h.authService.SetLanguage(r.Context(), currentUser.ID(), language); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.save_language_failed", err)
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func loadAuthTemplates() templateSet {
+	return parseTemplates(template.FuncMap{
 		"title": func(s string) string {
 			if len(s) == 0 {
 				return s
@@ -274,6 +457,4 @@ func loadAuthTemplates() *template.Template {
 			return template.JS(string(b))
 		},
 	})
-	template.Must(tmpl.ParseFS(assets.FS, "templates/*.html"))
-	return tmpl
 }
@@ -1,22 +1,25 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log/slog"
 	"net/http"
 	"peso/internal/application"
 	"peso/internal/domain/goal"
 	"peso/internal/domain/user"
 	"peso/internal/domain/weight"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/logging"
 	"peso/internal/infrastructure/middleware"
 	"peso/internal/interfaces"
 	"strconv"
 	"strings"
 	"time"
-
-	assets "peso"
 )
 
 // Handlers contains all HTTP handlers
@@ -24,7 +27,7 @@ type Handlers struct {
 	weightTracker *application.WeightTracker
 	goalTracker   *application.GoalTracker
 	userRepo      interfaces.UserRepository
-	templates     *template.Template
+	templates     templateSet
 	logger        *slog.Logger
 }
 
@@ -50,19 +53,35 @@ func (h *Handlers) HomeHandler(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		Title string
 	}{
-		Title: "Peso - Weight Tracking",
+		Title: i18n.FromContext(r.Context()).T("home.title"),
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "index.html", data); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "index.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 		return
 	}
 }
 
+// ownUserID returns the user ID a request acts on when it is the signed-in
+// user. Otherwise it answers 401 without a session or 403 for someone
+// else's data, and returns false.
+func (h *Handlers) ownUserID(w http.ResponseWriter, r *http.Request, userID string) (user.UserID, bool) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		writeError(h.logger, w, r, http.StatusUnauthorized, "error.not_authenticated", nil)
+		return "", false
+	}
+	if userID != currentUser.ID().String() {
+		writeError(h.logger, w, r, http.StatusForbidden, "error.forbidden", nil)
+		return "", false
+	}
+	return currentUser.ID(), true
+}
+
 // AddWeightHandler handles weight recording
 func (h *Handlers) AddWeightHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "error.method_not_allowed", nil)
 		return
 	}
 
@@ -72,47 +91,48 @@ func (h *Handlers) AddWeightHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Validate inputs
 	if userIDStr == "" || weightStr == "" {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Missing required fields", nil)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.missing_fields", nil)
 		return
 	}
 
 	// Parse weight value
 	weightFloat, err := strconv.ParseFloat(weightStr, 64)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid weight value", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_weight_value", err)
 		return
 	}
 
 	// Use current server time
 	measuredAt := time.Now()
 
-	// Create domain objects
-	userID, err := user.NewUserID(userIDStr)
-	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+	// Only the signed-in user records their weights, so the audit log has an actor
+	userID, ok := h.ownUserID(w, r, userIDStr)
+	if !ok {
 		return
 	}
 
 	weightValue, err := weight.NewWeightValue(weightFloat)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid weight", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_weight", err)
 		return
 	}
 
 	unit, err := weight.NewWeightUnit("kg")
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Invalid unit", err)
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.invalid_unit", err)
 		return
 	}
 
 	// Record weight using domain service
-	recordedWeight, err := h.weightTracker.RecordWeight(userID, weightValue, unit, measuredAt, "")
+	recordedWeight, err := h.weightTracker.RecordWeight(r.Context(), userID, weightValue, unit, measuredAt, "")
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Failed to record weight", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.record_weight_failed", err)
 		return
 	}
+	middleware.SetCreatedResource(r.Context(), recordedWeight.ID().String())
 
 	// Return success response (HTMX will handle this)
+	w.Header().Set("HX-Trigger", "weight-updated")
 	w.Header().Set("Content-Type", "application/json")
 	response := struct {
 		Success bool   `json:"success"`
@@ -125,7 +145,7 @@ func (h *Handlers) AddWeightHandler(w http.ResponseWriter, r *http.Request) {
 		} `json:"weight"`
 	}{
 		Success: true,
-		Message: "Weight recorded successfully",
+		Message: i18n.FromContext(r.Context()).T("toast.weight_recorded"),
 		Weight: struct {
 			ID    string  `json:"id"`
 			Value float64 `json:"value"`
@@ -142,13 +162,114 @@ func (h *Handlers) AddWeightHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+const (
+	// maxBatchEntries bounds one POST /api/weights/batch; the offline queue
+	// sends larger backlogs in several batches.
+	maxBatchEntries   = 100
+	maxBatchBodyBytes = 64 << 10
+)
+
+// AddWeightsBatchHandler records measurements queued by the PWA while it
+// was offline. Every entry carries its own key and is recorded at most
+// once.
+func (h *Handlers) AddWeightsBatchHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		writeError(h.logger, w, r, http.StatusUnauthorized, "error.not_authenticated", nil)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes))
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusRequestEntityTooLarge, "error.batch_too_large", err)
+		return
+	}
+
+	var req struct {
+		UserID  string `json:"user_id"`
+		Entries []struct {
+			Key        string    `json:"key"`
+			Weight     float64   `json:"weight"`
+			Unit       string    `json:"unit"`
+			MeasuredAt time.Time `json:"measured_at"`
+			Notes      string    `json:"notes"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_json", err)
+		return
+	}
+	// The queue is per device, so entries recorded by someone who has since
+	// logged out must not be filed under the current user.
+	if req.UserID != "" && req.UserID != currentUser.ID().String() {
+		writeError(h.logger, w, r, http.StatusForbidden, "error.entries_other_user", nil)
+		return
+	}
+	if len(req.Entries) == 0 || len(req.Entries) > maxBatchEntries {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.batch_size", nil, maxBatchEntries)
+		return
+	}
+
+	entries := make([]application.WeightEntry, 0, len(req.Entries))
+	for _, e := range req.Entries {
+		unit := e.Unit
+		if unit == "" {
+			unit = "kg"
+		}
+		entries = append(entries, application.WeightEntry{
+			Key:        e.Key,
+			Value:      e.Weight,
+			Unit:       unit,
+			MeasuredAt: e.MeasuredAt,
+			Notes:      e.Notes,
+		})
+	}
+
+	results, err := h.weightTracker.RecordWeights(r.Context(), currentUser.ID(), entries)
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.record_weights_failed", err)
+		return
+	}
+
+	type entryResult struct {
+		Key      string `json:"key"`
+		Status   string `json:"status"`
+		WeightID string `json:"weight_id,omitempty"`
+		Error    string `json:"error,omitempty"`
+	}
+	resp := struct {
+		Results []entryResult `json:"results"`
+	}{Results: make([]entryResult, 0, len(results))}
+	loc := i18n.FromContext(r.Context())
+	created := false
+	for _, res := range results {
+		out := entryResult{Key: res.Key, Status: string(res.Status), WeightID: res.WeightID}
+		if res.Err != nil {
+			out.Error = errorMessage(loc, res.Err)
+		}
+		created = created || res.Status == application.EntryCreated
+		resp.Results = append(resp.Results, out)
+	}
+
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.encode_failed", err)
+		return
+	}
+	if created {
+		w.Header().Set("HX-Trigger", "weight-updated")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(payload)
+}
+
 // WeightHistoryHandler returns weight history for a user
 func (h *Handlers) WeightHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.PathValue("userID")
 
 	userID, err := user.NewUserID(userIDStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
 		return
 	}
 
@@ -171,9 +292,9 @@ func (h *Handlers) WeightHistoryHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	weights, err := h.weightTracker.GetWeightHistory(userID, period)
+	weights, err := h.weightTracker.GetWeightHistory(r.Context(), userID, period)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Failed to get weight history", err)
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.weight_history_failed", err)
 		return
 	}
 
@@ -209,13 +330,13 @@ func (h *Handlers) WeightLatestHandler(w http.ResponseWriter, r *http.Request) {
 
 	userID, err := user.NewUserID(userIDStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
 		return
 	}
 
-	latest, err := h.weightTracker.GetLatestWeight(userID)
+	latest, err := h.weightTracker.GetLatestWeight(r.Context(), userID)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusNotFound, "No weight found", err)
+		writeError(h.logger, w, r, http.StatusNotFound, "error.no_weight", err)
 		return
 	}
 
@@ -255,20 +376,20 @@ func (h *Handlers) UserDashboardHandler(w http.ResponseWriter, r *http.Request)
 	userID := currentUser.ID()
 
 	// Get active goal if exists
-	activeGoal, _ := h.goalTracker.GetActiveGoal(userID)
+	activeGoal, _ := h.goalTracker.GetActiveGoal(r.Context(), userID)
 
 	// Calculate goal progress if goal exists
 	var progress *application.GoalProgress
 	var startWeight interface{}
 	var createdAt interface{}
 	if activeGoal != nil {
-		p, err := h.goalTracker.CalculateProgress(userID)
+		p, err := h.goalTracker.CalculateProgress(r.Context(), userID)
 		if err == nil {
 			progress = &p
 		}
 
 		// Get starting weight for trajectory calculation
-		if startWeightRecord, err := h.goalTracker.GetStartingWeightForGoal(userID, activeGoal.CreatedAt()); err == nil {
+		if startWeightRecord, err := h.goalTracker.GetStartingWeightForGoal(r.Context(), userID, activeGoal.CreatedAt()); err == nil {
 			startWeight = startWeightRecord.Value().Float64()
 		}
 		createdAt = activeGoal.CreatedAt().Format("02/01/2006")
@@ -290,8 +411,8 @@ func (h *Handlers) UserDashboardHandler(w http.ResponseWriter, r *http.Request)
 		CreatedAt:   createdAt,
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "user_dashboard.html", data); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "user_dashboard.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 		return
 	}
 }
@@ -302,12 +423,12 @@ func (h *Handlers) GoalFormHandler(w http.ResponseWriter, r *http.Request) {
 
 	userID, err := user.NewUserID(userIDStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
 		return
 	}
 
 	// Try to get current weight for helper text
-	latest, _ := h.weightTracker.GetLatestWeight(userID)
+	latest, _ := h.weightTracker.GetLatestWeight(r.Context(), userID)
 	var current struct {
 		Value float64
 		Unit  string
@@ -338,8 +459,8 @@ func (h *Handlers) GoalFormHandler(w http.ResponseWriter, r *http.Request) {
 		}(),
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "goal_form.html", data); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "goal_form.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 		return
 	}
 }
@@ -347,7 +468,7 @@ func (h *Handlers) GoalFormHandler(w http.ResponseWriter, r *http.Request) {
 // AddGoalHandler handles goal creation
 func (h *Handlers) AddGoalHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		writeError(h.logger, w, r, http.StatusMethodNotAllowed, "error.method_not_allowed", nil)
 		return
 	}
 
@@ -358,71 +479,103 @@ func (h *Handlers) AddGoalHandler(w http.ResponseWriter, r *http.Request) {
 	notes := r.FormValue("notes")
 
 	if userIDStr == "" || targetWeightStr == "" || targetDateStr == "" {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Missing required fields", nil)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.missing_fields", nil)
 		return
 	}
 
-	userID, err := user.NewUserID(userIDStr)
-	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+	userID, ok := h.ownUserID(w, r, userIDStr)
+	if !ok {
 		return
 	}
 
 	tw, err := strconv.ParseFloat(targetWeightStr, 64)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid target weight", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_target_weight", err)
 		return
 	}
 	targetWeight, err := weight.NewWeightValue(tw)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid target weight", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_target_weight", err)
 		return
 	}
 
 	unit, err := weight.NewWeightUnit("kg")
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Invalid unit", err)
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.invalid_unit", err)
 		return
 	}
 
 	// Parse target date (YYYY-MM-DD)
 	t, err := time.Parse("2006-01-02", targetDateStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid target date", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_target_date", err)
 		return
 	}
 	td, err := goal.NewTargetDate(t.Year(), int(t.Month()), t.Day())
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid target date", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_target_date", err)
 		return
 	}
 
 	// Optionally, enforce direction for goalType (not used by domain yet)
 	_ = goalType
 
-	if _, err := h.goalTracker.SetGoal(userID, targetWeight, unit, td, notes); err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Failed to set goal", err)
+	newGoal, err := h.goalTracker.SetGoal(r.Context(), userID, targetWeight, unit, td, notes)
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.set_goal_failed", err)
 		return
 	}
+	middleware.SetCreatedResource(r.Context(), newGoal.ID().String())
 
 	// Return simple success response for HTMX
+	w.Header().Set("HX-Trigger", "goal-updated")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
 
+// GoalActiveHandler returns the user's active goal
+func (h *Handlers) GoalActiveHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := user.NewUserID(r.PathValue("userID"))
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
+		return
+	}
+
+	active, err := h.goalTracker.GetActiveGoal(r.Context(), userID)
+	if err != nil || active == nil {
+		writeError(h.logger, w, r, http.StatusNotFound, "error.no_active_goal", err)
+		return
+	}
+
+	resp := struct {
+		ID           string  `json:"id"`
+		TargetWeight float64 `json:"target_weight"`
+		Unit         string  `json:"unit"`
+		TargetDate   string  `json:"target_date"`
+	}{
+		ID:           active.ID().String(),
+		TargetWeight: active.TargetWeight().Float64(),
+		Unit:         active.Unit().String(),
+		TargetDate:   active.TargetDate().String(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // RecentWeightsHandler returns the HTML partial with recent weights list
 func (h *Handlers) RecentWeightsHandler(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.PathValue("userID")
 
 	userID, err := user.NewUserID(userIDStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
 		return
 	}
 
-	weights, err := h.weightTracker.GetRecentWeights(userID, 10)
+	weights, err := h.weightTracker.GetRecentWeights(r.Context(), userID, 10)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Failed to load recent weights", err)
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.recent_weights_failed", err)
 		return
 	}
 
@@ -436,14 +589,15 @@ func (h *Handlers) RecentWeightsHandler(w http.ResponseWriter, r *http.Request)
 		Unit   string
 		Notes  string
 	}
+	loc := i18n.FromContext(r.Context())
 	var rows []Row
 	for _, wgt := range weights {
 		rows = append(rows, Row{
 			ID:     wgt.ID().String(),
 			UserID: userIDStr,
-			Date:   wgt.MeasuredAt().Format("02/01/2006"),
-			Time:   wgt.MeasuredAt().Format("15:04"),
-			Value:  fmt.Sprintf("%.1f", wgt.Value().Float64()),
+			Date:   loc.Date(wgt.MeasuredAt()),
+			Time:   loc.Time(wgt.MeasuredAt()),
+			Value:  loc.Number(wgt.Value().Float64(), 1),
 			Unit:   wgt.Unit().String(),
 			Notes:  wgt.Notes(),
 		})
@@ -453,36 +607,140 @@ func (h *Handlers) RecentWeightsHandler(w http.ResponseWriter, r *http.Request)
 		Rows []Row
 	}{Rows: rows}
 
-	if err := h.templates.ExecuteTemplate(w, "partials_recent_weights.html", data); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "partials_recent_weights.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 		return
 	}
 }
 
 // DeleteWeightHandler handles weight deletion
 func (h *Handlers) DeleteWeightHandler(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.PathValue("userID")
-	weightIDStr := r.PathValue("weightID")
+	userID, ok := h.ownUserID(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
 
-	userID, err := user.NewUserID(userIDStr)
+	weightID, err := weight.NewWeightID(r.PathValue("weightID"))
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_weight_id", err)
+		return
+	}
+
+	if err := h.weightTracker.DeleteWeight(r.Context(), userID, weightID); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.delete_weight_failed", err)
 		return
 	}
 
-	weightID, err := weight.NewWeightID(weightIDStr)
+	w.Header().Set("HX-Trigger", "weight-updated")
+	h.renderToast(w, r, toast{
+		Type:    "success",
+		Message: i18n.FromContext(r.Context()).T("toast.weight_deleted"),
+		UndoURL: "/api/weights/" + userID.String() + "/" + weightID.String() + "/restore",
+	})
+}
+
+// RestoreWeightHandler undoes a weight deletion
+func (h *Handlers) RestoreWeightHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.ownUserID(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+
+	weightID, err := weight.NewWeightID(r.PathValue("weightID"))
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid weight ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_weight_id", err)
 		return
 	}
 
-	if err := h.weightTracker.DeleteWeight(userID, weightID); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Failed to delete weight", err)
+	if err := h.weightTracker.RestoreWeight(r.Context(), userID, weightID); err != nil {
+		if errors.Is(err, application.ErrMaxDailyRecordings) {
+			writeError(h.logger, w, r, http.StatusConflict, "error.too_many_weights", err)
+			return
+		}
+		writeError(h.logger, w, r, http.StatusNotFound, "error.restore_weight_failed", err)
 		return
 	}
 
 	w.Header().Set("HX-Trigger", "weight-updated")
-	w.WriteHeader(http.StatusOK)
+	h.renderToast(w, r, toast{Type: "success", Message: i18n.FromContext(r.Context()).T("toast.weight_restored")})
+}
+
+// DeleteGoalHandler handles goal deletion
+func (h *Handlers) DeleteGoalHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.ownUserID(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+
+	goalID, err := goal.NewGoalID(r.PathValue("goalID"))
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_goal_id", err)
+		return
+	}
+
+	if err := h.goalTracker.DeleteGoal(r.Context(), userID, goalID); err != nil {
+		if errors.Is(err, application.ErrGoalNotFound) {
+			writeError(h.logger, w, r, http.StatusNotFound, "error.goal_not_found", err)
+			return
+		}
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.delete_goal_failed", err)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "goal-updated")
+	h.renderToast(w, r, toast{
+		Type:    "success",
+		Message: i18n.FromContext(r.Context()).T("toast.goal_deleted"),
+		UndoURL: "/api/goals/" + userID.String() + "/" + goalID.String() + "/restore",
+	})
+}
+
+// RestoreGoalHandler undoes a goal deletion
+func (h *Handlers) RestoreGoalHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.ownUserID(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+
+	goalID, err := goal.NewGoalID(r.PathValue("goalID"))
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_goal_id", err)
+		return
+	}
+
+	if err := h.goalTracker.RestoreGoal(r.Context(), userID, goalID); err != nil {
+		if errors.Is(err, application.ErrActiveGoalExists) {
+			writeError(h.logger, w, r, http.StatusConflict, "error.goal_already_active", err)
+			return
+		}
+		writeError(h.logger, w, r, http.StatusNotFound, "error.restore_goal_failed", err)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "goal-updated")
+	h.renderToast(w, r, toast{Type: "success", Message: i18n.FromContext(r.Context()).T("toast.goal_restored")})
+}
+
+// undoGracePeriod is how long the undo toast stays on screen after a
+// deletion. Deleted records remain restorable until they are purged.
+const undoGracePeriod = 8 * time.Second
+
+// toast is the view model for partials_toast.html
+type toast struct {
+	Type      string
+	Message   string
+	UndoURL   string
+	TimeoutMS int64
+}
+
+// renderToast writes a toast for HTMX to append to the toast container
+func (h *Handlers) renderToast(w http.ResponseWriter, r *http.Request, t toast) {
+	if t.TimeoutMS == 0 {
+		t.TimeoutMS = undoGracePeriod.Milliseconds()
+	}
+	if err := render(h.templates, w, r, "partials_toast.html", t); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
+	}
 }
 
 // WeightFormHandler serves the weight entry form
@@ -495,8 +753,8 @@ func (h *Handlers) WeightFormHandler(w http.ResponseWriter, r *http.Request) {
 		UserID: userIDStr,
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "weight_form.html", data); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "weight_form.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 		return
 	}
 }
@@ -507,18 +765,19 @@ func (h *Handlers) GoalSummaryHandler(w http.ResponseWriter, r *http.Request) {
 
 	userID, err := user.NewUserID(userIDStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
 		return
 	}
 
 	// Check if user has any weight records
 	hasWeights := false
-	if _, err := h.weightTracker.GetLatestWeight(userID); err == nil {
+	if _, err := h.weightTracker.GetLatestWeight(r.Context(), userID); err == nil {
 		hasWeights = true
 	}
 
 	type vm struct {
 		UserID          string
+		GoalID          string
 		Active          bool
 		TargetWeight    string
 		Unit            string
@@ -531,15 +790,17 @@ func (h *Handlers) GoalSummaryHandler(w http.ResponseWriter, r *http.Request) {
 		IsOnTrack       bool
 	}
 
+	loc := i18n.FromContext(r.Context())
 	out := vm{UserID: userIDStr, HasWeights: hasWeights}
-	if g, _ := h.goalTracker.GetActiveGoal(userID); g != nil {
+	if g, _ := h.goalTracker.GetActiveGoal(r.Context(), userID); g != nil {
 		out.Active = true
-		out.TargetWeight = fmt.Sprintf("%.1f", g.TargetWeight().Float64())
+		out.GoalID = g.ID().String()
+		out.TargetWeight = loc.Number(g.TargetWeight().Float64(), 1)
 		out.Unit = g.Unit().String()
-		out.TargetDate = g.TargetDate().String()
-		if p, err := h.goalTracker.CalculateProgress(userID); err == nil {
+		out.TargetDate = loc.Date(g.TargetDate().ToTime())
+		if p, err := h.goalTracker.CalculateProgress(r.Context(), userID); err == nil {
 			out.HasProgress = true
-			out.WeightToLose = fmt.Sprintf("%.1f", p.WeightToLose.Float64())
+			out.WeightToLose = loc.Number(p.WeightToLose.Float64(), 1)
 			out.DaysRemaining = p.DaysRemaining
 			out.ProgressPercent = int(p.ProgressPercent)
 			if out.ProgressPercent > 100 {
@@ -552,8 +813,8 @@ func (h *Handlers) GoalSummaryHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "partials_goal_summary.html", out); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "partials_goal_summary.html", out); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 		return
 	}
 }
@@ -563,7 +824,7 @@ func (h *Handlers) GoalBadgeHandler(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.PathValue("userID")
 	userID, err := user.NewUserID(userIDStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
 		return
 	}
 
@@ -574,9 +835,9 @@ func (h *Handlers) GoalBadgeHandler(w http.ResponseWriter, r *http.Request) {
 		DaysRemaining int
 	}
 	out := vm{}
-	if g, _ := h.goalTracker.GetActiveGoal(userID); g != nil {
+	if g, _ := h.goalTracker.GetActiveGoal(r.Context(), userID); g != nil {
 		out.Active = true
-		if p, err := h.goalTracker.CalculateProgress(userID); err == nil {
+		if p, err := h.goalTracker.CalculateProgress(r.Context(), userID); err == nil {
 			out.HasProgress = true
 			out.WeightToLose = fmt.Sprintf("%.1f", p.WeightToLose.Float64())
 			out.DaysRemaining = p.DaysRemaining
@@ -589,13 +850,14 @@ func (h *Handlers) StatHeroHandler(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.PathValue("userID")
 	userID, err := user.NewUserID(userIDStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
 		return
 	}
 
 	type vm struct {
 		HasData       bool
 		CurrentWeight string
+		CurrentValue  string
 		Unit          string
 		LastDate      string
 		LastTime      string
@@ -603,37 +865,40 @@ func (h *Handlers) StatHeroHandler(w http.ResponseWriter, r *http.Request) {
 		TrendClass    string
 	}
 
+	loc := i18n.FromContext(r.Context())
 	out := vm{}
 
-	latest, err := h.weightTracker.GetLatestWeight(userID)
+	latest, err := h.weightTracker.GetLatestWeight(r.Context(), userID)
 	if err == nil && latest != nil {
 		out.HasData = true
-		out.CurrentWeight = fmt.Sprintf("%.1f", latest.Value().Float64())
+		out.CurrentWeight = loc.Number(latest.Value().Float64(), 1)
+		// The page script adjusts the raw value, not the localized text
+		out.CurrentValue = fmt.Sprintf("%.1f", latest.Value().Float64())
 		out.Unit = latest.Unit().String()
-		out.LastDate = latest.MeasuredAt().Format("02/01")
-		out.LastTime = latest.MeasuredAt().Format("15:04")
+		out.LastDate = loc.ShortDate(latest.MeasuredAt())
+		out.LastTime = loc.Time(latest.MeasuredAt())
 
 		// Calculate 7-day trend
-		weights, _ := h.weightTracker.GetWeightHistory(userID, application.TimePeriodLastWeek)
+		weights, _ := h.weightTracker.GetWeightHistory(r.Context(), userID, application.TimePeriodLastWeek)
 		if len(weights) >= 2 {
 			oldest := weights[len(weights)-1].Value().Float64()
 			newest := weights[0].Value().Float64()
 			diff := newest - oldest
 			if diff < -0.1 {
-				out.TrendValue = fmt.Sprintf("%.1f", diff)
+				out.TrendValue = loc.Number(diff, 1)
 				out.TrendClass = "stat-hero__trend--down"
 			} else if diff > 0.1 {
-				out.TrendValue = fmt.Sprintf("+%.1f", diff)
+				out.TrendValue = "+" + loc.Number(diff, 1)
 				out.TrendClass = "stat-hero__trend--up"
 			} else {
-				out.TrendValue = "0.0"
+				out.TrendValue = loc.Number(0, 1)
 				out.TrendClass = "stat-hero__trend--neutral"
 			}
 		}
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "partials_stat_hero.html", out); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "partials_stat_hero.html", out); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 		return
 	}
 }
@@ -643,7 +908,7 @@ func (h *Handlers) StatPillsHandler(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.PathValue("userID")
 	userID, err := user.NewUserID(userIDStr)
 	if err != nil {
-		writeError(h.logger, w, r, http.StatusBadRequest, "Invalid user ID", err)
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_user_id", err)
 		return
 	}
 
@@ -656,15 +921,16 @@ func (h *Handlers) StatPillsHandler(w http.ResponseWriter, r *http.Request) {
 		GoalReached  bool
 	}
 
+	loc := i18n.FromContext(r.Context())
 	out := vm{}
 
 	// Get latest weight for calculations
-	latest, _ := h.weightTracker.GetLatestWeight(userID)
+	latest, _ := h.weightTracker.GetLatestWeight(r.Context(), userID)
 
 	// Get goal info
-	if g, _ := h.goalTracker.GetActiveGoal(userID); g != nil {
+	if g, _ := h.goalTracker.GetActiveGoal(r.Context(), userID); g != nil {
 		out.HasGoal = true
-		out.GoalWeight = fmt.Sprintf("%.1f", g.TargetWeight().Float64())
+		out.GoalWeight = loc.Number(g.TargetWeight().Float64(), 1)
 		out.GoalUnit = g.Unit().String()
 
 		// Calculate remaining to goal
@@ -677,23 +943,23 @@ func (h *Handlers) StatPillsHandler(w http.ResponseWriter, r *http.Request) {
 				out.HasRemaining = true
 				// Show absolute value
 				if remaining > 0 {
-					out.Remaining = fmt.Sprintf("%.1f", remaining)
+					out.Remaining = loc.Number(remaining, 1)
 				} else {
-					out.Remaining = fmt.Sprintf("%.1f", -remaining)
+					out.Remaining = loc.Number(-remaining, 1)
 				}
 			}
 		}
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "partials_stat_pills.html", out); err != nil {
-		writeError(h.logger, w, r, http.StatusInternalServerError, "Template error", err)
+	if err := render(h.templates, w, r, "partials_stat_pills.html", out); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
 		return
 	}
 }
 
 // loadTemplates loads HTML templates from files
-func loadTemplates() *template.Template {
-	tmpl := template.New("").Funcs(template.FuncMap{
+func loadTemplates() templateSet {
+	return parseTemplates(template.FuncMap{
 		"title": func(s string) string {
 			if len(s) == 0 {
 				return s
@@ -709,11 +975,6 @@ func loadTemplates() *template.Template {
 			return template.JS(string(b))
 		},
 	})
-
-	// Load templates from embedded filesystem
-	template.Must(tmpl.ParseFS(assets.FS, "templates/*.html"))
-
-	return tmpl
 }
 
 // Helper function to convert typed slice to interface slice for templates
@@ -723,38 +984,48 @@ func interfaceSlice(slice interface{}) []interface{} {
 	return []interface{}{}
 }
 
-// writeError writes a uniform error structure and logs it
-func writeError(logger *slog.Logger, w http.ResponseWriter, r *http.Request, status int, message string, err error) {
-	reqID := r.Header.Get("X-Request-ID")
+// writeError writes a uniform error structure and logs it. message is a
+// catalog key, formatted with args; clients get it in their own language,
+// or the explanation of err when a client error was caused by a known
+// domain error.
+func writeError(logger *slog.Logger, w http.ResponseWriter, r *http.Request, status int, message string, err error, args ...any) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		status, message, args = http.StatusServiceUnavailable, "error.timeout", nil
+	}
 	if err != nil {
-		logger.Error("http_error",
+		logger.ErrorContext(r.Context(), "http_error",
 			slog.Int("status", status),
-			slog.String("message", message),
+			slog.String("message", i18n.English.T(message, args...)),
 			slog.Any("error", err),
 			slog.String("path", r.URL.Path),
-			slog.String("request_id", reqID),
 		)
 	} else {
-		logger.Warn("http_error",
+		logger.WarnContext(r.Context(), "http_error",
 			slog.Int("status", status),
-			slog.String("message", message),
+			slog.String("message", i18n.English.T(message, args...)),
 			slog.String("path", r.URL.Path),
-			slog.String("request_id", reqID),
 		)
 	}
 
-	// JSON for /api, HTML/plain otherwise
-	isAPI := strings.HasPrefix(r.URL.Path, "/api/")
+	loc := i18n.FromContext(r.Context())
+	text := loc.T(message, args...)
+	if key, ok := errorKey(err); ok && status < http.StatusInternalServerError {
+		text = loc.T(key)
+	}
+
+	// JSON for /api and /admin, HTML/plain otherwise
+	isAPI := strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/admin/")
 	if isAPI {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(status)
 		_ = json.NewEncoder(w).Encode(map[string]any{
 			"success":    false,
 			"error":      http.StatusText(status),
-			"message":    message,
-			"request_id": reqID,
+			"code":       strings.TrimPrefix(message, "error."),
+			"message":    text,
+			"request_id": logging.RequestIDFromContext(r.Context()),
 		})
 		return
 	}
-	http.Error(w, message, status)
+	http.Error(w, text, status)
 }
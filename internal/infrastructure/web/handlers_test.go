@@ -0,0 +1,527 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	assets "peso"
+	"peso/internal/application"
+	"peso/internal/domain/audit"
+	"peso/internal/domain/goal"
+	"peso/internal/domain/session"
+	"peso/internal/domain/user"
+	"peso/internal/domain/weight"
+	"peso/internal/infrastructure/eventbus"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/metrics"
+	"peso/internal/infrastructure/middleware"
+	"peso/internal/infrastructure/persistence"
+)
+
+// testServer serves the data handlers over a migrated SQLite database
+// behind the session and audit middleware, as NewRouter does.
+type testServer struct {
+	handler http.Handler
+	weights *application.WeightTracker
+	goals   *application.GoalTracker
+	auth    *application.AuthService
+	audit   *application.AuditLog
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	db, err := persistence.NewDB(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrations, err := assets.Migrations("sqlite")
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if err := db.Migrate(migrations); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	events := eventbus.New(logger)
+	t.Cleanup(func() { events.Close(context.Background()) })
+
+	userRepo := persistence.NewUserRepository(db)
+	weightRepo := persistence.NewWeightRepository(db)
+	s := &testServer{
+		weights: application.NewWeightTracker(userRepo, weightRepo, db, events),
+		goals:   application.NewGoalTracker(userRepo, weightRepo, persistence.NewGoalRepository(db), db, events),
+		auth:    application.NewAuthService(userRepo, persistence.NewSessionRepository(db), db, events),
+		audit:   application.NewAuditLog(persistence.NewAuditRepository(db)),
+	}
+
+	handlers := NewHandlers(s.weights, s.goals, userRepo, logger)
+	authHandlers := NewAuthHandlers(s.auth, metrics.New(), logger)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", authHandlers.LoginHandler)
+	mux.HandleFunc("GET /set-password", authHandlers.SetPasswordPageHandler)
+	mux.HandleFunc("POST /set-password", authHandlers.SetPasswordHandler)
+	mux.HandleFunc("POST /api/weights", handlers.AddWeightHandler)
+	mux.HandleFunc("POST /api/weights/batch", handlers.AddWeightsBatchHandler)
+	mux.HandleFunc("POST /api/goals", handlers.AddGoalHandler)
+	mux.HandleFunc("DELETE /api/weights/{userID}/{weightID}", handlers.DeleteWeightHandler)
+	mux.HandleFunc("POST /api/weights/{userID}/{weightID}/restore", handlers.RestoreWeightHandler)
+	mux.HandleFunc("DELETE /api/goals/{userID}/{goalID}", handlers.DeleteGoalHandler)
+	mux.HandleFunc("POST /api/goals/{userID}/{goalID}/restore", handlers.RestoreGoalHandler)
+	s.handler = middleware.SessionMiddleware(s.auth)(middleware.AuditMetadata(mux))
+	return s
+}
+
+// signUp creates an account and returns the cookie of a new session.
+func (s *testServer) signUp(t *testing.T, email string) (*user.User, *http.Cookie) {
+	t.Helper()
+
+	ctx := context.Background()
+	if _, err := s.auth.CreateUser(ctx, strings.Split(email, "@")[0], email, "correct horse battery"); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	u, sess, err := s.auth.Login(ctx, email, "correct horse battery", session.ClientInfo{UserAgent: "test"})
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	return u, &http.Cookie{Name: middleware.CookieName, Value: sess.Token()}
+}
+
+// do sends a request, signed in when cookie is not nil. A body is sent as
+// a form unless it starts like JSON.
+func (s *testServer) do(method, path string, cookie *http.Cookie, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	switch {
+	case strings.HasPrefix(body, "{"):
+		req.Header.Set("Content-Type", "application/json")
+	case body != "":
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *testServer) recordWeight(t *testing.T, userID user.UserID, value float64) *weight.Weight {
+	t.Helper()
+
+	v, err := weight.NewWeightValue(value)
+	if err != nil {
+		t.Fatalf("invalid weight: %v", err)
+	}
+	w, err := s.weights.RecordWeight(context.Background(), userID, v, weight.WeightUnitKg, time.Now(), "")
+	if err != nil {
+		t.Fatalf("failed to record weight: %v", err)
+	}
+	return w
+}
+
+func (s *testServer) setGoal(t *testing.T, userID user.UserID, target float64) *goal.Goal {
+	t.Helper()
+
+	v, err := weight.NewWeightValue(target)
+	if err != nil {
+		t.Fatalf("invalid target: %v", err)
+	}
+	due := time.Now().AddDate(0, 3, 0)
+	date, err := goal.NewTargetDate(due.Year(), int(due.Month()), due.Day())
+	if err != nil {
+		t.Fatalf("invalid target date: %v", err)
+	}
+	g, err := s.goals.SetGoal(context.Background(), userID, v, weight.WeightUnitKg, date, "")
+	if err != nil {
+		t.Fatalf("failed to set goal: %v", err)
+	}
+	return g
+}
+
+func (s *testServer) weightCount(t *testing.T, userID user.UserID) int {
+	t.Helper()
+
+	history, err := s.weights.GetWeightHistory(context.Background(), userID, application.TimePeriodAll)
+	if err != nil {
+		t.Fatalf("failed to read weights: %v", err)
+	}
+	return len(history)
+}
+
+func (s *testServer) hasActiveGoal(userID user.UserID) bool {
+	_, err := s.goals.GetActiveGoal(context.Background(), userID)
+	return err == nil
+}
+
+func TestDeleteAndRestoreWeight(t *testing.T) {
+	s := newTestServer(t)
+	giada, giadaCookie := s.signUp(t, "giada@example.com")
+	w := s.recordWeight(t, giada.ID(), 72.4)
+
+	deleteURL := "/api/weights/" + giada.ID().String() + "/" + w.ID().String()
+	rec := s.do(http.MethodDelete, deleteURL, giadaCookie, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected delete to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := s.weightCount(t, giada.ID()); got != 0 {
+		t.Errorf("expected the weight to be deleted, %d left", got)
+	}
+	if !strings.Contains(rec.Body.String(), deleteURL+"/restore") {
+		t.Errorf("expected the toast to offer undo, got %s", rec.Body)
+	}
+
+	rec = s.do(http.MethodPost, deleteURL+"/restore", giadaCookie, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected restore to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := s.weightCount(t, giada.ID()); got != 1 {
+		t.Errorf("expected the weight to be restored, got %d", got)
+	}
+}
+
+func TestDeleteAndRestoreGoal(t *testing.T) {
+	s := newTestServer(t)
+	giada, giadaCookie := s.signUp(t, "giada@example.com")
+	s.recordWeight(t, giada.ID(), 72.4)
+	g := s.setGoal(t, giada.ID(), 70)
+
+	deleteURL := "/api/goals/" + giada.ID().String() + "/" + g.ID().String()
+	rec := s.do(http.MethodDelete, deleteURL, giadaCookie, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected delete to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+	if s.hasActiveGoal(giada.ID()) {
+		t.Error("expected the goal to be deleted")
+	}
+	if !strings.Contains(rec.Body.String(), deleteURL+"/restore") {
+		t.Errorf("expected the toast to offer undo, got %s", rec.Body)
+	}
+
+	rec = s.do(http.MethodPost, deleteURL+"/restore", giadaCookie, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected restore to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+	if !s.hasActiveGoal(giada.ID()) {
+		t.Error("expected the goal to be restored")
+	}
+}
+
+func TestDeleteAndRestore_RequireOwner(t *testing.T) {
+	s := newTestServer(t)
+	giada, _ := s.signUp(t, "giada@example.com")
+	_, lucaCookie := s.signUp(t, "luca@example.com")
+
+	w := s.recordWeight(t, giada.ID(), 72.4)
+	deleted := s.recordWeight(t, giada.ID(), 72.1)
+	if err := s.weights.DeleteWeight(context.Background(), giada.ID(), deleted.ID()); err != nil {
+		t.Fatalf("failed to delete weight: %v", err)
+	}
+	g := s.setGoal(t, giada.ID(), 70)
+
+	weightURL := "/api/weights/" + giada.ID().String() + "/"
+	goalURL := "/api/goals/" + giada.ID().String() + "/" + g.ID().String()
+	requests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "delete weight", method: http.MethodDelete, path: weightURL + w.ID().String()},
+		{name: "restore weight", method: http.MethodPost, path: weightURL + deleted.ID().String() + "/restore"},
+		{name: "delete goal", method: http.MethodDelete, path: goalURL},
+		{name: "restore goal", method: http.MethodPost, path: goalURL + "/restore"},
+	}
+	callers := []struct {
+		name       string
+		cookie     *http.Cookie
+		wantStatus int
+	}{
+		{name: "without session", wantStatus: http.StatusUnauthorized},
+		{name: "as another user", cookie: lucaCookie, wantStatus: http.StatusForbidden},
+	}
+
+	for _, req := range requests {
+		for _, caller := range callers {
+			t.Run(req.name+" "+caller.name, func(t *testing.T) {
+				rec := s.do(req.method, req.path, caller.cookie, "")
+				if rec.Code != caller.wantStatus {
+					t.Errorf("expected status %d, got %d: %s", caller.wantStatus, rec.Code, rec.Body)
+				}
+			})
+		}
+	}
+
+	if got := s.weightCount(t, giada.ID()); got != 1 {
+		t.Errorf("expected Giada's weights to be untouched, got %d", got)
+	}
+	if !s.hasActiveGoal(giada.ID()) {
+		t.Error("expected Giada's goal to be untouched")
+	}
+}
+
+func TestAddWeightAndGoal_AuditTheSignedInUser(t *testing.T) {
+	s := newTestServer(t)
+	giada, giadaCookie := s.signUp(t, "giada@example.com")
+
+	rec := s.do(http.MethodPost, "/api/weights", giadaCookie, url.Values{
+		"user_id": {giada.ID().String()},
+		"weight":  {"72.4"},
+	}.Encode())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the weight to be recorded, got %d: %s", rec.Code, rec.Body)
+	}
+	var recorded struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &recorded); err != nil || recorded.Message != i18n.Default.T("toast.weight_recorded") {
+		t.Errorf("expected the translated confirmation, got %s", rec.Body)
+	}
+	rec = s.do(http.MethodPost, "/api/goals", giadaCookie, url.Values{
+		"user_id":       {giada.ID().String()},
+		"target_weight": {"70"},
+		"target_date":   {time.Now().AddDate(0, 3, 0).Format("2006-01-02")},
+	}.Encode())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the goal to be set, got %d: %s", rec.Code, rec.Body)
+	}
+
+	events, err := s.audit.UserActivity(context.Background(), giada.ID(), 10)
+	if err != nil {
+		t.Fatalf("failed to read the audit log: %v", err)
+	}
+	actors := map[audit.Action]user.UserID{}
+	for _, e := range events {
+		actors[e.Action()] = e.ActorID()
+	}
+	for _, action := range []audit.Action{audit.ActionWeightRecorded, audit.ActionGoalSet} {
+		actor, ok := actors[action]
+		if !ok {
+			t.Errorf("expected a %s event", action)
+		} else if actor != giada.ID() {
+			t.Errorf("expected %s to be attributed to Giada, got %q", action, actor)
+		}
+	}
+}
+
+func TestAddWeightAndGoal_RequireOwner(t *testing.T) {
+	s := newTestServer(t)
+	giada, _ := s.signUp(t, "giada@example.com")
+	_, lucaCookie := s.signUp(t, "luca@example.com")
+
+	requests := []struct {
+		name string
+		path string
+		form url.Values
+	}{
+		{name: "add weight", path: "/api/weights", form: url.Values{"weight": {"72.4"}}},
+		{name: "add goal", path: "/api/goals", form: url.Values{
+			"target_weight": {"70"},
+			"target_date":   {time.Now().AddDate(0, 3, 0).Format("2006-01-02")},
+		}},
+	}
+	callers := []struct {
+		name       string
+		cookie     *http.Cookie
+		wantStatus int
+	}{
+		{name: "without session", wantStatus: http.StatusUnauthorized},
+		{name: "as another user", cookie: lucaCookie, wantStatus: http.StatusForbidden},
+	}
+
+	for _, req := range requests {
+		req.form.Set("user_id", giada.ID().String())
+		for _, caller := range callers {
+			t.Run(req.name+" "+caller.name, func(t *testing.T) {
+				rec := s.do(http.MethodPost, req.path, caller.cookie, req.form.Encode())
+				if rec.Code != caller.wantStatus {
+					t.Errorf("expected status %d, got %d: %s", caller.wantStatus, rec.Code, rec.Body)
+				}
+			})
+		}
+	}
+
+	if got := s.weightCount(t, giada.ID()); got != 0 {
+		t.Errorf("expected no weights for Giada, got %d", got)
+	}
+	if s.hasActiveGoal(giada.ID()) {
+		t.Error("expected no goal for Giada")
+	}
+}
+
+// batchEntry is one entry of a POST /api/weights/batch body.
+func batchEntry(key string, value float64, unit string, measuredAt time.Time) string {
+	return fmt.Sprintf(`{"key":%q,"weight":%v,"unit":%q,"measured_at":%q}`, key, value, unit, measuredAt.Format(time.RFC3339))
+}
+
+func TestAddWeightsBatch_ReportsEveryEntry(t *testing.T) {
+	s := newTestServer(t)
+	giada, giadaCookie := s.signUp(t, "giada@example.com")
+	morning := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+
+	rec := s.do(http.MethodPost, "/api/weights/batch", giadaCookie,
+		`{"user_id":"`+giada.ID().String()+`","entries":[`+batchEntry("key-sent-before", 72.4, "kg", morning)+`]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the first batch to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+
+	entries := strings.Join([]string{
+		batchEntry("key-sent-before", 72.4, "kg", morning),
+		batchEntry("key-new", 72.1, "kg", morning.Add(time.Hour)),
+		batchEntry("key-bad-unit", 72.0, "stone", morning),
+		batchEntry("key-bad-value", -1, "kg", morning),
+	}, ",")
+	rec = s.do(http.MethodPost, "/api/weights/batch", giadaCookie, `{"entries":[`+entries+`]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the batch to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp struct {
+		Results []struct {
+			Key      string `json:"key"`
+			Status   string `json:"status"`
+			WeightID string `json:"weight_id"`
+			Error    string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := []struct {
+		key    string
+		status application.EntryStatus
+	}{
+		{key: "key-sent-before", status: application.EntryDuplicate},
+		{key: "key-new", status: application.EntryCreated},
+		{key: "key-bad-unit", status: application.EntryRejected},
+		{key: "key-bad-value", status: application.EntryRejected},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("expected %d results, got %d: %s", len(want), len(resp.Results), rec.Body)
+	}
+	for i, w := range want {
+		got := resp.Results[i]
+		if got.Key != w.key || got.Status != string(w.status) {
+			t.Errorf("result %d: expected %s %s, got %s %s", i, w.key, w.status, got.Key, got.Status)
+		}
+		if rejected := w.status == application.EntryRejected; rejected != (got.Error != "") {
+			t.Errorf("result %d: expected an error only for rejected entries, got %q", i, got.Error)
+		}
+		if rejected := w.status == application.EntryRejected; rejected != (got.WeightID == "") {
+			t.Errorf("result %d: expected a weight ID only for recorded entries, got %q", i, got.WeightID)
+		}
+	}
+	if rec.Header().Get("HX-Trigger") != "weight-updated" {
+		t.Error("expected the batch to trigger weight-updated")
+	}
+	if got := s.weightCount(t, giada.ID()); got != 2 {
+		t.Errorf("expected 2 weights, got %d", got)
+	}
+}
+
+func TestAddWeightsBatch_RequireOwner(t *testing.T) {
+	s := newTestServer(t)
+	giada, _ := s.signUp(t, "giada@example.com")
+	_, lucaCookie := s.signUp(t, "luca@example.com")
+	body := `{"user_id":"` + giada.ID().String() + `","entries":[` + batchEntry("key-1", 72.4, "kg", time.Now()) + `]}`
+
+	tests := []struct {
+		name       string
+		cookie     *http.Cookie
+		wantStatus int
+	}{
+		{name: "without session", wantStatus: http.StatusUnauthorized},
+		{name: "entries of another user", cookie: lucaCookie, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(http.MethodPost, "/api/weights/batch", tt.cookie, body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	if got := s.weightCount(t, giada.ID()); got != 0 {
+		t.Errorf("expected no weights for Giada, got %d", got)
+	}
+}
+
+func TestSetPassword_RequiresValidClaim(t *testing.T) {
+	s := newTestServer(t)
+	giada, _ := s.signUp(t, "giada@example.com")
+	ctx := context.Background()
+
+	form := url.Values{"password": {"hijacked password"}, "confirm_password": {"hijacked password"}}.Encode()
+	claims := []struct {
+		name   string
+		cookie *http.Cookie
+	}{
+		{name: "without claim"},
+		{name: "with a forged email cookie", cookie: &http.Cookie{Name: "pending_email", Value: "giada@example.com"}},
+		{name: "with an email as claim", cookie: &http.Cookie{Name: passwordClaimCookie, Value: "giada@example.com"}},
+		{name: "with a claim for a user with a password", cookie: &http.Cookie{Name: passwordClaimCookie, Value: s.auth.PasswordClaim(giada.ID())}},
+	}
+
+	for _, c := range claims {
+		t.Run(c.name, func(t *testing.T) {
+			rec := s.do(http.MethodPost, "/set-password", c.cookie, form)
+			if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/login" {
+				t.Errorf("expected a redirect to /login, got %d %q", rec.Code, rec.Header().Get("Location"))
+			}
+		})
+	}
+
+	if _, _, err := s.auth.Login(ctx, "giada@example.com", "correct horse battery", session.ClientInfo{}); err != nil {
+		t.Errorf("expected Giada's password to be unchanged, got %v", err)
+	}
+	if _, _, err := s.auth.Login(ctx, "giada@example.com", "hijacked password", session.ClientInfo{}); err == nil {
+		t.Error("expected the hijacked password to be rejected")
+	}
+}
+
+func TestSetPassword_ClaimsOnce(t *testing.T) {
+	s := newTestServer(t)
+	if _, err := s.auth.CreateUser(context.Background(), "luca", "luca@example.com", ""); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	rec := s.do(http.MethodPost, "/login", nil, url.Values{"email": {"luca@example.com"}}.Encode())
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/set-password" {
+		t.Fatalf("expected a redirect to /set-password, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	var claim *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == passwordClaimCookie {
+			claim = c
+		}
+	}
+	if claim == nil || claim.Value == "" || strings.Contains(claim.Value, "luca@example.com") {
+		t.Fatalf("expected an opaque claim cookie, got %v", claim)
+	}
+
+	form := url.Values{"password": {"correct horse battery"}, "confirm_password": {"correct horse battery"}}.Encode()
+	rec = s.do(http.MethodPost, "/set-password", claim, form)
+	if rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), "/users/") {
+		t.Fatalf("expected to be signed in, got %d %q: %s", rec.Code, rec.Header().Get("Location"), rec.Body)
+	}
+
+	form = url.Values{"password": {"hijacked password"}, "confirm_password": {"hijacked password"}}.Encode()
+	rec = s.do(http.MethodPost, "/set-password", claim, form)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/login" {
+		t.Errorf("expected a used claim to be refused, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if _, _, err := s.auth.Login(context.Background(), "luca@example.com", "correct horse battery", session.ClientInfo{}); err != nil {
+		t.Errorf("expected the first password to be kept, got %v", err)
+	}
+}
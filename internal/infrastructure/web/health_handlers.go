@@ -0,0 +1,76 @@
+package web
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"peso/internal/buildinfo"
+	"peso/internal/infrastructure/health"
+)
+
+// HealthHandlers serve the probes used by orchestrators and monitoring
+type HealthHandlers struct {
+	checker *health.Checker
+	logger  *slog.Logger
+}
+
+// NewHealthHandlers creates probe handlers backed by checker
+func NewHealthHandlers(checker *health.Checker, logger *slog.Logger) *HealthHandlers {
+	return &HealthHandlers{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+// LiveHandler reports that the process is up and serving HTTP. It touches
+// no dependencies, so a slow database never gets the process restarted.
+func (h *HealthHandlers) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, r, http.StatusOK, map[string]any{
+		"status":         "alive",
+		"uptime_seconds": int64(h.checker.Uptime().Seconds()),
+	})
+}
+
+// ReadyHandler reports whether this instance should receive traffic: the
+// database answers, its schema is current and the data directory is
+// writable. It fails while the server is shutting down.
+func (h *HealthHandlers) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+	h.writeJSON(w, r, reportStatus(report), report)
+}
+
+// HealthHandler combines the readiness report with build information and
+// uptime for humans and dashboards.
+func (h *HealthHandlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+	uptime := h.checker.Uptime()
+	h.writeJSON(w, r, reportStatus(report), struct {
+		health.Report
+		Build         buildinfo.Info `json:"build"`
+		Uptime        string         `json:"uptime"`
+		UptimeSeconds int64          `json:"uptime_seconds"`
+	}{
+		Report:        report,
+		Build:         buildinfo.Get(),
+		Uptime:        uptime.Truncate(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+	})
+}
+
+func (h *HealthHandlers) writeJSON(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.WarnContext(r.Context(), "health_response_failed", slog.Any("error", err))
+	}
+}
+
+func reportStatus(report health.Report) int {
+	if report.Ready() {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,98 @@
+package web
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"peso/internal/infrastructure/live"
+	"peso/internal/infrastructure/middleware"
+)
+
+const (
+	// liveHeartbeatInterval keeps idle streams open through proxies that
+	// drop silent connections.
+	liveHeartbeatInterval = 25 * time.Second
+	// liveRetry is how long browsers wait before reconnecting.
+	liveRetry = 5 * time.Second
+)
+
+// LiveHandlers streams changes to a user's data to their open pages
+type LiveHandlers struct {
+	broker *live.Broker
+	logger *slog.Logger
+}
+
+// NewLiveHandlers creates live update handlers
+func NewLiveHandlers(broker *live.Broker, logger *slog.Logger) *LiveHandlers {
+	return &LiveHandlers{
+		broker: broker,
+		logger: logger,
+	}
+}
+
+// EventsHandler serves the current user's changes as server-sent events
+// named after the dashboard's HTMX triggers. Reconnecting clients send
+// Last-Event-ID and get what they missed replayed first.
+func (h *LiveHandlers) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		writeError(h.logger, w, r, http.StatusUnauthorized, "error.not_authenticated", nil)
+		return
+	}
+	if r.PathValue("userID") != currentUser.ID().String() {
+		writeError(h.logger, w, r, http.StatusForbidden, "error.forbidden", nil)
+		return
+	}
+
+	sub := h.broker.Subscribe(currentUser.ID(), r.Header.Get("Last-Event-ID"))
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n", liveRetry.Milliseconds())
+	if sub.Replay == nil {
+		// An id without data sets the client's Last-Event-ID, so a
+		// reconnect replays whatever happens from here on.
+		fmt.Fprintf(w, "id: %d\n\n", sub.LastID)
+	} else {
+		fmt.Fprint(w, "\n")
+	}
+	for _, msg := range sub.Replay {
+		writeLiveMessage(w, msg)
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		h.logger.ErrorContext(r.Context(), "live_stream_unsupported", slog.Any("error", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return
+			}
+			writeLiveMessage(w, msg)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeLiveMessage(w http.ResponseWriter, msg live.Message) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Topic, msg.Data)
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"errors"
+
+	"peso/internal/application"
+	"peso/internal/domain/goal"
+	"peso/internal/domain/idempotency"
+	"peso/internal/domain/user"
+	"peso/internal/domain/webhook"
+	"peso/internal/domain/weight"
+	"peso/internal/infrastructure/i18n"
+)
+
+// errorMessages maps the domain and application errors a user can cause to
+// the catalog messages explaining them
+var errorMessages = []struct {
+	err error
+	key string
+}{
+	{weight.ErrWeightTooLow, "error.domain.weight_too_low"},
+	{weight.ErrWeightTooHigh, "error.domain.weight_too_high"},
+	{weight.ErrWeightInvalid, "error.domain.weight_invalid"},
+	{weight.ErrZeroWeight, "error.domain.weight_invalid"},
+	{weight.ErrInvalidWeightUnit, "error.domain.weight_unit"},
+	{weight.ErrFutureMeasurement, "error.domain.future_measurement"},
+	{application.ErrMissingMeasurementTime, "error.domain.missing_measurement_time"},
+	{application.ErrMaxDailyRecordings, "error.domain.max_daily_recordings"},
+	{application.ErrUserNotFound, "error.domain.user_not_found"},
+	{application.ErrUserNotActive, "error.domain.user_not_active"},
+	{goal.ErrInvalidDate, "error.domain.invalid_date"},
+	{goal.ErrPastDate, "error.domain.past_date"},
+	{application.ErrNoCurrentWeight, "error.domain.no_current_weight"},
+	{application.ErrSameWeight, "error.domain.same_weight"},
+	{application.ErrActiveGoalExists, "error.domain.active_goal_exists"},
+	{application.ErrUnrealisticGoal, "error.domain.unrealistic_goal"},
+	{user.ErrPasswordTooShort, "error.domain.password_too_short"},
+	{user.ErrInvalidLanguage, "error.domain.invalid_language"},
+	{application.ErrInvalidEmail, "error.domain.invalid_email"},
+	{idempotency.ErrInvalidKey, "error.domain.idempotency_key"},
+	{webhook.ErrInvalidURL, "error.domain.invalid_url"},
+	{webhook.ErrNoEvents, "error.domain.no_events"},
+	{webhook.ErrUnsupportedEvent, "error.domain.unsupported_event"},
+	{application.ErrTooManyWebhooks, "error.domain.too_many_webhooks"},
+}
+
+// errorKey returns the catalog message for err, if it is one users can
+// cause
+func errorKey(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	for _, m := range errorMessages {
+		if errors.Is(err, m.err) {
+			return m.key, true
+		}
+	}
+	return "", false
+}
+
+// errorMessage explains err in the given language, falling back to the
+// error's own text
+func errorMessage(loc i18n.Locale, err error) string {
+	if key, ok := errorKey(err); ok {
+		return loc.T(key)
+	}
+	return err.Error()
+}
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+
+	"github.com/google/uuid"
+
+	assets "peso"
+	"peso/internal/domain/idempotency"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/middleware"
+)
+
+// requestFuncs returns the template helpers whose output depends on the
+// current request. Templates are parsed with placeholders (r == nil) and
+// bound to the real request in render.
+func requestFuncs(r *http.Request) template.FuncMap {
+	token, nonce, loc := "", "", i18n.Default
+	if r != nil {
+		token = middleware.CSRFTokenFromContext(r.Context())
+		nonce = middleware.CSPNonceFromContext(r.Context())
+		loc = i18n.FromContext(r.Context())
+	}
+
+	return template.FuncMap{
+		// csrfToken is echoed by HTMX and fetch via the X-CSRF-Token header
+		"csrfToken": func() string {
+			return token
+		},
+		// csrfField renders the hidden input plain HTML forms must include
+		"csrfField": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + middleware.CSRFFormField + `" value="` + template.HTMLEscapeString(token) + `">`)
+		},
+		// idempotencyField gives a create form a fresh key, so submitting
+		// it twice creates one record
+		"idempotencyField": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + idempotency.FormField + `" value="` + uuid.NewString() + `">`)
+		},
+		// cspNonce must be set on every <script> element
+		"cspNonce": func() string {
+			return nonce
+		},
+		// t translates a catalog message into the request's language
+		"t": func(key string, args ...any) string {
+			return loc.T(key, args...)
+		},
+		// lang is the request's language code, for <html lang>
+		"lang": func() string {
+			return string(loc)
+		},
+		// number formats a value with the request's decimal separator
+		"number": func(v float64, decimals int) string {
+			return loc.Number(v, decimals)
+		},
+	}
+}
+
+// templateSet holds every file in templates/ as its own template, keyed by
+// file name and parsed with placeholder request helpers. The files do not
+// include one another, so render only clones the one it executes.
+type templateSet map[string]*template.Template
+
+// parseTemplates parses the embedded templates with funcs and the request
+// helpers.
+func parseTemplates(funcs template.FuncMap) templateSet {
+	files, err := fs.Glob(assets.FS, "templates/*.html")
+	if err != nil {
+		panic(err)
+	}
+
+	set := make(templateSet, len(files))
+	for _, file := range files {
+		name := path.Base(file)
+		tmpl := template.New(name).Funcs(funcs).Funcs(requestFuncs(nil))
+		set[name] = template.Must(tmpl.ParseFS(assets.FS, file))
+	}
+	return set
+}
+
+// render executes the named template with request scoped helpers bound in.
+// The parsed template is cloned so it is never executed directly.
+func render(set templateSet, w http.ResponseWriter, r *http.Request, name string, data any) error {
+	tmpl, ok := set[name]
+	if !ok {
+		return fmt.Errorf("template %q not found", name)
+	}
+	t, err := tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	return t.Funcs(requestFuncs(r)).Execute(w, data)
+}
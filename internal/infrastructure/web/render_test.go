@@ -0,0 +1,66 @@
+package web
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	assets "peso"
+	"peso/internal/infrastructure/middleware"
+)
+
+func TestRender_BindsEachRequest(t *testing.T) {
+	set := loadAuthTemplates()
+	handler := middleware.CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := render(set, w, r, "login.html", struct{ Title, Error string }{}); err != nil {
+			t.Errorf("failed to render: %v", err)
+		}
+	}))
+
+	for _, token := range []string{"token-giada", "token-luca"} {
+		req := httptest.NewRequest(http.MethodGet, "/login", nil)
+		req.AddCookie(&http.Cookie{Name: middleware.CSRFCookieName, Value: token})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		field := `class="auth-form">` + "\n" + `                <input type="hidden" name="` + middleware.CSRFFormField + `" value="` + token + `">`
+		if !strings.Contains(rec.Body.String(), field) {
+			t.Errorf("expected the form to start with the CSRF field for %s, got:\n%s", token, rec.Body)
+		}
+	}
+
+	// Templates that have been executed can no longer be cloned.
+	if _, err := set["login.html"].Clone(); err != nil {
+		t.Errorf("expected the parsed template to be left unexecuted, got %v", err)
+	}
+	if err := render(set, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "missing.html", nil); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+}
+
+// TestTemplates_ScriptsMatchPolicy keeps the third-party scripts the
+// templates load in step with the versioned URLs script-src allows.
+func TestTemplates_ScriptsMatchPolicy(t *testing.T) {
+	allowed := middleware.DefaultSecurityHeadersConfig().ScriptSources
+	external := regexp.MustCompile(`<script[^>]*\ssrc="(https?://[^"]+)"`)
+
+	files, err := fs.Glob(assets.FS, "templates/*.html")
+	if err != nil {
+		t.Fatalf("failed to list templates: %v", err)
+	}
+	for _, name := range files {
+		content, err := fs.ReadFile(assets.FS, name)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		for _, m := range external.FindAllStringSubmatch(string(content), -1) {
+			if !slices.Contains(allowed, m[1]) {
+				t.Errorf("%s loads %s, which script-src does not allow", name, m[1])
+			}
+		}
+	}
+}
@@ -5,11 +5,19 @@ import (
 	"io/fs"
 	"log/slog"
 	"net/http"
+	"time"
 
 	assets "peso"
 	"peso/internal/application"
+	"peso/internal/config"
+	"peso/internal/infrastructure/backup"
+	"peso/internal/infrastructure/health"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/live"
 	"peso/internal/infrastructure/logging"
+	"peso/internal/infrastructure/metrics"
 	"peso/internal/infrastructure/middleware"
+	"peso/internal/infrastructure/tracing"
 	"peso/internal/interfaces"
 )
 
@@ -34,19 +42,32 @@ func (m *mergedFileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func NewRouter(
+	cfg *config.Config,
+	defaultLocale i18n.Locale,
 	weightTracker *application.WeightTracker,
 	goalTracker *application.GoalTracker,
 	authService *application.AuthService,
+	auditLog *application.AuditLog,
+	webhookService *application.WebhookService,
+	idempotency *application.Idempotency,
 	userRepo interfaces.UserRepository,
+	backups *backup.Manager,
+	broker *live.Broker,
+	m *metrics.Metrics,
+	checker *health.Checker,
 	logger *slog.Logger,
 ) http.Handler {
 	mux := http.NewServeMux()
 
 	handlers := NewHandlers(weightTracker, goalTracker, userRepo, logger)
-	authHandlers := NewAuthHandlers(authService, logger)
-
-	mux.HandleFunc("GET /health", healthHandler)
-	mux.HandleFunc("GET /ready", readyHandler)
+	// Create endpoints can be retried safely with an Idempotency-Key.
+	idempotent := middleware.Idempotent(idempotency, logger)
+	authHandlers := NewAuthHandlers(authService, m, logger)
+	auditHandlers := NewAuditHandlers(auditLog, logger)
+	webhookHandlers := NewWebhookHandlers(webhookService, logger)
+	adminHandlers := NewAdminHandlers(backups, auditLog, logger)
+	liveHandlers := NewLiveHandlers(broker, logger)
+	healthHandlers := NewHealthHandlers(checker, logger)
 
 	registerStaticRoutes(mux, logger)
 
@@ -57,7 +78,15 @@ func NewRouter(
 	mux.HandleFunc("GET /set-password", authHandlers.SetPasswordPageHandler)
 	mux.HandleFunc("POST /set-password", authHandlers.SetPasswordHandler)
 	mux.HandleFunc("POST /logout", authHandlers.LogoutHandler)
-	mux.HandleFunc("GET /logout", authHandlers.LogoutHandler)
+	mux.HandleFunc("GET /account", authHandlers.AccountPageHandler)
+	mux.HandleFunc("POST /account/sessions/{sessionID}/revoke", authHandlers.RevokeSessionHandler)
+	mux.HandleFunc("POST /account/sessions/revoke-others", authHandlers.RevokeOtherSessionsHandler)
+	mux.HandleFunc("POST /account/language", authHandlers.SetLanguageHandler)
+	mux.HandleFunc("GET /account/activity", auditHandlers.ActivityPageHandler)
+	mux.HandleFunc("GET /account/webhooks", webhookHandlers.WebhooksPageHandler)
+	mux.Handle("POST /account/webhooks", idempotent(http.HandlerFunc(webhookHandlers.CreateWebhookHandler)))
+	mux.HandleFunc("POST /account/webhooks/{webhookID}/test", webhookHandlers.TestWebhookHandler)
+	mux.HandleFunc("POST /account/webhooks/{webhookID}/delete", webhookHandlers.DeleteWebhookHandler)
 
 	mux.HandleFunc("GET /", handlers.HomeHandler)
 	mux.HandleFunc("GET /users/{userID}", handlers.UserDashboardHandler)
@@ -69,21 +98,85 @@ func NewRouter(
 	mux.HandleFunc("GET /users/{userID}/stat-hero", handlers.StatHeroHandler)
 	mux.HandleFunc("GET /users/{userID}/stat-pills", handlers.StatPillsHandler)
 
-	mux.HandleFunc("POST /api/weights", handlers.AddWeightHandler)
+	mux.Handle("POST /api/weights", idempotent(http.HandlerFunc(handlers.AddWeightHandler)))
+	mux.Handle("POST /api/weights/batch", idempotent(http.HandlerFunc(handlers.AddWeightsBatchHandler)))
 	mux.HandleFunc("DELETE /api/weights/{userID}/{weightID}", handlers.DeleteWeightHandler)
+	mux.HandleFunc("POST /api/weights/{userID}/{weightID}/restore", handlers.RestoreWeightHandler)
 	mux.HandleFunc("GET /api/weights/{userID}", handlers.WeightHistoryHandler)
 	mux.HandleFunc("GET /api/weights/latest/{userID}", handlers.WeightLatestHandler)
-	mux.HandleFunc("POST /api/goals", handlers.AddGoalHandler)
-
-	var handler http.Handler = mux
-	handler = middleware.SessionMiddleware(authService)(handler)
+	mux.Handle("POST /api/goals", idempotent(http.HandlerFunc(handlers.AddGoalHandler)))
+	mux.HandleFunc("GET /api/goals/active/{userID}", handlers.GoalActiveHandler)
+	mux.HandleFunc("DELETE /api/goals/{userID}/{goalID}", handlers.DeleteGoalHandler)
+	mux.HandleFunc("POST /api/goals/{userID}/{goalID}/restore", handlers.RestoreGoalHandler)
+
+	var app http.Handler = logging.Route(mux)
+	app = middleware.CSRF(app)
+	app = middleware.AuditMetadata(app)
+	app = middleware.Locale(defaultLocale)(app)
+	app = middleware.SessionMiddleware(authService)(app)
+	app = middleware.QueryTimeout(cfg.QueryTimeout)(app)
+
+	// Admin routes authenticate with a bearer token instead of a session and
+	// may run longer than QueryTimeout or WriteTimeout, so they bypass the
+	// browser chain.
+	admin := http.NewServeMux()
+	admin.HandleFunc("POST /admin/backups", adminHandlers.CreateBackupHandler)
+	admin.HandleFunc("GET /admin/backups", adminHandlers.ListBackupsHandler)
+	admin.HandleFunc("GET /admin/backups/{name}", adminHandlers.DownloadBackupHandler)
+	admin.HandleFunc("GET /admin/audit", adminHandlers.ListAuditEventsHandler)
+
+	// Live update streams stay open for as long as the page does, so they
+	// only need the session and must not be cut off by QueryTimeout or the
+	// server timeouts.
+	events := withoutDeadlines(middleware.SessionMiddleware(authService)(http.HandlerFunc(liveHandlers.EventsHandler)))
+
+	// Probes skip sessions and QueryTimeout so they stay cheap and report
+	// on the database themselves.
+	root := http.NewServeMux()
+	root.HandleFunc("GET /live", healthHandlers.LiveHandler)
+	root.HandleFunc("GET /ready", healthHandlers.ReadyHandler)
+	root.HandleFunc("GET /health", healthHandlers.HealthHandler)
+	root.Handle("/admin/", withoutDeadlines(middleware.AdminToken(cfg.AdminToken)(middleware.Locale(defaultLocale)(logging.Route(admin)))))
+	root.Handle("GET /metrics", middleware.BasicAuth("peso metrics", cfg.MetricsUsername, cfg.MetricsPassword)(m.Handler(logger)))
+	root.Handle("GET /users/{userID}/events", events)
+	root.Handle("/", app)
+
+	var handler http.Handler = logging.Route(root)
+	handler = middleware.MaxBodySize(int64(cfg.MaxBodyBytes))(handler)
+	handler = middleware.SecurityHeaders(securityHeadersConfig(cfg))(handler)
 	handler = logging.RequestLogger(logger)(handler)
 	handler = logging.Recoverer(logger)(handler)
-	handler = logging.RequestID(handler)
+	handler = m.Middleware(handler)
+	handler = middleware.ProxyHeaders(cfg.TrustProxy)(handler)
+	if cfg.TracingEnabled {
+		handler = tracing.Middleware(handler)
+	}
+	handler = logging.RequestID(cfg.TrustTraceparent)(handler)
 
 	return handler
 }
 
+// withoutDeadlines lifts the server's read and write timeouts for handlers
+// that legitimately outlast them: event streams and backup transfers.
+func withoutDeadlines(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		// Writers that cannot change deadlines are not subject to them.
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+		next.ServeHTTP(w, r)
+	})
+}
+
+func securityHeadersConfig(cfg *config.Config) middleware.SecurityHeadersConfig {
+	sec := middleware.DefaultSecurityHeadersConfig()
+	sec.HSTSMaxAge = cfg.HSTSMaxAge
+	sec.FrameOptions = cfg.FrameOptions
+	sec.ReferrerPolicy = cfg.ReferrerPolicy
+	sec.CSPReportOnly = cfg.CSPReportOnly
+	return sec
+}
+
 func registerStaticRoutes(mux *http.ServeMux, logger *slog.Logger) {
 	var fs1, fs2 http.FileSystem
 
@@ -106,15 +199,3 @@ func registerStaticRoutes(mux *http.ServeMux, logger *slog.Logger) {
 		http.StripPrefix("/static/", staticHandler).ServeHTTP(w, r)
 	})
 }
-
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "healthy"}`))
-}
-
-func readyHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "ready"}`))
-}
@@ -0,0 +1,252 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"peso/internal/application"
+	"peso/internal/domain/user"
+	"peso/internal/domain/webhook"
+	"peso/internal/infrastructure/i18n"
+	"peso/internal/infrastructure/middleware"
+)
+
+const webhookDeliveriesShown = 5
+
+// deliveryView is a webhook delivery as shown on the webhooks page
+type deliveryView struct {
+	When   string
+	Event  string
+	Status string
+	Failed bool
+	Detail string
+}
+
+// WebhookHandlers lets users manage their outgoing webhooks
+type WebhookHandlers struct {
+	webhooks  *application.WebhookService
+	templates templateSet
+	logger    *slog.Logger
+}
+
+// NewWebhookHandlers creates webhook handlers
+func NewWebhookHandlers(webhooks *application.WebhookService, logger *slog.Logger) *WebhookHandlers {
+	return &WebhookHandlers{
+		webhooks:  webhooks,
+		templates: loadTemplates(),
+		logger:    logger,
+	}
+}
+
+// WebhooksPageHandler lists the current user's webhooks with their recent
+// deliveries
+func (h *WebhookHandlers) WebhooksPageHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	notice := ""
+	if r.URL.Query().Get("test") == "sent" {
+		notice = "webhooks.test_queued"
+	}
+	h.renderPage(w, r, currentUser, http.StatusOK, "", notice)
+}
+
+// CreateWebhookHandler registers a new webhook from the page form
+func (h *WebhookHandlers) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_form", err)
+		return
+	}
+
+	created, err := h.webhooks.Register(r.Context(), currentUser.ID(), r.PostFormValue("url"), r.PostForm["events"])
+	switch {
+	case errors.Is(err, webhook.ErrInvalidURL):
+		h.renderPage(w, r, currentUser, http.StatusBadRequest, "webhooks.error.invalid_url", "")
+		return
+	case errors.Is(err, webhook.ErrNoEvents), errors.Is(err, webhook.ErrUnsupportedEvent):
+		h.renderPage(w, r, currentUser, http.StatusBadRequest, "webhooks.error.no_events", "")
+		return
+	case errors.Is(err, application.ErrTooManyWebhooks):
+		h.renderPage(w, r, currentUser, http.StatusConflict, "webhooks.error.too_many", "")
+		return
+	case err != nil:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.create_webhook_failed", err)
+		return
+	}
+	middleware.SetCreatedResource(r.Context(), created.ID().String())
+
+	http.Redirect(w, r, "/account/webhooks", http.StatusSeeOther)
+}
+
+// DeleteWebhookHandler removes one of the current user's webhooks
+func (h *WebhookHandlers) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	h.withWebhook(w, r, h.webhooks.Delete, "error.delete_webhook_failed", "/account/webhooks")
+}
+
+// TestWebhookHandler queues a test event for one of the current user's
+// webhooks
+func (h *WebhookHandlers) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	h.withWebhook(w, r, h.webhooks.SendTest, "error.send_test_failed", "/account/webhooks?test=sent")
+}
+
+func (h *WebhookHandlers) withWebhook(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, userID user.UserID, id webhook.WebhookID) error, failure, redirect string) {
+	currentUser := middleware.UserFromContext(r.Context())
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	webhookID, err := webhook.ParseWebhookID(r.PathValue("webhookID"))
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "error.invalid_webhook_id", err)
+		return
+	}
+
+	if err := action(r.Context(), currentUser.ID(), webhookID); err != nil {
+		if errors.Is(err, application.ErrWebhookNotFound) {
+			writeError(h.logger, w, r, http.StatusNotFound, "error.webhook_not_found", err)
+			return
+		}
+		writeError(h.logger, w, r, http.StatusInternalServerError, failure, err)
+		return
+	}
+
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+// renderPage shows the webhooks page; formError and notice are catalog keys
+func (h *WebhookHandlers) renderPage(w http.ResponseWriter, r *http.Request, currentUser *user.User, status int, formError, notice string) {
+	loc := i18n.FromContext(r.Context())
+	webhooks, err := h.webhooks.List(r.Context(), currentUser.ID())
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.webhooks_failed", err)
+		return
+	}
+
+	type WebhookRow struct {
+		ID         string
+		URL        string
+		Secret     string
+		Events     []string
+		Deliveries []deliveryView
+	}
+	var rows []WebhookRow
+	for _, hook := range webhooks {
+		deliveries, err := h.webhooks.Deliveries(r.Context(), currentUser.ID(), hook.ID(), webhookDeliveriesShown)
+		if err != nil {
+			writeError(h.logger, w, r, http.StatusInternalServerError, "error.webhook_deliveries_failed", err)
+			return
+		}
+
+		row := WebhookRow{ID: hook.ID().String(), URL: hook.URL(), Secret: hook.Secret()}
+		for _, name := range hook.Events() {
+			row.Events = append(row.Events, webhookEventLabel(loc, name))
+		}
+		for _, d := range deliveries {
+			row.Deliveries = append(row.Deliveries, deliveryRow(loc, d))
+		}
+		rows = append(rows, row)
+	}
+
+	type EventOption struct {
+		Name  string
+		Label string
+	}
+	var options []EventOption
+	for _, name := range webhook.SupportedEvents {
+		options = append(options, EventOption{Name: name, Label: webhookEventLabel(loc, name)})
+	}
+
+	data := struct {
+		Title    string
+		UserID   string
+		UserName string
+		Error    string
+		Notice   string
+		Webhooks []WebhookRow
+		Events   []EventOption
+		URL      string
+	}{
+		Title:    loc.T("webhooks.title"),
+		UserID:   currentUser.ID().String(),
+		UserName: currentUser.Name(),
+		Error:    translate(loc, formError),
+		Notice:   translate(loc, notice),
+		Webhooks: rows,
+		Events:   options,
+	}
+	if formError != "" {
+		data.URL = r.PostFormValue("url")
+	}
+
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		render(h.templates, w, r, "webhooks.html", data)
+		return
+	}
+	if err := render(h.templates, w, r, "webhooks.html", data); err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "error.template", err)
+	}
+}
+
+func webhookEventLabel(loc i18n.Locale, name string) string {
+	if key := "webhooks.event." + name; loc.Has(key) {
+		return loc.T(key)
+	}
+	return name
+}
+
+// translate returns the message for key, or "" when there is none
+func translate(loc i18n.Locale, key string) string {
+	if key == "" {
+		return ""
+	}
+	return loc.T(key)
+}
+
+// deliveryRow describes the state of a delivery in one line
+func deliveryRow(loc i18n.Locale, d *webhook.Delivery) deliveryView {
+	row := deliveryView{
+		When:  loc.DateTime(d.CreatedAt().Local()),
+		Event: webhookEventLabel(loc, d.Event()),
+	}
+
+	switch d.Status() {
+	case webhook.StatusDelivered:
+		row.Status = loc.T("webhooks.status.delivered")
+	case webhook.StatusFailed:
+		row.Status = loc.T("webhooks.status.failed")
+		row.Failed = true
+	default:
+		row.Status = loc.T("webhooks.status.pending")
+		if d.Attempts() > 0 {
+			row.Status = loc.T("webhooks.status.retrying", loc.Time(d.NextAttemptAt().Local()))
+			row.Failed = true
+		}
+	}
+
+	if d.Attempts() > 0 {
+		row.Detail = loc.T("webhooks.attempts_many", d.Attempts())
+		if d.Attempts() == 1 {
+			row.Detail = loc.T("webhooks.attempts_one")
+		}
+		if d.LastError() != "" {
+			row.Detail += " · " + d.LastError()
+		} else if d.LastStatusCode() > 0 {
+			row.Detail += fmt.Sprintf(" · HTTP %d", d.LastStatusCode())
+		}
+	}
+	return row
+}
@@ -0,0 +1,59 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// errForbiddenAddress is recorded for deliveries to an address webhooks
+// may not reach.
+var errForbiddenAddress = errors.New("webhooks may not be sent to this address")
+
+// checkAddress rejects the addresses of the server itself and of cloud
+// metadata services: loopback, link-local and unspecified ones. Private
+// LAN ranges are rejected too unless allowPrivate is set.
+func checkAddress(addr netip.Addr, allowPrivate bool) error {
+	addr = addr.Unmap()
+	switch {
+	case addr.IsLoopback(), addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast(), addr.IsUnspecified():
+		return fmt.Errorf("%w: %s", errForbiddenAddress, addr)
+	case addr.IsPrivate() && !allowPrivate:
+		return fmt.Errorf("%w: %s is private, see WEBHOOK_ALLOW_PRIVATE_NETWORKS", errForbiddenAddress, addr)
+	}
+	return nil
+}
+
+// newClient returns the client deliveries are sent with. Addresses are
+// checked when connecting, after DNS resolution, so a hostname cannot be
+// pointed at an internal address once the webhook has been registered.
+// Proxies are not used, as the check would only see the proxy.
+func newClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			return checkAddress(addrPort.Addr(), allowPrivate)
+		},
+	}
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: requestTimeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
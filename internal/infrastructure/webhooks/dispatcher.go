@@ -0,0 +1,175 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"peso/internal/domain/webhook"
+	"peso/internal/interfaces"
+)
+
+// Headers sent with every delivery, next to webhook.SignatureHeader.
+const (
+	EventHeader    = "X-Peso-Event"
+	DeliveryHeader = "X-Peso-Delivery"
+)
+
+const (
+	batchSize       = 20
+	requestTimeout  = 10 * time.Second
+	maxResponseBody = 4 << 10
+	userAgent       = "Peso-Webhooks/1.0"
+
+	// leaseDuration is how long a claimed delivery is hidden from other
+	// dispatchers. It must comfortably exceed requestTimeout.
+	leaseDuration = 2 * time.Minute
+)
+
+// Dispatcher sends queued webhook deliveries and records every attempt.
+// Failed deliveries are retried with the backoff in webhook.Backoff.
+type Dispatcher struct {
+	webhooks   interfaces.WebhookRepository
+	deliveries interfaces.WebhookDeliveryRepository
+	uow        interfaces.UnitOfWork
+	client     *http.Client
+	logger     *slog.Logger
+
+	now func() time.Time
+}
+
+// NewDispatcher returns a Dispatcher reading the delivery queue. Redirects
+// are not followed: a 3xx response counts as a failed attempt. Deliveries
+// to private LAN addresses fail unless allowPrivateNetworks is set; those
+// to the server itself always do.
+func NewDispatcher(webhooks interfaces.WebhookRepository, deliveries interfaces.WebhookDeliveryRepository, uow interfaces.UnitOfWork, allowPrivateNetworks bool, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		uow:        uow,
+		client:     newClient(allowPrivateNetworks),
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Run delivers whatever is due every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Warn("webhook_dispatch_failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends every delivery that is due and returns how many were
+// attempted.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	attempted := 0
+	for {
+		now := d.now()
+		due, err := d.deliveries.ClaimDue(ctx, now, now.Add(leaseDuration), batchSize)
+		if err != nil {
+			return attempted, err
+		}
+
+		for _, delivery := range due {
+			if ctx.Err() != nil {
+				return attempted, ctx.Err()
+			}
+			if d.deliver(ctx, delivery) {
+				attempted++
+			}
+		}
+
+		if len(due) < batchSize {
+			return attempted, nil
+		}
+	}
+}
+
+// deliver makes one attempt and records it. It reports false when no
+// attempt was made; the delivery is then retried once its lease expires.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *webhook.Delivery) bool {
+	hook, err := d.webhooks.FindByID(ctx, delivery.WebhookID())
+	if err != nil {
+		d.logger.Warn("webhook_lookup_failed",
+			slog.String("delivery_id", delivery.ID()),
+			slog.Any("error", err),
+		)
+		return false
+	}
+
+	start := d.now()
+	status, sendErr := d.send(ctx, hook, delivery)
+	if ctx.Err() != nil {
+		// Shutting down: the receiver never got a fair chance.
+		return false
+	}
+
+	attempt := delivery.RecordAttempt(d.now(), status, sendErr, d.now().Sub(start))
+	err = d.uow.Do(ctx, func(repos interfaces.Repositories) error {
+		return repos.WebhookDeliveries.RecordAttempt(ctx, delivery, attempt)
+	})
+	if err != nil {
+		d.logger.Error("failed_to_record_webhook_attempt",
+			slog.String("delivery_id", delivery.ID()),
+			slog.Any("error", err),
+		)
+	}
+
+	attrs := []any{
+		slog.String("delivery_id", delivery.ID()),
+		slog.String("webhook_id", hook.ID().String()),
+		slog.String("event", delivery.Event()),
+		slog.Int("attempt", attempt.Number),
+		slog.Int("status", attempt.StatusCode),
+	}
+	switch delivery.Status() {
+	case webhook.StatusDelivered:
+		d.logger.Info("webhook_delivered", attrs...)
+	case webhook.StatusFailed:
+		d.logger.Warn("webhook_delivery_abandoned", append(attrs, slog.String("error", attempt.Error))...)
+	default:
+		d.logger.Warn("webhook_delivery_failed", append(attrs,
+			slog.String("error", attempt.Error),
+			slog.Time("next_attempt_at", delivery.NextAttemptAt()),
+		)...)
+	}
+	return true
+}
+
+// send POSTs the signed payload and returns the response status.
+func (d *Dispatcher) send(ctx context.Context, hook *webhook.Webhook, delivery *webhook.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL(), bytes.NewReader(delivery.Payload()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventHeader, delivery.Event())
+	req.Header.Set(DeliveryHeader, delivery.ID())
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(hook.Secret(), d.now(), delivery.Payload()))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,212 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"peso/internal/domain/webhook"
+	"peso/internal/infrastructure/persistence"
+)
+
+// receiver is a local stand-in for a webhook consumer. It verifies every
+// signature and answers with the queued status codes, then 204.
+type receiver struct {
+	t      *testing.T
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	events   []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := webhook.Verify(rc.secret, r.Header.Get(webhook.SignatureHeader), body, time.Hour); err != nil {
+		rc.t.Errorf("expected a valid signature, got %v", err)
+	}
+	if r.Header.Get(DeliveryHeader) == "" {
+		rc.t.Error("expected a delivery ID header")
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.events = append(rc.events, r.Header.Get(EventHeader))
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func setup(t *testing.T, rc *receiver) (*Dispatcher, *webhook.Webhook) {
+	t.Helper()
+
+	db, err := persistence.NewDB(filepath.Join(t.TempDir(), "peso.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(os.DirFS("../../../migrations/sqlite")); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	hook, err := webhook.NewWebhook("giada", server.URL+"/hook", []string{"goal.achieved"})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	rc.secret = hook.Secret()
+
+	webhooks := persistence.NewWebhookRepository(db)
+	if err := webhooks.Save(context.Background(), hook); err != nil {
+		t.Fatalf("failed to save webhook: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := NewDispatcher(webhooks, persistence.NewWebhookDeliveryRepository(db), db, false, logger)
+	// The receiver listens on loopback, which deliveries may not reach.
+	d.client.Transport = http.DefaultTransport
+	return d, hook
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{t: t, statuses: []int{http.StatusInternalServerError, http.StatusFound}}
+	d, hook := setup(t, rc)
+
+	delivery := webhook.NewDelivery(hook.ID(), "giada", "goal.achieved", []byte(`{"type":"goal.achieved"}`))
+	if err := d.deliveries.Enqueue(ctx, delivery); err != nil {
+		t.Fatalf("failed to enqueue delivery: %v", err)
+	}
+
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	steps := []struct {
+		advance       time.Duration
+		wantAttempted int
+		wantStatus    webhook.Status
+	}{
+		{advance: 0, wantAttempted: 1, wantStatus: webhook.StatusPending},
+		{advance: webhook.Backoff(1) - time.Second, wantAttempted: 0, wantStatus: webhook.StatusPending},
+		{advance: 2 * time.Second, wantAttempted: 1, wantStatus: webhook.StatusPending},
+		{advance: webhook.Backoff(2), wantAttempted: 1, wantStatus: webhook.StatusDelivered},
+		{advance: time.Hour, wantAttempted: 0, wantStatus: webhook.StatusDelivered},
+	}
+
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		attempted, err := d.DeliverDue(ctx)
+		if err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if attempted != step.wantAttempted {
+			t.Errorf("step %d: expected %d attempts, got %d", i, step.wantAttempted, attempted)
+		}
+
+		history, err := d.deliveries.FindByWebhookID(ctx, hook.ID(), 10)
+		if err != nil || len(history) != 1 {
+			t.Fatalf("step %d: expected 1 delivery, got %d (err %v)", i, len(history), err)
+		}
+		if history[0].Status() != step.wantStatus {
+			t.Errorf("step %d: expected status %s, got %s", i, step.wantStatus, history[0].Status())
+		}
+	}
+
+	history, _ := d.deliveries.FindByWebhookID(ctx, hook.ID(), 10)
+	if history[0].Attempts() != 3 || history[0].LastStatusCode() != http.StatusNoContent {
+		t.Errorf("expected delivery after 3 attempts, got %d ending in %d", history[0].Attempts(), history[0].LastStatusCode())
+	}
+	if len(rc.events) != 3 || rc.events[0] != "goal.achieved" {
+		t.Errorf("expected 3 goal.achieved requests, got %v", rc.events)
+	}
+}
+
+func TestDispatcher_UnreachableReceiver(t *testing.T) {
+	ctx := context.Background()
+	d, _ := setup(t, &receiver{t: t})
+
+	hook, err := webhook.NewWebhook("giada", "http://127.0.0.1:1/unreachable", []string{"goal.set"})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	if err := d.webhooks.Save(ctx, hook); err != nil {
+		t.Fatalf("failed to save webhook: %v", err)
+	}
+	if err := d.deliveries.Enqueue(ctx, webhook.NewDelivery(hook.ID(), "giada", "goal.set", []byte(`{}`))); err != nil {
+		t.Fatalf("failed to enqueue delivery: %v", err)
+	}
+
+	if attempted, err := d.DeliverDue(ctx); err != nil || attempted != 1 {
+		t.Fatalf("expected 1 attempt, got %d (err %v)", attempted, err)
+	}
+
+	history, _ := d.deliveries.FindByWebhookID(ctx, hook.ID(), 10)
+	if len(history) != 1 || history[0].Status() != webhook.StatusPending || history[0].LastError() == "" {
+		t.Errorf("expected a pending retry with the connection error recorded, got %+v", history)
+	}
+}
+
+func TestDispatcher_RefusesLoopback(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{t: t}
+	d, hook := setup(t, rc)
+	d.client = newClient(true)
+
+	if err := d.deliveries.Enqueue(ctx, webhook.NewDelivery(hook.ID(), "giada", "goal.achieved", []byte(`{}`))); err != nil {
+		t.Fatalf("failed to enqueue delivery: %v", err)
+	}
+	if attempted, err := d.DeliverDue(ctx); err != nil || attempted != 1 {
+		t.Fatalf("expected 1 attempt, got %d (err %v)", attempted, err)
+	}
+
+	history, _ := d.deliveries.FindByWebhookID(ctx, hook.ID(), 10)
+	if len(history) != 1 || !strings.Contains(history[0].LastError(), errForbiddenAddress.Error()) {
+		t.Errorf("expected the delivery to be refused, got %+v", history)
+	}
+	if len(rc.events) != 0 {
+		t.Errorf("expected no request to reach the receiver, got %v", rc.events)
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	tests := []struct {
+		addr         string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{addr: "203.0.113.10", wantErr: false},
+		{addr: "2001:db8::1", wantErr: false},
+		{addr: "127.0.0.1", allowPrivate: true, wantErr: true},
+		{addr: "::1", allowPrivate: true, wantErr: true},
+		{addr: "::ffff:127.0.0.1", allowPrivate: true, wantErr: true},
+		{addr: "169.254.169.254", allowPrivate: true, wantErr: true},
+		{addr: "fe80::1", allowPrivate: true, wantErr: true},
+		{addr: "0.0.0.0", allowPrivate: true, wantErr: true},
+		{addr: "::", allowPrivate: true, wantErr: true},
+		{addr: "192.168.1.10", wantErr: true},
+		{addr: "10.0.0.5", wantErr: true},
+		{addr: "fd00::5", wantErr: true},
+		{addr: "192.168.1.10", allowPrivate: true, wantErr: false},
+		{addr: "fd00::5", allowPrivate: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		err := checkAddress(netip.MustParseAddr(tt.addr), tt.allowPrivate)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkAddress(%s, allowPrivate=%v): expected error %v, got %v", tt.addr, tt.allowPrivate, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"peso/internal/domain/event"
+)
+
+// EventPublisher dispatches domain events to whoever subscribed to them.
+// Publishing never fails the caller: the change behind the event has
+// already been committed, so subscriber errors are the publisher's to
+// handle.
+type EventPublisher interface {
+	Publish(ctx context.Context, events ...event.Event)
+}
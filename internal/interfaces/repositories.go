@@ -1,51 +1,149 @@
 package interfaces
 
 import (
+	"context"
+	"errors"
 	"time"
 
+	"peso/internal/domain/audit"
 	"peso/internal/domain/goal"
+	"peso/internal/domain/idempotency"
 	"peso/internal/domain/session"
 	"peso/internal/domain/user"
+	"peso/internal/domain/webhook"
 	"peso/internal/domain/weight"
 )
 
+// ErrConflict is returned by repositories when a write violates a
+// uniqueness constraint.
+var ErrConflict = errors.New("conflicting record")
+
 // UserRepository defines the interface for user persistence
 type UserRepository interface {
-	Save(user *user.User) error
-	FindByID(id user.UserID) (*user.User, error)
-	FindByEmail(email string) (*user.User, error)
-	FindByName(name string) (*user.User, error)
-	FindActive() ([]*user.User, error)
-	Exists(id user.UserID) (bool, error)
-	EmailExists(email string) (bool, error)
+	Save(ctx context.Context, user *user.User) error
+	FindByID(ctx context.Context, id user.UserID) (*user.User, error)
+	FindByEmail(ctx context.Context, email string) (*user.User, error)
+	FindByName(ctx context.Context, name string) (*user.User, error)
+	FindActive(ctx context.Context) ([]*user.User, error)
+	Exists(ctx context.Context, id user.UserID) (bool, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
 }
 
 // SessionRepository defines the interface for session persistence
 type SessionRepository interface {
-	Save(session *session.Session) error
-	FindByToken(token string) (*session.Session, error)
-	DeleteByToken(token string) error
-	DeleteByUserID(userID user.UserID) error
-	DeleteExpired() error
+	Save(ctx context.Context, session *session.Session) error
+	FindByID(ctx context.Context, id session.SessionID) (*session.Session, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*session.Session, error)
+	FindActiveByUserID(ctx context.Context, userID user.UserID) ([]*session.Session, error)
+	DeleteByID(ctx context.Context, id session.SessionID) error
+	DeleteByTokenHash(ctx context.Context, tokenHash string) error
+	DeleteByUserID(ctx context.Context, userID user.UserID) error
+	DeleteByUserIDExcept(ctx context.Context, userID user.UserID, keep session.SessionID) error
+	DeleteExpired(ctx context.Context) (int64, error)
+	CountActive(ctx context.Context) (int64, error)
 }
 
 // WeightRepository defines the interface for weight persistence
 type WeightRepository interface {
-	Save(weight *weight.Weight) error
-	FindByID(id weight.WeightID) (*weight.Weight, error)
-	FindByUserID(userID user.UserID, limit int) ([]*weight.Weight, error)
-	FindByUserIDAndPeriod(userID user.UserID, from, to time.Time) ([]*weight.Weight, error)
-	FindLatestByUserID(userID user.UserID) (*weight.Weight, error)
-	CountByUserIDAndDate(userID user.UserID, date time.Time) (int, error)
-	Delete(id weight.WeightID) error
+	// Save inserts a new weight. Weights are never edited, so an ID that is
+	// already taken is an error rather than an update.
+	Save(ctx context.Context, weight *weight.Weight) error
+	FindByID(ctx context.Context, id weight.WeightID) (*weight.Weight, error)
+	FindByUserID(ctx context.Context, userID user.UserID, limit int) ([]*weight.Weight, error)
+	FindByUserIDAndPeriod(ctx context.Context, userID user.UserID, from, to time.Time) ([]*weight.Weight, error)
+	FindLatestByUserID(ctx context.Context, userID user.UserID) (*weight.Weight, error)
+	CountByUserIDAndDate(ctx context.Context, userID user.UserID, date time.Time) (int, error)
+	// Delete is a soft delete: the weight disappears from every query but
+	// can be restored until it is purged.
+	Delete(ctx context.Context, id weight.WeightID) error
+	Restore(ctx context.Context, userID user.UserID, id weight.WeightID) error
+	// PurgeDeleted permanently removes weights deleted before the cutoff.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
 // GoalRepository defines the interface for goal persistence
 type GoalRepository interface {
-	Save(goal *goal.Goal) error
-	FindByID(id goal.GoalID) (*goal.Goal, error)
-	FindActiveByUserID(userID user.UserID) (*goal.Goal, error)
-	FindByUserID(userID user.UserID) ([]*goal.Goal, error)
-	DeactivateByUserID(userID user.UserID) error
-	Delete(id goal.GoalID) error
+	// Create inserts a new goal and fails if its ID is already taken.
+	Create(ctx context.Context, goal *goal.Goal) error
+	// Save updates a goal, inserting it if it does not exist yet.
+	Save(ctx context.Context, goal *goal.Goal) error
+	FindByID(ctx context.Context, id goal.GoalID) (*goal.Goal, error)
+	FindActiveByUserID(ctx context.Context, userID user.UserID) (*goal.Goal, error)
+	FindByUserID(ctx context.Context, userID user.UserID) ([]*goal.Goal, error)
+	DeactivateByUserID(ctx context.Context, userID user.UserID) error
+	// Delete is a soft delete: the goal disappears from every query but can
+	// be restored until it is purged.
+	Delete(ctx context.Context, id goal.GoalID) error
+	Restore(ctx context.Context, userID user.UserID, id goal.GoalID) error
+	// PurgeDeleted permanently removes goals deleted before the cutoff.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AuditQuery filters audit events. Zero fields match everything. Results
+// are newest first; BeforeID pages back from an earlier result.
+type AuditQuery struct {
+	UserID   user.UserID
+	Action   audit.Action
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64
+	Limit    int
+}
+
+// AuditRepository defines the interface for the append-only audit log
+type AuditRepository interface {
+	Append(ctx context.Context, event *audit.Event) error
+	Find(ctx context.Context, query AuditQuery) ([]*audit.Event, error)
+}
+
+// WebhookRepository defines the interface for webhook persistence
+type WebhookRepository interface {
+	Save(ctx context.Context, webhook *webhook.Webhook) error
+	FindByID(ctx context.Context, id webhook.WebhookID) (*webhook.Webhook, error)
+	FindByUserID(ctx context.Context, userID user.UserID) ([]*webhook.Webhook, error)
+	// Delete removes the webhook together with its queued deliveries.
+	Delete(ctx context.Context, id webhook.WebhookID) error
+}
+
+// WebhookDeliveryRepository is the persistent queue of webhook deliveries
+type WebhookDeliveryRepository interface {
+	Enqueue(ctx context.Context, delivery *webhook.Delivery) error
+	// ClaimDue leases up to limit pending deliveries due at now by moving
+	// their next attempt to leaseUntil, so concurrent dispatchers skip
+	// them. A delivery whose dispatcher dies is retried once the lease
+	// expires.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*webhook.Delivery, error)
+	// RecordAttempt stores an attempt and the delivery state it led to.
+	RecordAttempt(ctx context.Context, delivery *webhook.Delivery, attempt webhook.Attempt) error
+	FindByWebhookID(ctx context.Context, id webhook.WebhookID, limit int) ([]*webhook.Delivery, error)
+}
+
+// IdempotencyRepository stores the results of requests made with an
+// idempotency key
+type IdempotencyRepository interface {
+	// Find returns nil when key has not been used by userID.
+	Find(ctx context.Context, userID user.UserID, key string) (*idempotency.Record, error)
+	// Save fails with ErrConflict when the key is already taken.
+	Save(ctx context.Context, record *idempotency.Record) error
+	// Purge removes records created before the cutoff.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Repositories groups the repositories available inside a unit of work.
+type Repositories struct {
+	Users             UserRepository
+	Weights           WeightRepository
+	Goals             GoalRepository
+	Sessions          SessionRepository
+	Audit             AuditRepository
+	Webhooks          WebhookRepository
+	WebhookDeliveries WebhookDeliveryRepository
+	Idempotency       IdempotencyRepository
+}
+
+// UnitOfWork runs fn atomically: every repository passed to fn shares one
+// transaction, which is committed if fn returns nil and rolled back
+// otherwise. Repositories must be called with the ctx passed to Do.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos Repositories) error) error
 }
@@ -0,0 +1,5 @@
+-- Only remove the default users if they never recorded anything
+DELETE FROM users
+WHERE id IN ('giada', 'emilio')
+  AND id NOT IN (SELECT user_id FROM weights)
+  AND id NOT IN (SELECT user_id FROM goals)
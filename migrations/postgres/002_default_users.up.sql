@@ -0,0 +1,3 @@
+-- Insert default users (Giada and Emilio)
+INSERT INTO users (id, name, active) VALUES ('giada', 'Giada', TRUE) ON CONFLICT (id) DO NOTHING;
+INSERT INTO users (id, name, active) VALUES ('emilio', 'Emilio', TRUE) ON CONFLICT (id) DO NOTHING;
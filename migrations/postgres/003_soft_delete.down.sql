@@ -0,0 +1,9 @@
+-- Rows still waiting in the trash are dropped for good
+DELETE FROM weights WHERE deleted_at IS NOT NULL;
+DELETE FROM goals WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_goals_one_active_per_user;
+ALTER TABLE goals DROP COLUMN deleted_at;
+ALTER TABLE weights DROP COLUMN deleted_at;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_goals_one_active_per_user ON goals(user_id) WHERE active = TRUE;
@@ -0,0 +1,2 @@
+-- Headers replayed along with a stored response, as a JSON object
+ALTER TABLE idempotency_keys ADD COLUMN response_headers TEXT NOT NULL DEFAULT '';
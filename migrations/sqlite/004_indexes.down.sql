@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_weights_user_id;
+DROP INDEX IF EXISTS idx_weights_measured_at;
+DROP INDEX IF EXISTS idx_weights_user_measured;
+DROP INDEX IF EXISTS idx_goals_user_id;
+DROP INDEX IF EXISTS idx_goals_active;
+DROP INDEX IF EXISTS idx_goals_user_active
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_sessions_expires;
+DROP INDEX IF EXISTS idx_sessions_token;
+DROP TABLE IF EXISTS sessions;
+
+ALTER TABLE users DROP COLUMN password_hash;
@@ -0,0 +1,9 @@
+-- Track the device behind each session so users can review and revoke them
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN ip_address TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN device_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN last_seen_at DATETIME;
+
+UPDATE sessions SET last_seen_at = created_at WHERE last_seen_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
@@ -0,0 +1,6 @@
+-- Hashes cannot be turned back into tokens, so every session is logged out
+DELETE FROM sessions;
+
+DROP INDEX IF EXISTS idx_sessions_token_hash;
+ALTER TABLE sessions RENAME COLUMN token_hash TO token;
+CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
@@ -0,0 +1,7 @@
+-- Keep only a SHA-256 of each session token so a leaked database copy
+-- cannot be used to log in. peso_sha256 is registered by the application.
+UPDATE sessions SET token = peso_sha256(token);
+ALTER TABLE sessions RENAME COLUMN token TO token_hash;
+
+DROP INDEX IF EXISTS idx_sessions_token;
+CREATE INDEX IF NOT EXISTS idx_sessions_token_hash ON sessions(token_hash);
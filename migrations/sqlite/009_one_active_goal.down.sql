@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_goals_one_active_per_user;
@@ -0,0 +1,12 @@
+-- Keep only the newest active goal per user, then let the database enforce it
+UPDATE goals SET active = FALSE, updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC, id DESC) AS rn
+        FROM goals
+        WHERE active = TRUE
+    )
+    WHERE rn > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_goals_one_active_per_user ON goals(user_id) WHERE active = TRUE;
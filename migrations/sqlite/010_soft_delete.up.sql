@@ -0,0 +1,10 @@
+-- Deleted weights and goals are kept for a grace period so they can be restored
+ALTER TABLE weights ADD COLUMN deleted_at DATETIME;
+ALTER TABLE goals ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_weights_deleted_at ON weights(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_goals_deleted_at ON goals(deleted_at);
+
+-- A deleted goal no longer blocks setting a new one
+DROP INDEX IF EXISTS idx_goals_one_active_per_user;
+CREATE UNIQUE INDEX idx_goals_one_active_per_user ON goals(user_id) WHERE active = TRUE AND deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- Interface language chosen by the user; empty follows the browser
+ALTER TABLE users ADD COLUMN language TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,143 @@
+// Offline queue for weigh-ins, shared by the dashboard and the service
+// worker. Entries are stored in IndexedDB with a client-generated key and
+// the time they were measured, and sent to POST /api/weights/batch. The
+// server records each key at most once, so sending an entry twice (after a
+// lost response, or from the page and the service worker at the same time)
+// is harmless.
+const PesoQueue = (() => {
+  const DB_NAME = 'peso';
+  const DB_VERSION = 1;
+  const ENTRIES = 'weights';
+  const META = 'meta';
+  const BATCH_SIZE = 100;
+  const SYNC_TAG = 'peso-weights';
+
+  function open() {
+    return new Promise((resolve, reject) => {
+      const req = indexedDB.open(DB_NAME, DB_VERSION);
+      req.onupgradeneeded = () => {
+        req.result.createObjectStore(ENTRIES, { keyPath: 'key' });
+        req.result.createObjectStore(META);
+      };
+      req.onsuccess = () => resolve(req.result);
+      req.onerror = () => reject(req.error);
+    });
+  }
+
+  async function run(store, mode, fn) {
+    const db = await open();
+    try {
+      return await new Promise((resolve, reject) => {
+        const tx = db.transaction(store, mode);
+        const req = fn(tx.objectStore(store));
+        tx.oncomplete = () => resolve(req && req.result);
+        tx.onerror = () => reject(tx.error);
+      });
+    } finally {
+      db.close();
+    }
+  }
+
+  // crypto.randomUUID needs a secure context; homelab installs are often
+  // served over plain HTTP.
+  function newKey() {
+    if (self.crypto && crypto.randomUUID) return crypto.randomUUID();
+    const b = crypto.getRandomValues(new Uint8Array(16));
+    b[6] = (b[6] & 0x0f) | 0x40;
+    b[8] = (b[8] & 0x3f) | 0x80;
+    const hex = Array.from(b, (x) => x.toString(16).padStart(2, '0')).join('');
+    return `${hex.slice(0, 8)}-${hex.slice(8, 12)}-${hex.slice(12, 16)}-${hex.slice(16, 20)}-${hex.slice(20)}`;
+  }
+
+  function add(userId, weight, measuredAt = new Date()) {
+    const entry = { key: newKey(), user_id: userId, weight, unit: 'kg', measured_at: measuredAt.toISOString() };
+    return run(ENTRIES, 'readwrite', (store) => store.put(entry)).then(() => entry);
+  }
+
+  function entries() {
+    return run(ENTRIES, 'readonly', (store) => store.getAll());
+  }
+
+  function remove(keys) {
+    return run(ENTRIES, 'readwrite', (store) => keys.forEach((key) => store.delete(key)));
+  }
+
+  // Entries the server rejected stay in the queue with its error, so a
+  // page can tell the user about them even when the service worker sent
+  // them. They are not sent again.
+  function markRejected(rejected) {
+    return run(ENTRIES, 'readwrite', (store) => rejected.forEach((e) => store.put(e)));
+  }
+
+  async function rejected(userId) {
+    return (await entries()).filter((e) => e.error && (!userId || e.user_id === userId));
+  }
+
+  // The service worker cannot read the page, so the page leaves the CSRF
+  // token for it.
+  function setToken(token) {
+    return run(META, 'readwrite', (store) => store.put(token, 'csrf'));
+  }
+
+  function getToken() {
+    return run(META, 'readonly', (store) => store.get('csrf'));
+  }
+
+  // Asks the service worker to flush the queue once the browser is back
+  // online, even if the page has been closed by then.
+  async function requestSync() {
+    if (!('serviceWorker' in navigator)) return;
+    const reg = await navigator.serviceWorker.ready;
+    if (reg.sync) await reg.sync.register(SYNC_TAG);
+  }
+
+  // Sends queued entries for userId, or for every user when it is omitted.
+  // Recorded entries leave the queue, rejected ones are kept for
+  // rejected() and the rest stay for the next attempt. Rejects when the
+  // server cannot be reached.
+  async function flush(userId, token) {
+    token = token || await getToken();
+    const pending = (await entries()).filter((e) => !e.error && (!userId || e.user_id === userId));
+    const outcome = { created: 0, duplicate: 0, rejected: 0, remaining: pending.length };
+
+    const byUser = new Map();
+    for (const e of pending) {
+      if (!byUser.has(e.user_id)) byUser.set(e.user_id, []);
+      byUser.get(e.user_id).push(e);
+    }
+
+    for (const [owner, queued] of byUser) {
+      for (let i = 0; i < queued.length; i += BATCH_SIZE) {
+        const batch = queued.slice(i, i + BATCH_SIZE);
+        const response = await fetch('/api/weights/batch', {
+          method: 'POST',
+          credentials: 'same-origin',
+          headers: {
+            'Content-Type': 'application/json',
+            'X-CSRF-Token': token || ''
+          },
+          body: JSON.stringify({
+            user_id: owner,
+            entries: batch.map(({ key, weight, unit, measured_at }) => ({ key, weight, unit, measured_at }))
+          })
+        });
+        // Logged out or someone else logged in: keep the entries for
+        // their owner.
+        if (response.status === 401 || response.status === 403) break;
+        if (!response.ok) throw new Error(`batch failed with status ${response.status}`);
+
+        const { results } = await response.json();
+        const sent = new Map(batch.map((e) => [e.key, e]));
+        await remove(results.filter((r) => r.status !== 'rejected').map((r) => r.key));
+        await markRejected(results
+          .filter((r) => r.status === 'rejected' && sent.has(r.key))
+          .map((r) => ({ ...sent.get(r.key), error: r.error || 'rejected' })));
+        for (const r of results) outcome[r.status] = (outcome[r.status] || 0) + 1;
+        outcome.remaining -= results.length;
+      }
+    }
+    return outcome;
+  }
+
+  return { SYNC_TAG, add, entries, flush, rejected, remove, setToken, requestSync };
+})();
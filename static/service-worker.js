@@ -1,7 +1,10 @@
-const CACHE_NAME = 'peso-v1';
+importScripts('/static/offline-queue.js');
+
+const CACHE_NAME = 'peso-v2';
 const STATIC_ASSETS = [
   '/',
   '/static/min.css',
+  '/static/offline-queue.js',
   '/static/favicon.svg',
   '/static/icon-192.svg',
   '/static/icon-512.svg'
@@ -30,19 +33,14 @@ self.addEventListener('activate', (event) => {
 });
 
 self.addEventListener('fetch', (event) => {
-  // Network-first for API calls
+  // Live update streams never end, so they must bypass the cache
+  if (event.request.headers.get('Accept') === 'text/event-stream') {
+    return;
+  }
+
+  // API calls go straight to the network, so the page sees real failures
+  // and can queue what it could not send
   if (event.request.url.includes('/api/')) {
-    event.respondWith(
-      fetch(event.request)
-        .then((response) => {
-          return response;
-        })
-        .catch(() => {
-          return new Response(JSON.stringify({ error: 'Offline' }), {
-            headers: { 'Content-Type': 'application/json' }
-          });
-        })
-    );
     return;
   }
 
@@ -73,3 +71,11 @@ self.addEventListener('fetch', (event) => {
     })
   );
 });
+
+// Weigh-ins queued while offline are sent once connectivity returns; a
+// failed flush makes the browser retry the sync later
+self.addEventListener('sync', (event) => {
+  if (event.tag === PesoQueue.SYNC_TAG) {
+    event.waitUntil(PesoQueue.flush());
+  }
+});